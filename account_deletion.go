@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+)
+
+// accountDeletionGracePeriod hesap silme talebinden anonimleştirmeye kadar geçen süre.
+// Bu süre boyunca kullanıcı giriş yapıp talebini geri alabilir.
+func accountDeletionGracePeriod() time.Duration {
+	return envDuration("ACCOUNT_DELETION_GRACE_DAYS", 24*time.Hour, 30*24*time.Hour)
+}
+
+// processAccountDeletions süresi dolan hesapları anonimleştirir ve Firebase kullanıcısını siler.
+// Firebase adımı başarısız olursa hesap 'anonymized' durumunda kalır ve sonraki turda tekrar denenir.
+func processAccountDeletions(ctx context.Context, repo AccountStore) error {
+	due, err := repo.getDueAccountDeletions(ctx, 100)
+	if err != nil {
+		return err
+	}
+
+	completed := 0
+	for userID, status := range due {
+		if status == deletionStatusPending {
+			if err := repo.anonymizeUser(ctx, userID); err != nil {
+				log.Printf("Hesap anonimleştirilemedi (user=%s): %v", userID, err)
+				continue
+			}
+		}
+
+		// Firebase'de zaten yoksa silinmiş say
+		firebaseErr := DeleteFirebaseUser(userID)
+		if firebaseErr != nil && auth.IsUserNotFound(firebaseErr) {
+			firebaseErr = nil
+		}
+		if firebaseErr != nil {
+			log.Printf("Firebase kullanıcısı silinemedi (user=%s): %v", userID, firebaseErr)
+		}
+
+		if err := repo.completeAccountDeletion(ctx, userID, firebaseErr); err != nil {
+			log.Printf("Hesap silme durumu kaydedilemedi (user=%s): %v", userID, err)
+			continue
+		}
+		if firebaseErr == nil {
+			completed++
+		}
+	}
+
+	if completed > 0 {
+		log.Printf("Silme işlemi tamamlanan hesap: %d", completed)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// Aktivite kaydındaki eylemler
+const (
+	activityGroupCreated        = "group.created"
+	activityMemberJoined        = "member.joined"
+	activityRequestSent         = "request.sent"
+	activityRequestAccepted     = "request.accepted"
+	activityRequestRejected     = "request.rejected"
+	activityExpenseCreated      = "expense.created"
+	activityExpenseDeleted      = "expense.deleted"
+	activityExpenseRestored     = "expense.restored"
+	activityExpensePurged       = "expense.purged"
+	activityPaymentSettled      = "payment.settled"
+	activityPaymentReminded     = "payment.reminded"
+	activityRemindersUpdated    = "reminder_settings.updated"
+	activityPlaceholderAdded    = "member.placeholder_added"
+	activityPlaceholderMerged   = "member.placeholder_merged"
+	activityExpensesImported    = "expenses.imported"
+	activityJoinRequested       = "request.join_requested"
+	activityInviteLinkCreated   = "invite_link.created"
+	activityInviteLinkRevoked   = "invite_link.revoked"
+	activityInviteLinkRotated   = "invite_link.rotated"
+	activityJoinSettingsUpdated = "join_settings.updated"
+	activityInvitationSent      = "invitation.sent"
+	activityRequestCancelled    = "request.cancelled"
+	activityRequestResent       = "request.resent"
+	activityRequestExpired      = "request.expired"
+)
+
+// sqlExecer hem *sql.DB hem *sql.Tx tarafından karşılanır; aktivite kaydı
+// işlemle aynı transaction içinde yazılabilsin diye kullanılır.
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// logGroupActivity grubun aktivite kaydına (sadece ekleme yapılan) bir satır yazar.
+// before/after nil ise NULL olarak saklanır; json.RawMessage olduğu gibi yazılır.
+func logGroupActivity(ctx context.Context, db sqlExecer, groupID int64, actorID, action, entityType, entityID string, before, after interface{}) error {
+	beforeJSON, err := activitySnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := activitySnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	var actor, entity interface{}
+	if actorID != "" {
+		actor = actorID
+	}
+	if entityID != "" {
+		entity = entityID
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO group_activity (group_id, actor_id, action, entity_type, entity_id, before_data, after_data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, groupID, actor, action, entityType, entity, beforeJSON, afterJSON)
+	if err != nil {
+		return fmt.Errorf("aktivite kaydı yazılamadı: %w", err)
+	}
+	return nil
+}
+
+func activitySnapshot(v interface{}) (interface{}, error) {
+	switch s := v.(type) {
+	case nil:
+		return nil, nil
+	case json.RawMessage:
+		if len(s) == 0 {
+			return nil, nil
+		}
+		return string(s), nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("aktivite verisi çözümlenemedi: %w", err)
+	}
+	return string(b), nil
+}
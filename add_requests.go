@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+var (
+	errAddRequestNotFound      = newDomainError(ErrNotFound, "error.request.not_found", "grup ekleme isteği bulunamadı")
+	errAddRequestNotPending    = newDomainError(ErrConflict, "error.request.not_pending", "grup ekleme isteği artık beklemede değil")
+	errAddRequestResendTooSoon = newDomainError(ErrRateLimited, "error.request.resend_rate_limited", "bu istek için bildirim kısa süre önce gönderildi")
+	errAddRequestForbidden     = newDomainError(ErrForbidden, "error.request.forbidden", "yetkisiz işlem: kullanıcı uyuşmazlığı")
+	errAddRequestExists        = newDomainError(ErrConflict, "error.request.already_pending", "bu kullanıcıya zaten bekleyen bir istek gönderilmiş")
+	errAlreadyMember           = newDomainError(ErrConflict, "error.request.already_member", "bu kullanıcı zaten grup üyesi")
+	errInvalidGroupID          = newDomainError(ErrValidation, "error.group.invalid_id", "geçersiz grup ID")
+)
+
+// addRequestTTL bekleyen isteklerin ne kadar sonra 'expired' sayılacağıdır
+func addRequestTTL() time.Duration {
+	return envDuration("ADD_REQUEST_TTL_DAYS", 24*time.Hour, 30*24*time.Hour)
+}
+
+// addRequestResendCooldown aynı istek için bildirimin en sık hangi aralıkla tekrar gönderilebileceğidir
+func addRequestResendCooldown() time.Duration {
+	return envDuration("ADD_REQUEST_RESEND_COOLDOWN_HOURS", time.Hour, 24*time.Hour)
+}
+
+// expireAddRequests süresi dolan bekleyen istekleri kapatır
+func expireAddRequests(ctx context.Context, repo AddRequestStore) error {
+	expired, err := repo.expirePendingAddRequests(ctx, int(addRequestTTL()/(24*time.Hour)), 500)
+	if expired > 0 {
+		log.Printf("Süresi dolan grup ekleme isteği: %d", expired)
+	}
+	return err
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Grup sorgularının ölçümü: group_loader.go'daki küme sorguları, yerlerini aldıkları eski tek
+// sorguyla (legacyGroupQuery) aynı SQLite veri kümesinde karşılaştırılır.
+//
+//	go test -run '^$' -bench 'GetMyGroups|GetGroupDetails' -benchtime 20x
+
+// benchmarkSize oluşturulacak veri kümesinin boyutudur
+type benchmarkSize struct {
+	groups           int
+	expensesPerGroup int
+	users            int
+	groupMembers     int
+	// userEvery ölçülen kullanıcının kaç grupta bir üye olduğudur
+	userEvery int
+}
+
+// 1000 grup, 100000 harcama; ölçülen kullanıcı 50 grubun üyesi
+var fullBenchmarkSize = benchmarkSize{groups: 1000, expensesPerGroup: 100, users: 2000, groupMembers: 6, userEvery: 20}
+
+// Eski grup sorgusu. Her liste (üye payları dahil) grup başına ilişkili bir alt sorguyla JSON
+// olarak toplanır; ilk iki parametre borç ve alacaklar için isteyen kullanıcının ID'sidir.
+var legacyGroupQuery = `
+	SELECT
+		g.id,
+		g.group_token,
+		g.group_name,
+		g.currency,
+		` + dialectSQLite.unix("g.created_at") + `,
+		u.id,
+		u.fullname,
+		u.email,
+
+		(
+			SELECT ` + dialectSQLite.jsonArrayAgg(`json_object(
+				'id', gm_user.id,
+				'fullname', gm_user.fullname,
+				'email', gm_user.email,
+				'total_share', COALESCE((
+					SELECT SUM(p.amount_share)
+					FROM group_expenses e
+					JOIN group_expense_participants p ON p.expense_id = e.expense_id
+					WHERE e.group_id = g.id AND p.user_id = gm_user.id AND e.deleted_at IS NULL
+				), 0)
+			)`) + `
+			FROM group_members gm
+			JOIN users gm_user ON gm.user_id = gm_user.id
+			WHERE gm.group_id = g.id
+		) AS members,
+
+		(
+			SELECT ` + dialectSQLite.jsonArrayAgg(`json_object(
+				'request_id', r.request_id,
+				'user_id', r.user_id,
+				'fullname', ru.fullname,
+				'email', ru.email,
+				'requested_at', `+dialectSQLite.unix("r.requested_at")+`,
+				'request_status', r.request_status,
+				'request_direction', r.request_direction,
+				'group_name', gr.group_name,
+				'group_id', gr.id
+			)`) + `
+			FROM group_add_requests r
+			JOIN users ru ON r.user_id = ru.id
+			JOIN groups gr ON r.group_id = gr.id
+			WHERE r.group_id = g.id AND r.request_status = 'pending'
+		) AS pending_requests,
+
+		(
+			SELECT ` + dialectSQLite.jsonArrayAgg(`json_object(
+				'expense_id', e.expense_id,
+				'group_id', e.group_id,
+				'amount', e.amount,
+				'description_note', e.description_note,
+				'payment_date', `+dialectSQLite.unix("e.payment_date")+`,
+				'payment_title', e.payment_title,
+				'bill_image_url', e.bill_image_url,
+				'payer_id', e.payer_id,
+				'payer_name', e.payer_name,
+				'participants', json(`+participantsJSON(dialectSQLite)+`)
+			)`) + `
+			FROM (
+				SELECT ge.*, pu.fullname AS payer_name
+				FROM group_expenses ge
+				LEFT JOIN users pu ON pu.id = ge.payer_id
+				WHERE ge.group_id = g.id AND ge.deleted_at IS NULL
+				ORDER BY ge.payment_date ASC
+			) e
+		) AS expenses,
+
+		` + balancesJSON(dialectSQLite, "g.id") + `
+
+	FROM groups g
+	JOIN users u ON g.creator_id = u.id
+`
+
+// scanLegacyGroup eski sorgunun satırını Group'a çevirir; NULL liste boş listedir
+func scanLegacyGroup(row interface{ Scan(...any) error }, currentUserID string) (*Group, error) {
+	var g Group
+	var token sql.NullString
+	var members, requests, expenses, debts, credits []byte
+	err := row.Scan(
+		&g.ID,
+		&token,
+		&g.Name,
+		&g.Currency,
+		&g.CreatedAt,
+		&g.Creator.ID,
+		&g.Creator.FullName,
+		&g.Creator.Email,
+		&members,
+		&requests,
+		&expenses,
+		&debts,
+		&credits,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if token.Valid {
+		g.GroupToken = &token.String
+	}
+	g.IsAdmin = g.Creator.ID == currentUserID
+
+	lists := []struct {
+		raw  []byte
+		dest interface{}
+	}{
+		{members, &g.Members},
+		{requests, &g.PendingRequests},
+		{expenses, &g.Expenses},
+		{debts, &g.Debts},
+		{credits, &g.Credits},
+	}
+	for _, list := range lists {
+		if list.raw == nil {
+			continue
+		}
+		if err := json.Unmarshal(list.raw, list.dest); err != nil {
+			return nil, fmt.Errorf("grup %d çözümlenemedi: %w", g.ID, err)
+		}
+	}
+	return &g, nil
+}
+
+func legacyLoadGroups(ctx context.Context, db *sql.DB, where, currentUserID string, arg interface{}) ([]*Group, error) {
+	rows, err := db.QueryContext(ctx, legacyGroupQuery+where, currentUserID, currentUserID, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*Group
+	for rows.Next() {
+		g, err := scanLegacyGroup(rows, currentUserID)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+func legacyGetMyGroups(ctx context.Context, db *sql.DB, userID string) ([]*Group, error) {
+	return legacyLoadGroups(ctx, db, `
+		JOIN group_members me ON me.group_id = g.id
+		WHERE me.user_id = ?
+		ORDER BY g.created_at DESC, g.id DESC
+	`, userID, userID)
+}
+
+func legacyGetGroupDetails(ctx context.Context, db *sql.DB, groupID, userID string) ([]*Group, error) {
+	return legacyLoadGroups(ctx, db, " WHERE g.id = ?", userID, groupID)
+}
+
+// benchmarkFixture veri kümesinin açıldığı depo, ölçülen kullanıcı ve onun gruplarından biridir
+type benchmarkFixture struct {
+	repo    *KasaRepository
+	userID  string
+	groupID string
+}
+
+// seedBenchmarkFixture bellekte bir SQLite veritabanı açar ve veri kümesini rastgele ama her
+// seferinde aynı biçimde oluşturur. Her harcamayı ödeyen ve iki üye paylaşır; harcamaların
+// %5'i silinmiş, grupların onda birinde bekleyen istek vardır.
+func seedBenchmarkFixture(tb testing.TB, size benchmarkSize) *benchmarkFixture {
+	tb.Helper()
+	repo, err := openSQLiteRepository(":memory:")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(1))
+	base := time.Now().UTC().Truncate(time.Second).Add(-365 * 24 * time.Hour)
+	userID := func(i int) string { return fmt.Sprintf("bench-u%d", i) }
+
+	var users, groups, members, requests, expenses, participants [][]interface{}
+	for i := 0; i < size.users; i++ {
+		users = append(users, []interface{}{userID(i), fmt.Sprintf("Kullanıcı %d", i), userID(i) + "@example.com", "TR00"})
+	}
+	for i := 0; i < size.groups; i++ {
+		groupID := int64(i + 1)
+
+		// Ölçülen kullanıcı (0) her userEvery grupta bir üyedir, diğer üyeler rastgele
+		picked := map[int]bool{}
+		var groupMembers []string
+		if i%size.userEvery == 0 {
+			picked[0] = true
+			groupMembers = append(groupMembers, userID(0))
+		}
+		for len(groupMembers) < size.groupMembers {
+			n := 1 + rng.Intn(size.users-1)
+			if !picked[n] {
+				picked[n] = true
+				groupMembers = append(groupMembers, userID(n))
+			}
+		}
+
+		groups = append(groups, []interface{}{groupID, fmt.Sprintf("Grup %d", i), groupMembers[0], fmt.Sprintf("bench-%d", i), base.Add(time.Duration(i) * time.Minute)})
+		for _, member := range groupMembers {
+			members = append(members, []interface{}{groupID, member})
+		}
+		if i%10 == 0 {
+			for {
+				n := 1 + rng.Intn(size.users-1)
+				if !picked[n] {
+					requests = append(requests, []interface{}{groupID, userID(n), base.Add(time.Duration(i) * time.Minute)})
+					break
+				}
+			}
+		}
+
+		for j := 0; j < size.expensesPerGroup; j++ {
+			expenseID := int64(i*size.expensesPerGroup + j + 1)
+			order := rng.Perm(len(groupMembers))[:3]
+			payer := groupMembers[order[0]]
+			amount := float64(300+rng.Intn(30000)) / 100
+			share := math.Round(amount/3*100) / 100
+
+			var deletedAt interface{}
+			if rng.Intn(20) == 0 {
+				deletedAt = base
+			}
+			expenses = append(expenses, []interface{}{expenseID, groupID, payer, amount, fmt.Sprintf("Harcama %d", j), base.Add(time.Duration(j) * time.Hour), deletedAt})
+			for k, idx := range order {
+				status := "unpaid"
+				amountShare := share
+				if k == 0 {
+					status = "paid"
+					amountShare = math.Round((amount-2*share)*100) / 100
+				} else if rng.Intn(2) == 0 {
+					status = "paid"
+				}
+				participants = append(participants, []interface{}{expenseID, groupMembers[idx], amountShare, status})
+			}
+		}
+	}
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer tx.Rollback()
+	inserts := []struct {
+		query string
+		rows  [][]interface{}
+	}{
+		{"INSERT INTO users (id, fullname, email, iban)", users},
+		{"INSERT INTO groups (id, group_name, creator_id, group_token, created_at)", groups},
+		{"INSERT INTO group_members (group_id, user_id)", members},
+		{"INSERT INTO group_add_requests (group_id, user_id, requested_at)", requests},
+		{"INSERT INTO group_expenses (expense_id, group_id, payer_id, amount, payment_title, payment_date, deleted_at)", expenses},
+		{"INSERT INTO group_expense_participants (expense_id, user_id, amount_share, payment_status)", participants},
+	}
+	for _, insert := range inserts {
+		if err := insertBatch(ctx, tx, insert.query, insert.rows); err != nil {
+			tb.Fatalf("veri kümesi oluşturulamadı: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		tb.Fatal(err)
+	}
+	return &benchmarkFixture{repo: repo, userID: userID(0), groupID: "1"}
+}
+
+// insertBatch satırları 500'erlik çok satırlı INSERT'lerle yazar
+func insertBatch(ctx context.Context, tx *sql.Tx, insert string, rows [][]interface{}) error {
+	const batchSize = 500
+	for start := 0; start < len(rows); start += batchSize {
+		batch := rows[start:min(start+batchSize, len(rows))]
+		placeholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(batch[0])), ", ") + ")"
+		values := make([]string, len(batch))
+		var args []interface{}
+		for i, row := range batch {
+			values[i] = placeholder
+			args = append(args, row...)
+		}
+		if _, err := tx.ExecContext(ctx, insert+" VALUES "+strings.Join(values, ", "), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Büyük veri kümesi bir kez oluşturulur ve iki ölçüm arasında paylaşılır
+var (
+	fullFixtureOnce sync.Once
+	fullFixture     *benchmarkFixture
+)
+
+func benchmarkFixtureFor(b *testing.B) *benchmarkFixture {
+	fullFixtureOnce.Do(func() { fullFixture = seedBenchmarkFixture(b, fullBenchmarkSize) })
+	if fullFixture == nil {
+		b.Fatal("veri kümesi oluşturulamadı")
+	}
+	return fullFixture
+}
+
+func BenchmarkGetMyGroups(b *testing.B) {
+	f := benchmarkFixtureFor(b)
+	ctx := context.Background()
+	b.Run("legacy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := legacyGetMyGroups(ctx, f.repo.DB, f.userID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("loader", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := f.repo.getMyGroups(f.userID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetGroupDetails(b *testing.B) {
+	f := benchmarkFixtureFor(b)
+	ctx := context.Background()
+	b.Run("legacy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := legacyGetGroupDetails(ctx, f.repo.DB, f.groupID, f.userID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("loader", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := f.repo.getGroupDetails(f.groupID, f.userID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// TestGroupLoaderMatchesLegacyQuery küme sorgularının eski sorguyla aynı grupları döndüğünü
+// küçük bir veri kümesinde doğrular
+func TestGroupLoaderMatchesLegacyQuery(t *testing.T) {
+	f := seedBenchmarkFixture(t, benchmarkSize{groups: 40, expensesPerGroup: 10, users: 60, groupMembers: 5, userEvery: 4})
+	defer f.repo.DB.Close()
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		legacy func() ([]*Group, error)
+		loader func() ([]*Group, error)
+	}{
+		{
+			name:   "getMyGroups",
+			legacy: func() ([]*Group, error) { return legacyGetMyGroups(ctx, f.repo.DB, f.userID) },
+			loader: func() ([]*Group, error) { return f.repo.getMyGroups(f.userID) },
+		},
+		{
+			name:   "getGroupDetails",
+			legacy: func() ([]*Group, error) { return legacyGetGroupDetails(ctx, f.repo.DB, f.groupID, f.userID) },
+			loader: func() ([]*Group, error) {
+				group, err := f.repo.getGroupDetails(f.groupID, f.userID)
+				return []*Group{group}, err
+			},
+		},
+	}
+	for _, c := range cases {
+		legacyGroups, err := c.legacy()
+		if err != nil {
+			t.Fatalf("%s (eski): %v", c.name, err)
+		}
+		loadedGroups, err := c.loader()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if len(loadedGroups) == 0 {
+			t.Fatalf("%s: grup dönmedi", c.name)
+		}
+		want, err := normalizeGroups(legacyGroups)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := normalizeGroups(loadedGroups)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s: yanıt eski sorgununkinden farklı\neski: %s\nyeni: %s", c.name, want, got)
+		}
+	}
+}
+
+// normalizeGroups sırası sözleşmede tanımlı olmayan listeleri sıralayıp JSON döner; eski sorguda
+// json_group_array sırası belirsiz olduğu için iki yolun sonucu bu biçimde karşılaştırılır
+func normalizeGroups(groups []*Group) ([]byte, error) {
+	for _, g := range groups {
+		sort.Slice(g.Members, func(i, j int) bool { return g.Members[i].ID < g.Members[j].ID })
+		for i := range g.Members {
+			g.Members[i].TotalShare = math.Round(g.Members[i].TotalShare*100) / 100
+		}
+		sort.Slice(g.PendingRequests, func(i, j int) bool { return g.PendingRequests[i].RequestID < g.PendingRequests[j].RequestID })
+		sort.Slice(g.Expenses, func(i, j int) bool { return g.Expenses[i].ExpenseID < g.Expenses[j].ExpenseID })
+		for _, e := range g.Expenses {
+			sort.Slice(e.Participants, func(i, j int) bool { return e.Participants[i].UserID < e.Participants[j].UserID })
+		}
+		sort.Slice(g.Debts, func(i, j int) bool { return g.Debts[i].Expenses[0] < g.Debts[j].Expenses[0] })
+		sort.Slice(g.Credits, func(i, j int) bool {
+			a, b := g.Credits[i], g.Credits[j]
+			return a.Expenses[0] < b.Expenses[0] || (a.Expenses[0] == b.Expenses[0] && a.UserID < b.UserID)
+		})
+	}
+	return json.Marshal(groups)
+}
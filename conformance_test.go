@@ -0,0 +1,2011 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// conformanceCheck uyumluluk testlerinden biridir
+type conformanceCheck struct {
+	name string
+	run  func(ctx context.Context, repo Repository, f *conformanceFixture) error
+}
+
+// conformanceFixture testlerin birbirine aktardığı kayıtlardır
+type conformanceFixture struct {
+	suffix    string
+	owner     string
+	member    string
+	outsider  string
+	groupID   int64
+	requestID int64
+	expenseID int64
+}
+
+func (f *conformanceFixture) group() string {
+	return fmt.Sprint(f.groupID)
+}
+
+// TestRepositoryConformance KasaRepository'nin davranışını her iki veritabanında da aynı olması
+// gereken senaryolarla dener. SQLite her zaman denenir; MySQL için KASA_TEST_MYSQL_DSN'e veritabanı
+// oluşturma yetkisi olan bir kullanıcının DSN'i verilir (örn. "kasa:sifre@tcp(localhost:3306)/").
+// Test bu sunucuda geçici bir veritabanı açar, migration'ları uygular ve sonunda veritabanını siler;
+// .env'deki veritabanına dokunulmaz.
+func TestRepositoryConformance(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		repo, err := openSQLiteRepository(":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { repo.DB.Close() })
+		runRepositoryConformance(t, repo)
+	})
+	t.Run("mysql", func(t *testing.T) {
+		dsn := os.Getenv("KASA_TEST_MYSQL_DSN")
+		if dsn == "" {
+			t.Skip("KASA_TEST_MYSQL_DSN verilmedi")
+		}
+		runRepositoryConformance(t, openMySQLTestRepository(t, dsn))
+	})
+}
+
+// TestConformanceCoversRepository Repository arayüzündeki her metodun uyumluluk testlerinde en az bir
+// kez çağrıldığını kontrol eder; böylece SQLite ile yerelde çalışan her sorgu MySQL'dekiyle karşılaştırılır
+func TestConformanceCoversRepository(t *testing.T) {
+	src, err := os.ReadFile("conformance_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := reflect.TypeOf((*Repository)(nil)).Elem()
+	for i := 0; i < typ.NumMethod(); i++ {
+		name := typ.Method(i).Name
+		if !bytes.Contains(src, []byte("repo."+name+"(")) {
+			t.Errorf("%s uyumluluk testlerinde çağrılmıyor", name)
+		}
+	}
+}
+
+// openMySQLTestRepository dsn'deki sunucuda geçici bir veritabanı açıp migration'ları uygular;
+// veritabanı test bitince silinir
+func openMySQLTestRepository(t *testing.T, dsn string) *KasaRepository {
+	t.Helper()
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("KASA_TEST_MYSQL_DSN geçersiz: %v", err)
+	}
+	cfg.DBName = ""
+	server, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	name := fmt.Sprintf("kasa_conformance_%d", time.Now().UnixNano())
+	if _, err := server.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatalf("test veritabanı oluşturulamadı: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := server.Exec("DROP DATABASE " + name); err != nil {
+			t.Errorf("test veritabanı silinemedi: %v", err)
+		}
+	})
+
+	cfg.DBName = name
+	cfg.ParseTime = true
+	cfg.MultiStatements = true
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("migration'lar uygulanamadı: %v", err)
+	}
+	return &KasaRepository{DB: db}
+}
+
+// runRepositoryConformance conformanceChecks'i sırayla çalıştırır. Testler birbirinin kayıtlarına
+// dayandığı için ilk hatada durulur. Yeni bir Repository metodu eklendiğinde buraya da test eklenmelidir;
+// TestConformanceCoversRepository eksik kalan metotları bulur.
+func runRepositoryConformance(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	f := &conformanceFixture{suffix: fmt.Sprint(time.Now().UnixNano())}
+	f.owner = "conf-owner-" + f.suffix
+	f.member = "conf-member-" + f.suffix
+	f.outsider = "conf-outsider-" + f.suffix
+
+	for _, check := range conformanceChecks {
+		ok := t.Run(check.name, func(t *testing.T) {
+			if err := check.run(ctx, repo, f); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if !ok {
+			return
+		}
+	}
+}
+
+func expect(cond bool, format string, args ...interface{}) error {
+	if cond {
+		return nil
+	}
+	return fmt.Errorf(format, args...)
+}
+
+var conformanceChecks = []conformanceCheck{
+	{"kullanıcı oluşturma ve okuma", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		for _, id := range []string{f.owner, f.member, f.outsider} {
+			if err := repo.CreateUser(id, "Kullanıcı "+id, id+"@example.com", "hash", "TR00", "en"); err != nil {
+				return err
+			}
+		}
+		user, err := repo.GetUserByID(f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(user.Email == f.owner+"@example.com" && user.Locale == "en" && user.DeletionStatus == "active" && !user.Deleted,
+			"beklenmeyen kullanıcı: %+v", user); err != nil {
+			return err
+		}
+		if _, err := repo.GetUserByID("conf-missing-" + f.suffix); !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("olmayan kullanıcı için sql.ErrNoRows beklenirdi, gelen: %v", err)
+		}
+
+		id, err := repo.GetUserIDByEmail(f.member + "@example.com")
+		if err != nil {
+			return err
+		}
+		if err := expect(id == f.member, "email ile bulunan ID %q, beklenen %q", id, f.member); err != nil {
+			return err
+		}
+		id, err = repo.GetUserIDByEmail("conf-missing-" + f.suffix + "@example.com")
+		if err != nil {
+			return err
+		}
+		return expect(id == "", "olmayan email için boş ID beklenirdi, gelen %q", id)
+	}},
+
+	{"kullanıcı güncelleme ve dil", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		err := repo.UpdateUser(&User{ID: f.owner, FullName: "Yönetici", IBAN: "TR01", Locale: "tr"})
+		if err != nil {
+			return err
+		}
+		locale, err := repo.GetUserLocale(ctx, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(locale == "tr", "dil %q, beklenen tr", locale); err != nil {
+			return err
+		}
+
+		// InsertUser var olan kullanıcıda sadece ad ve IBAN'ı günceller
+		err = repo.InsertUser(User{ID: f.owner, Email: f.owner + "@example.com", FullName: "Yönetici 2", IBAN: "TR02", Locale: "en"})
+		if err != nil {
+			return err
+		}
+		user, err := repo.GetUserByID(f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(user.FullName == "Yönetici 2" && user.IBAN == "TR02" && user.Locale == "tr", "InsertUser sonrası: %+v", user); err != nil {
+			return err
+		}
+
+		locale, err = repo.GetUserLocale(ctx, "conf-missing-"+f.suffix)
+		if err != nil {
+			return err
+		}
+		return expect(locale == defaultLocale, "olmayan kullanıcı için varsayılan dil beklenirdi, gelen %q", locale)
+	}},
+
+	{"grup oluşturma", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		groupID, err := repo.CreateGroup(f.owner, "Ev", "conf-token-"+f.suffix, "EUR")
+		if err != nil {
+			return err
+		}
+		f.groupID = groupID
+
+		creatorID, err := repo.getGroupCreatorID(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		if err := expect(creatorID == f.owner, "kurucu %q, beklenen %q", creatorID, f.owner); err != nil {
+			return err
+		}
+		if _, err := repo.getGroupCreatorID(ctx, -1); !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("olmayan grup için sql.ErrNoRows beklenirdi, gelen: %v", err)
+		}
+		currency, err := repo.getGroupCurrency(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		if err := expect(currency == "EUR", "para birimi %q, beklenen EUR", currency); err != nil {
+			return err
+		}
+		if _, err := repo.getGroupCurrency(ctx, -1); !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("olmayan grubun para birimi için sql.ErrNoRows beklenirdi, gelen: %v", err)
+		}
+
+		isMember, err := repo.isGroupMember(ctx, groupID, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(isMember, "kurucu grubun üyesi olmalı"); err != nil {
+			return err
+		}
+
+		group, err := repo.getGroupDetails(f.group(), f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(group.ID == groupID && group.Name == "Ev" && group.Currency == "EUR" && group.Creator.ID == f.owner &&
+			group.IsAdmin && group.GroupToken != nil && *group.GroupToken == "conf-token-"+f.suffix && group.CreatedAt > 0,
+			"beklenmeyen grup: %+v", group); err != nil {
+			return err
+		}
+		if err := expect(len(group.Members) == 1 && group.Members[0].ID == f.owner, "beklenmeyen üyeler: %+v", group.Members); err != nil {
+			return err
+		}
+		// Boş toplamalar MySQL'deki gibi NULL döner
+		if err := expect(group.Expenses == nil && group.PendingRequests == nil && group.Debts == nil && group.Credits == nil,
+			"boş grupta harcama/istek/borç NULL olmalı: %+v", group); err != nil {
+			return err
+		}
+
+		if _, err := repo.getGroupDetails("-1", f.owner); !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("olmayan grup için sql.ErrNoRows beklenirdi, gelen: %v", err)
+		}
+		return nil
+	}},
+
+	{"grup ekleme isteği gönderme", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		group, invitation, err := repo.sendAddGroupRequest(f.group(), f.member+"@example.com", f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(invitation == nil, "kayıtlı kullanıcı için email daveti açılmamalı"); err != nil {
+			return err
+		}
+		if err := expect(len(group.PendingRequests) == 1 && group.PendingRequests[0].UserID == f.member &&
+			group.PendingRequests[0].RequestDirection == "invite", "beklenmeyen bekleyen istekler: %+v", group.PendingRequests); err != nil {
+			return err
+		}
+
+		if _, _, err := repo.sendAddGroupRequest(f.group(), f.member+"@example.com", f.owner); !errors.Is(err, ErrConflict) {
+			return fmt.Errorf("aynı kullanıcıya ikinci bekleyen istek için ErrConflict beklenirdi, gelen: %v", err)
+		}
+
+		requests, err := repo.getMyAddRequests(f.member)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(requests) == 1 && requests[0].GroupID == f.groupID && requests[0].GroupName == "Ev" &&
+			requests[0].RequestStatus == "pending" && requests[0].RequestedAt > 0, "beklenmeyen istekler: %+v", requests); err != nil {
+			return err
+		}
+		f.requestID = requests[0].RequestID
+
+		none, err := repo.getMyAddRequests(f.outsider)
+		if err != nil {
+			return err
+		}
+		return expect(none != nil && len(none) == 0, "isteği olmayan kullanıcı için boş dizi beklenirdi: %#v", none)
+	}},
+
+	{"email daveti", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		email := "conf-new-" + f.suffix + "@example.com"
+		_, invitation, err := repo.sendAddGroupRequest(f.group(), email, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(invitation != nil && invitation.GroupID == f.groupID && invitation.Email == email &&
+			invitation.Status == "pending" && invitation.InvitedBy == f.owner && invitation.CreatedAt > 0,
+			"beklenmeyen davet: %+v", invitation); err != nil {
+			return err
+		}
+		if _, _, err := repo.sendAddGroupRequest(f.group(), email, f.owner); !errors.Is(err, errInvitationPending) {
+			return fmt.Errorf("ikinci davet için errInvitationPending beklenirdi, gelen: %v", err)
+		}
+
+		// Kayıt olunca davet bekleyen bir grup ekleme isteğine dönüşür
+		newUser := "conf-new-" + f.suffix
+		if err := repo.CreateUser(newUser, "Yeni", email, "hash", "TR00", "en"); err != nil {
+			return err
+		}
+		converted, err := repo.convertEmailInvitations(ctx, newUser, strings.ToUpper(email))
+		if err != nil {
+			return err
+		}
+		if err := expect(len(converted) == 1 && converted[0].InvitationID == invitation.InvitationID && converted[0].Status == "converted" &&
+			converted[0].RequestID != nil, "dönüştürülen davetler: %+v", converted); err != nil {
+			return err
+		}
+		requests, err := repo.getMyAddRequests(newUser)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(requests) == 1 && requests[0].RequestID == *converted[0].RequestID && requests[0].RequestStatus == "pending",
+			"davetten gelen istekler: %+v", requests); err != nil {
+			return err
+		}
+		if converted, err = repo.convertEmailInvitations(ctx, newUser, email); err != nil {
+			return err
+		}
+		if err := expect(len(converted) == 0, "dönüştürülmüş davet tekrar dönüşmemeli: %+v", converted); err != nil {
+			return err
+		}
+		// Sonraki testler grupta bekleyen istek olmadığını varsayar
+		_, err = repo.rejectAddRequest(requests[0].RequestID, newUser)
+		return err
+	}},
+
+	{"grup ekleme isteğini yanıtlama", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		if _, err := repo.acceptAddRequest(f.requestID, f.outsider); !errors.Is(err, ErrForbidden) {
+			return fmt.Errorf("başkasına gelen isteği kabul için ErrForbidden beklenirdi, gelen: %v", err)
+		}
+		decision, err := repo.acceptAddRequest(f.requestID, f.member)
+		if err != nil {
+			return err
+		}
+		if err := expect(decision.GroupID == f.groupID && decision.UserID == f.member && decision.Direction == "invite",
+			"beklenmeyen karar: %+v", decision); err != nil {
+			return err
+		}
+		if _, err := repo.acceptAddRequest(f.requestID, f.member); !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("işlenmiş isteği tekrar kabul için ErrNotFound beklenirdi, gelen: %v", err)
+		}
+		if _, err := repo.rejectAddRequest(f.requestID, f.member); !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("işlenmiş isteği reddetmek için ErrNotFound beklenirdi, gelen: %v", err)
+		}
+
+		isMember, err := repo.isGroupMember(ctx, f.groupID, f.member)
+		if err != nil {
+			return err
+		}
+		if err := expect(isMember, "kabul eden kullanıcı grubun üyesi olmalı"); err != nil {
+			return err
+		}
+
+		// Reddedilen istek üyelik açmaz
+		if _, _, err := repo.sendAddGroupRequest(f.group(), f.outsider+"@example.com", f.owner); err != nil {
+			return err
+		}
+		requests, err := repo.getMyAddRequests(f.outsider)
+		if err != nil {
+			return err
+		}
+		if len(requests) != 1 {
+			return fmt.Errorf("dışarıdaki kullanıcıya 1 istek beklenirdi, gelen %d", len(requests))
+		}
+		if _, err := repo.rejectAddRequest(requests[0].RequestID, f.outsider); err != nil {
+			return err
+		}
+		isMember, err = repo.isGroupMember(ctx, f.groupID, f.outsider)
+		if err != nil {
+			return err
+		}
+		if err := expect(!isMember, "reddeden kullanıcı grubun üyesi olmamalı"); err != nil {
+			return err
+		}
+
+		groups, err := repo.getMyGroups(f.member)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(groups) == 1 && groups[0].ID == f.groupID && !groups[0].IsAdmin, "üyenin grupları: %+v", groups); err != nil {
+			return err
+		}
+		if err := expect(len(groups[0].Members) == 2 && groups[0].PendingRequests == nil, "kabul sonrası grup: %+v", groups[0]); err != nil {
+			return err
+		}
+		groups, err = repo.getMyGroups(f.outsider)
+		if err != nil {
+			return err
+		}
+		return expect(len(groups) == 0, "dışarıdaki kullanıcının grubu olmamalı: %d", len(groups))
+	}},
+
+	{"harcama ekleme", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		ownerShare, memberShare := 40.0, 60.0
+		invalid := map[string][]ExpenseUser{
+			"üye olmayan katılımcı":  {{UserID: f.owner, Amount: &ownerShare}, {UserID: f.outsider, Amount: &memberShare}},
+			"ödeyen katılımcı değil": {{UserID: f.member, Amount: &memberShare}},
+		}
+		for name, users := range invalid {
+			_, err := repo.createGroupExpense(ctx, f.owner, CreateExpenseRequest{
+				GroupID: int(f.groupID), TotalAmount: 100, PaymentTitle: "Market", Users: users,
+			})
+			if !errors.Is(err, ErrValidation) {
+				return fmt.Errorf("%s için ErrValidation beklenirdi, gelen: %v", name, err)
+			}
+		}
+
+		result, err := repo.createGroupExpense(ctx, f.owner, CreateExpenseRequest{
+			GroupID:      int(f.groupID),
+			TotalAmount:  100,
+			Note:         "not",
+			PaymentTitle: "Market",
+			Users: []ExpenseUser{
+				{UserID: f.owner, Amount: &ownerShare},
+				{UserID: f.member, Amount: &memberShare},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		e := result.Expense
+		f.expenseID = e.ExpenseID
+		if err := expect(e.GroupID == f.groupID && e.PayerID == f.owner && e.Amount == 100 && e.PaymentTitle == "Market" &&
+			e.DescriptionNote == "not" && e.PaymentDate > 0, "beklenmeyen harcama: %+v", e); err != nil {
+			return err
+		}
+
+		statuses := map[string]string{}
+		for _, p := range e.Participants {
+			statuses[p.UserID] = p.PaymentStatus
+		}
+		if err := expect(len(e.Participants) == 2 && statuses[f.owner] == "paid" && statuses[f.member] == "unpaid",
+			"beklenmeyen katılımcılar: %+v", e.Participants); err != nil {
+			return err
+		}
+
+		credits, debts := len(result.Credits), len(result.Debts)
+		if err := expect(credits == 1 && debts == 0 && result.Debts != nil, "ödeyenin alacak/borç sayısı %d/%d, beklenen 1/0", credits, debts); err != nil {
+			return err
+		}
+
+		group, err := repo.getGroupDetails(f.group(), f.member)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(group.Expenses) == 1 && len(group.Expenses[0].Participants) == 2 && group.Credits == nil,
+			"üyenin gördüğü harcamalar: %+v", group.Expenses); err != nil {
+			return err
+		}
+		return expect(len(group.Debts) == 1 && group.Debts[0].UserID == f.owner && group.Debts[0].Amount == 60 &&
+			group.Debts[0].Status == "unpaid" && len(group.Debts[0].Expenses) == 1 && group.Debts[0].Expenses[0] == f.expenseID,
+			"üyenin borçları: %+v", group.Debts)
+	}},
+
+	{"harcama ödeme", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		if err := repo.PayGroupExpense(f.member, f.owner, f.groupID); err != nil {
+			return err
+		}
+		group, err := repo.getGroupDetails(f.group(), f.member)
+		if err != nil {
+			return err
+		}
+		return expect(len(group.Debts) == 1 && group.Debts[0].Status == "paid", "ödeme sonrası borçlar: %+v", group.Debts)
+	}},
+
+	{"harcama silme ve geri alma", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		if _, err := repo.deleteGroupExpense(ctx, f.owner, f.groupID+1, f.expenseID); !errors.Is(err, errExpenseNotFound) {
+			return fmt.Errorf("başka grubun yolundan silme için errExpenseNotFound beklenirdi, gelen: %v", err)
+		}
+		if _, err := repo.deleteGroupExpense(ctx, f.outsider, f.groupID, f.expenseID); !errors.Is(err, ErrForbidden) {
+			return fmt.Errorf("yetkisiz silme için ErrForbidden beklenirdi, gelen: %v", err)
+		}
+		result, err := repo.deleteGroupExpense(ctx, f.owner, f.groupID, f.expenseID)
+		if err != nil {
+			return err
+		}
+		credits := len(result.Credits)
+		if err := expect(result.Expense.ExpenseID == f.expenseID && credits == 0, "silme sonrası alacak sayısı %d, beklenen 0", credits); err != nil {
+			return err
+		}
+		if _, err := repo.deleteGroupExpense(ctx, f.owner, f.groupID, f.expenseID); !errors.Is(err, errExpenseAlreadyDeleted) {
+			return fmt.Errorf("ikinci silme için errExpenseAlreadyDeleted beklenirdi, gelen: %v", err)
+		}
+
+		deleted, err := repo.getDeletedExpenses(ctx, f.groupID)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(deleted) == 1 && deleted[0].ExpenseID == f.expenseID && deleted[0].DeletedBy == f.owner &&
+			deleted[0].DeletedAt > 0 && deleted[0].RestoreUntil > deleted[0].DeletedAt, "beklenmeyen silinmiş harcamalar: %+v", deleted); err != nil {
+			return err
+		}
+
+		group, err := repo.getGroupDetails(f.group(), f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(group.Expenses == nil, "silinmiş harcama grupta görünmemeli: %+v", group.Expenses); err != nil {
+			return err
+		}
+
+		if _, err := repo.restoreGroupExpense(ctx, f.member, f.groupID, f.expenseID); !errors.Is(err, ErrForbidden) {
+			return fmt.Errorf("harcamayı yapmayan üyenin geri alması için ErrForbidden beklenirdi, gelen: %v", err)
+		}
+		result, err = repo.restoreGroupExpense(ctx, f.owner, f.groupID, f.expenseID)
+		if err != nil {
+			return err
+		}
+		credits = len(result.Credits)
+		if err := expect(credits == 1, "geri alma sonrası alacak sayısı %d, beklenen 1", credits); err != nil {
+			return err
+		}
+		if _, err := repo.restoreGroupExpense(ctx, f.owner, f.groupID, f.expenseID); !errors.Is(err, errExpenseNotDeleted) {
+			return fmt.Errorf("silinmemiş harcama için errExpenseNotDeleted beklenirdi, gelen: %v", err)
+		}
+
+		deleted, err = repo.getDeletedExpenses(ctx, f.groupID)
+		if err != nil {
+			return err
+		}
+		return expect(len(deleted) == 0, "geri alınan harcama silinmişlerde görünmemeli: %d", len(deleted))
+	}},
+
+	{"silinen harcamaların kalıcı silinmesi", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		share := 10.0
+		result, err := repo.createGroupExpense(ctx, f.owner, CreateExpenseRequest{
+			GroupID: int(f.groupID), TotalAmount: 20, PaymentTitle: "Taksi",
+			Users: []ExpenseUser{{UserID: f.owner, Amount: &share}, {UserID: f.member, Amount: &share}},
+		})
+		if err != nil {
+			return err
+		}
+		expenseID := result.Expense.ExpenseID
+		if _, err := repo.deleteGroupExpense(ctx, f.owner, f.groupID, expenseID); err != nil {
+			return err
+		}
+		purged, err := repo.purgeDeletedExpenses(ctx, 10)
+		if err != nil {
+			return err
+		}
+		if err := expect(purged == 0, "süresi dolmayan %d harcama silindi", purged); err != nil {
+			return err
+		}
+
+		// Geri alma süresi veritabanı saatine göre geçmiş sayılır
+		window := int64(expenseRestoreWindow().Seconds())
+		if err := backdate(ctx, repo, "group_expenses", "deleted_at", window+60, "expense_id = ?", expenseID); err != nil {
+			return err
+		}
+		if _, err := repo.restoreGroupExpense(ctx, f.owner, f.groupID, expenseID); !errors.Is(err, errRestoreWindowExpired) {
+			return fmt.Errorf("süresi dolan harcama için errRestoreWindowExpired beklenirdi, gelen: %v", err)
+		}
+		deleted, err := repo.getDeletedExpenses(ctx, f.groupID)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(deleted) == 0, "süresi dolan harcama silinmişlerde görünmemeli: %+v", deleted); err != nil {
+			return err
+		}
+		if purged, err = repo.purgeDeletedExpenses(ctx, 10); err != nil {
+			return err
+		}
+		if err := expect(purged == 1, "%d harcama kalıcı silindi, beklenen 1", purged); err != nil {
+			return err
+		}
+		if _, err := repo.restoreGroupExpense(ctx, f.owner, f.groupID, expenseID); !errors.Is(err, errExpenseNotFound) {
+			return fmt.Errorf("kalıcı silinen harcama için errExpenseNotFound beklenirdi, gelen: %v", err)
+		}
+		return nil
+	}},
+
+	{"FCM token", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		token, err := repo.GetFCMTokenByUserID(ctx, f.member)
+		if err != nil {
+			return err
+		}
+		if err := expect(token == "", "kayıtsız token için boş string beklenirdi, gelen %q", token); err != nil {
+			return err
+		}
+		for _, want := range []string{"token-1", "token-2"} {
+			if err := repo.SaveFCMToken(f.member, want); err != nil {
+				return err
+			}
+			token, err = repo.GetFCMTokenByUserID(ctx, f.member)
+			if err != nil {
+				return err
+			}
+			if err := expect(token == want, "token %q, beklenen %q", token, want); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+
+	{"ETag ve koşullu istek", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		doc, err := loadOpenAPIDocument()
+		if err != nil {
+			return err
+		}
+		get := func(pattern string, h http.Handler, userID, ifNoneMatch string) (*httptest.ResponseRecorder, error) {
+			r := httptest.NewRequest("GET", pattern, nil).WithContext(context.WithValue(ctx, "userUID", userID))
+			if ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			return w, doc.validateResponse("GET", pattern, w.Code, w.Header().Get("Content-Type"), w.Body.Bytes())
+		}
+
+		first, err := get("/api/v1/groups", GetGroups(repo), f.member, "")
+		if err != nil {
+			return err
+		}
+		etag := first.Header().Get("ETag")
+		if err := expect(first.Code == http.StatusOK && etag != "" && first.Header().Get("Cache-Control") == "private, no-cache",
+			"ETag'li yanıt beklenirdi: durum %d, ETag %q", first.Code, etag); err != nil {
+			return err
+		}
+		cached, err := get("/api/v1/groups", GetGroups(repo), f.member, `W/"eski", `+etag)
+		if err != nil {
+			return err
+		}
+		if err := expect(cached.Code == http.StatusNotModified && cached.Body.Len() == 0, "değişmeyen veri için 304 beklenirdi: durum %d", cached.Code); err != nil {
+			return err
+		}
+
+		// Gruptaki harcama üyelerin sürümünü artırır, gruba üye olmayanınkini değiştirmez
+		outsiderBefore, err := repo.GetDataVersion(ctx, f.outsider)
+		if err != nil {
+			return err
+		}
+		share := 10.0
+		_, err = repo.createGroupExpense(ctx, f.owner, CreateExpenseRequest{
+			GroupID: int(f.groupID), TotalAmount: 20, PaymentTitle: "Kahve",
+			Users: []ExpenseUser{{UserID: f.owner, Amount: &share}, {UserID: f.member, Amount: &share}},
+		})
+		if err != nil {
+			return err
+		}
+		changed, err := get("/api/v1/groups", GetGroups(repo), f.member, etag)
+		if err != nil {
+			return err
+		}
+		if err := expect(changed.Code == http.StatusOK && changed.Header().Get("ETag") != etag, "harcamadan sonra yeni ETag beklenirdi: durum %d", changed.Code); err != nil {
+			return err
+		}
+		outsiderAfter, err := repo.GetDataVersion(ctx, f.outsider)
+		if err != nil {
+			return err
+		}
+		if err := expect(outsiderAfter == outsiderBefore, "gruba üye olmayanın sürümü değişmemeli: %d → %d", outsiderBefore, outsiderAfter); err != nil {
+			return err
+		}
+
+		me, err := get("/api/v1/me", getMeHandler(repo), f.member, "")
+		if err != nil {
+			return err
+		}
+		meCached, err := get("/api/v1/me", getMeHandler(repo), f.member, me.Header().Get("ETag"))
+		if err != nil {
+			return err
+		}
+		if err := expect(meCached.Code == http.StatusNotModified, "değişmeyen kullanıcı için 304 beklenirdi: durum %d", meCached.Code); err != nil {
+			return err
+		}
+
+		// Sahibin IBAN'ı üyenin grup yanıtında da görünür
+		owner, err := repo.GetUserByID(f.owner)
+		if err != nil {
+			return err
+		}
+		owner.IBAN = "TR04"
+		if err := repo.UpdateUser(owner); err != nil {
+			return err
+		}
+		afterUpdate, err := get("/api/v1/groups", GetGroups(repo), f.member, changed.Header().Get("ETag"))
+		if err != nil {
+			return err
+		}
+		return expect(afterUpdate.Code == http.StatusOK, "grup üyesi güncellenince 200 beklenirdi: durum %d", afterUpdate.Code)
+	}},
+
+	{"idempotency anahtarı", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		doc, err := loadOpenAPIDocument()
+		if err != nil {
+			return err
+		}
+		newRequest := func(key, groupName string) (*http.Request, []byte) {
+			body, _ := json.Marshal(CreateGroupRequest{GroupName: groupName})
+			r := httptest.NewRequest("POST", "/api/v1/groups", bytes.NewReader(body))
+			r.Header.Set("Idempotency-Key", key)
+			return r.WithContext(context.WithValue(ctx, "userUID", f.outsider)), body
+		}
+		send := func(h http.Handler, key, groupName string) (*httptest.ResponseRecorder, error) {
+			r, _ := newRequest(key, groupName)
+			w := httptest.NewRecorder()
+			IdempotencyMiddleware(h, repo, time.Hour).ServeHTTP(w, r)
+			return w, doc.validateResponse("POST", "/api/v1/groups", w.Code, w.Header().Get("Content-Type"), w.Body.Bytes())
+		}
+		countGroups := func() (int, error) {
+			groups, err := repo.getMyGroups(f.outsider)
+			return len(groups), err
+		}
+
+		// Sunucu hatası saklanmaz, aynı anahtarla tekrar deneme yeniden işlenir
+		key := "conf-" + f.suffix
+		failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+		})
+		if w, err := send(failing, key, "Tekrar"); err != nil || w.Code != http.StatusInternalServerError {
+			return fmt.Errorf("hata veren istek: durum %d: %v", w.Code, err)
+		}
+
+		before, err := countGroups()
+		if err != nil {
+			return err
+		}
+		first, err := send(CreateGroupHandler(repo), key, "Tekrar")
+		if err != nil {
+			return err
+		}
+		if err := expect(first.Code == http.StatusCreated && first.Header().Get("Idempotent-Replayed") == "", "ilk istek işlenmeliydi: durum %d", first.Code); err != nil {
+			return err
+		}
+		retry, err := send(CreateGroupHandler(repo), key, "Tekrar")
+		if err != nil {
+			return err
+		}
+		if err := expect(retry.Code == first.Code && retry.Body.String() == first.Body.String() && retry.Header().Get("Idempotent-Replayed") == "true",
+			"tekrar deneme ilk yanıtı dönmeliydi: durum %d: %s", retry.Code, retry.Body.String()); err != nil {
+			return err
+		}
+		after, err := countGroups()
+		if err != nil {
+			return err
+		}
+		if err := expect(after == before+1, "tekrar denemeden sonra %d grup, beklenen %d", after, before+1); err != nil {
+			return err
+		}
+		reused, err := send(CreateGroupHandler(repo), key, "Başka")
+		if err != nil {
+			return err
+		}
+		if err := expect(reused.Code == http.StatusUnprocessableEntity, "farklı istekle kullanılan anahtar: durum %d, beklenen 422", reused.Code); err != nil {
+			return err
+		}
+
+		// Yanıtı henüz kaydedilmemiş anahtar işleniyor sayılır
+		pending := "conf-pending-" + f.suffix
+		r, body := newRequest(pending, "Tekrar")
+		hash := requestFingerprint(r, body)
+		now := time.Now()
+		existing, err := repo.reserveIdempotencyKey(ctx, f.outsider, pending, hash, now, now.Add(time.Hour))
+		if err != nil {
+			return err
+		}
+		if err := expect(existing == nil, "yeni anahtar ayrılmalıydı"); err != nil {
+			return err
+		}
+		inProgress, err := send(CreateGroupHandler(repo), pending, "Tekrar")
+		if err != nil {
+			return err
+		}
+		if err := expect(inProgress.Code == http.StatusConflict, "işlenen anahtar: durum %d, beklenen 409", inProgress.Code); err != nil {
+			return err
+		}
+		if err := repo.releaseIdempotencyKey(ctx, f.outsider, pending); err != nil {
+			return err
+		}
+		if existing, err = repo.reserveIdempotencyKey(ctx, f.outsider, pending, hash, now, now.Add(time.Hour)); err != nil {
+			return err
+		}
+		if err := expect(existing == nil, "bırakılan anahtar tekrar ayrılmalıydı"); err != nil {
+			return err
+		}
+		// Yarıda kalan kayıt kilit süresi geçince yeniden ayrılabilir
+		later := now.Add(idempotencyLockTimeout + time.Second)
+		if existing, err = repo.reserveIdempotencyKey(ctx, f.outsider, pending, hash, later, later.Add(time.Hour)); err != nil {
+			return err
+		}
+		if err := expect(existing == nil, "yarıda kalan anahtar tekrar ayrılmalıydı"); err != nil {
+			return err
+		}
+
+		purged, err := repo.purgeExpiredIdempotencyKeys(ctx, now.Add(3*time.Hour))
+		if err != nil {
+			return err
+		}
+		if err := expect(purged >= 2, "süresi dolan %d kayıt silindi, en az 2 bekleniyordu", purged); err != nil {
+			return err
+		}
+		if existing, err = repo.reserveIdempotencyKey(ctx, f.outsider, key, hash, now, now.Add(time.Hour)); err != nil {
+			return err
+		}
+		if err := expect(existing == nil, "süresi dolan anahtar tekrar kullanılabilmeli"); err != nil {
+			return err
+		}
+		return repo.releaseIdempotencyKey(ctx, f.outsider, key)
+	}},
+
+	{"veri dışa aktarma kuyruğu", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		export, created, err := repo.createDataExport(ctx, f.owner)
+		if err != nil {
+			return err
+		}
+		again, createdAgain, err := repo.createDataExport(ctx, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(created && !createdAgain && again.ID == export.ID, "bekleyen talep tekrar dönmeliydi: %d %d", export.ID, again.ID); err != nil {
+			return err
+		}
+
+		claim := func() (bool, error) {
+			claimed, err := repo.claimPendingDataExports(ctx, 10, time.Hour)
+			for _, e := range claimed {
+				if e.ID == export.ID {
+					return e.Status == dataExportProcessing, err
+				}
+			}
+			return false, err
+		}
+		if ok, err := claim(); err != nil || !ok {
+			return fmt.Errorf("bekleyen talep alınmalıydı: %v", err)
+		}
+		if ok, err := claim(); err != nil || ok {
+			return fmt.Errorf("işlenen talep tekrar alınmamalıydı: %v", err)
+		}
+		// İşlenirken süreç kapanmış gibi: talep zaman aşımından sonra yeniden alınır
+		if err := backdate(ctx, repo, "data_exports", "claimed_at", 2*60*60, "id = ?", export.ID); err != nil {
+			return err
+		}
+		if ok, err := claim(); err != nil || !ok {
+			return fmt.Errorf("yarım kalan talep yeniden alınmalıydı: %v", err)
+		}
+
+		if err := repo.failDataExport(ctx, export.ID, errors.New("arşiv yazılamadı")); err != nil {
+			return err
+		}
+		export, created, err = repo.createDataExport(ctx, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(created, "başarısız talepten sonra yeni talep açılmalıydı"); err != nil {
+			return err
+		}
+		if ok, err := claim(); err != nil || !ok {
+			return fmt.Errorf("yeni talep alınmalıydı: %v", err)
+		}
+		token := "conf-export-" + f.suffix
+		if err := repo.completeDataExport(ctx, export.ID, token, "/tmp/yok.zip", time.Hour); err != nil {
+			return err
+		}
+		ready, err := repo.getDownloadableDataExport(ctx, token)
+		if err != nil {
+			return err
+		}
+		if err := expect(ready.ID == export.ID && ready.Status == dataExportReady && ready.ExpiresAt != nil, "hazır arşiv: %+v", ready); err != nil {
+			return err
+		}
+		exports, err := repo.getDataExports(ctx, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(exports) == 2 && exports[0].Status == dataExportReady && exports[1].Status == dataExportFailed, "talepler: %+v", exports); err != nil {
+			return err
+		}
+
+		if err := backdate(ctx, repo, "data_exports", "expires_at", 60, "id = ?", export.ID); err != nil {
+			return err
+		}
+		expired, err := repo.getExpiredDataExports(ctx, 10)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(expired) == 1 && expired[0].ID == export.ID && expired[0].filePath == "/tmp/yok.zip", "süresi dolan arşivler: %+v", expired); err != nil {
+			return err
+		}
+		if err := repo.markDataExportExpired(ctx, export.ID); err != nil {
+			return err
+		}
+		if _, err := repo.getDownloadableDataExport(ctx, token); err != sql.ErrNoRows {
+			return fmt.Errorf("süresi dolan arşiv indirilememeli, gelen: %v", err)
+		}
+
+		data, err := repo.collectUserData(ctx, f.owner)
+		if err != nil {
+			return err
+		}
+		return expect(data.Profile.ID == f.owner && len(data.Groups) > 0, "dışa aktarılan veri: %+v", data.Profile)
+	}},
+
+	{"arkadaşlar ve arkadaş harcamaları", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		if _, _, err := repo.sendFriendRequest(ctx, f.owner, f.owner+"@example.com"); !errors.Is(err, errFriendSelf) {
+			return fmt.Errorf("kendine istek için errFriendSelf beklenirdi, gelen: %v", err)
+		}
+		requestID, friendID, err := repo.sendFriendRequest(ctx, f.owner, f.member+"@example.com")
+		if err != nil {
+			return err
+		}
+		if err := expect(friendID == f.member, "istek %q kullanıcısına gitti, beklenen %q", friendID, f.member); err != nil {
+			return err
+		}
+		if _, _, err := repo.sendFriendRequest(ctx, f.member, f.owner+"@example.com"); !errors.Is(err, errFriendRequestPending) {
+			return fmt.Errorf("karşı yönden istek için errFriendRequestPending beklenirdi, gelen: %v", err)
+		}
+		requests, err := repo.getMyFriendRequests(ctx, f.member)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(requests) == 1 && requests[0].RequestID == requestID && requests[0].RequesterID == f.owner,
+			"bekleyen istekler: %+v", requests); err != nil {
+			return err
+		}
+		if _, err := repo.respondFriendRequest(ctx, requestID, f.owner, true); !errors.Is(err, errFriendRequestNotFound) {
+			return fmt.Errorf("isteği gönderen yanıtlayamamalı, gelen: %v", err)
+		}
+		if _, err := repo.respondFriendRequest(ctx, requestID, f.member, true); err != nil {
+			return err
+		}
+		friends, err := repo.areFriends(ctx, f.member, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(friends, "kabul sonrası arkadaş olmalıydı"); err != nil {
+			return err
+		}
+
+		share := 25.0
+		invalid := map[string]struct {
+			users []ExpenseUser
+			want  error
+		}{
+			"ödeyen katılımcı değil": {[]ExpenseUser{{UserID: f.member, Amount: &share}}, errPayerNotParticipant},
+			"yalnız ödeyen":          {[]ExpenseUser{{UserID: f.owner, Amount: &share}}, errParticipantNeeded},
+			"arkadaş olmayan":        {[]ExpenseUser{{UserID: f.owner, Amount: &share}, {UserID: f.outsider, Amount: &share}}, errNotFriends},
+		}
+		for name, c := range invalid {
+			_, err := repo.createFriendExpense(ctx, f.owner, CreateExpenseRequest{TotalAmount: 50, PaymentTitle: "Kahve", Users: c.users})
+			if !errors.Is(err, c.want) {
+				return fmt.Errorf("%s için %v beklenirdi, gelen: %v", name, c.want, err)
+			}
+		}
+
+		expense, err := repo.createFriendExpense(ctx, f.owner, CreateExpenseRequest{
+			TotalAmount: 50, PaymentTitle: "Kahve",
+			Users: []ExpenseUser{{UserID: f.owner, Amount: &share}, {UserID: f.member, Amount: &share}},
+		})
+		if err != nil {
+			return err
+		}
+		if err := expect(expense.GroupID == 0 && expense.PayerID == f.owner && len(expense.Participants) == 2, "arkadaş harcaması: %+v", expense); err != nil {
+			return err
+		}
+		expenses, err := repo.getFriendExpenses(ctx, f.member, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(expenses) == 1 && expenses[0].ExpenseID == expense.ExpenseID, "arkadaş harcamaları: %+v", expenses); err != nil {
+			return err
+		}
+
+		friendBalance := func(userID, otherID string) (float64, error) {
+			balances, err := repo.getCounterpartyBalances(ctx, userID)
+			for _, b := range balances {
+				if b.UserID == otherID {
+					return b.FriendBalance, err
+				}
+			}
+			return 0, err
+		}
+		balance, err := friendBalance(f.owner, f.member)
+		if err != nil {
+			return err
+		}
+		if err := expect(balance == 25, "ödeyenin arkadaş bakiyesi %v, beklenen 25", balance); err != nil {
+			return err
+		}
+		settled, err := repo.settleFriendBalance(ctx, f.member, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(settled == 1, "%d pay ödendi, beklenen 1", settled); err != nil {
+			return err
+		}
+		if balance, err = friendBalance(f.owner, f.member); err != nil {
+			return err
+		}
+		if err := expect(balance == 0, "hesaplaşma sonrası bakiye %v, beklenen 0", balance); err != nil {
+			return err
+		}
+
+		if err := repo.deleteFriendExpense(ctx, f.member, expense.ExpenseID); !errors.Is(err, errFriendExpenseNotFound) {
+			return fmt.Errorf("ödeyen olmayan silememeli, gelen: %v", err)
+		}
+		if err := repo.deleteFriendExpense(ctx, f.owner, expense.ExpenseID); err != nil {
+			return err
+		}
+		if err := repo.removeFriend(ctx, f.member, f.owner); err != nil {
+			return err
+		}
+		if err := repo.removeFriend(ctx, f.member, f.owner); !errors.Is(err, errNotFriends) {
+			return fmt.Errorf("ikinci silme için errNotFriends beklenirdi, gelen: %v", err)
+		}
+		return nil
+	}},
+
+	{"katılım onayı", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		joiner, late := "conf-joiner-"+f.suffix, "conf-late-"+f.suffix
+		for _, id := range []string{joiner, late} {
+			if err := repo.CreateUser(id, "Kullanıcı "+id, id+"@example.com", "hash", "TR00", "en"); err != nil {
+				return err
+			}
+		}
+		token := "conf-token-" + f.suffix
+		setApproval := func(on bool) error {
+			return repo.setGroupJoinApproval(ctx, f.owner, GroupJoinSettings{GroupID: f.groupID, RequiresApproval: on})
+		}
+		if err := setApproval(true); err != nil {
+			return err
+		}
+		settings, err := repo.getGroupJoinSettings(ctx, f.groupID)
+		if err != nil {
+			return err
+		}
+		if err := expect(settings.RequiresApproval, "katılım onayı açılmalıydı"); err != nil {
+			return err
+		}
+
+		joined, err := repo.addUserToGroupWithToken(joiner, token)
+		if err != nil {
+			return err
+		}
+		if err := expect(joined.Pending && joined.RequestID > 0 && joined.GroupID == f.groupID, "onay bekleyen katılım: %+v", joined); err != nil {
+			return err
+		}
+		if _, err := repo.addUserToGroupWithToken(joiner, token); !errors.Is(err, errJoinRequestPending) {
+			return fmt.Errorf("ikinci katılım için errJoinRequestPending beklenirdi, gelen: %v", err)
+		}
+		if _, err := repo.acceptAddRequest(joined.RequestID, joiner); !errors.Is(err, ErrForbidden) {
+			return fmt.Errorf("katılım isteğini isteyen kabul edememeli, gelen: %v", err)
+		}
+
+		// Onay kapatılınca kullanıcı doğrudan katılır; bekleyen isteği kabul etmek üyeliği tekrar eklemez
+		if err := setApproval(false); err != nil {
+			return err
+		}
+		requestID := joined.RequestID
+		if joined, err = repo.addUserToGroupWithToken(joiner, token); err != nil {
+			return err
+		}
+		if err := expect(!joined.Pending, "onay kapalıyken doğrudan katılmalıydı: %+v", joined); err != nil {
+			return err
+		}
+		if _, err := repo.acceptAddRequest(requestID, f.owner); !errors.Is(err, errAlreadyMember) {
+			return fmt.Errorf("üye olmuş kullanıcının isteği için errAlreadyMember beklenirdi, gelen: %v", err)
+		}
+		if _, err := repo.rejectAddRequest(requestID, f.owner); !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("kapatılan istek reddedilememeli, gelen: %v", err)
+		}
+
+		if err := setApproval(true); err != nil {
+			return err
+		}
+		pending, err := repo.addUserToGroupWithToken(late, token)
+		if err != nil {
+			return err
+		}
+		decision, err := repo.rejectAddRequest(pending.RequestID, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(decision.UserID == late && decision.Direction == requestDirectionJoin, "red kararı: %+v", decision); err != nil {
+			return err
+		}
+		if pending, err = repo.addUserToGroupWithToken(late, token); err != nil {
+			return err
+		}
+		if decision, err = repo.acceptAddRequest(pending.RequestID, f.owner); err != nil {
+			return err
+		}
+		isMember, err := repo.isGroupMember(ctx, f.groupID, late)
+		if err != nil {
+			return err
+		}
+		if err := expect(isMember && decision.UserID == late, "onaylanan kullanıcı üye olmalı: %+v", decision); err != nil {
+			return err
+		}
+		return setApproval(false)
+	}},
+
+	{"davet bağlantısı kullanım hakkı ve iptali", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		users := make([]string, 3)
+		for i := range users {
+			users[i] = fmt.Sprintf("conf-link%d-%s", i, f.suffix)
+			if err := repo.CreateUser(users[i], "Kullanıcı "+users[i], users[i]+"@example.com", "hash", "TR00", "en"); err != nil {
+				return err
+			}
+		}
+		link, err := repo.createInviteLink(ctx, f.groupID, f.owner, CreateInviteLinkRequest{MaxUses: 2, RequiresApproval: true})
+		if err != nil {
+			return err
+		}
+		if err := expect(link.Status == inviteStatusActive && link.MaxUses != nil && *link.MaxUses == 2, "yeni bağlantı: %+v", link); err != nil {
+			return err
+		}
+		findLink := func(linkID int64) (*InviteLink, error) {
+			links, err := repo.getGroupInviteLinks(ctx, f.groupID)
+			for _, l := range links {
+				if l.LinkID == linkID {
+					return l, err
+				}
+			}
+			return nil, fmt.Errorf("bağlantı %d listede yok: %v", linkID, err)
+		}
+
+		// Onay bekleyen istekler de kullanım hakkından düşer
+		requestIDs := make([]int64, 2)
+		for i := range requestIDs {
+			joined, err := repo.addUserToGroupWithToken(users[i], link.Token)
+			if err != nil {
+				return err
+			}
+			requestIDs[i] = joined.RequestID
+		}
+		if _, err := repo.addUserToGroupWithToken(users[2], link.Token); !errors.Is(err, errInviteExhausted) {
+			return fmt.Errorf("dolan bağlantı için errInviteExhausted beklenirdi, gelen: %v", err)
+		}
+
+		// Yenilenen bağlantı bekleyen istekleri devralır, kullanım hakkı artmaz
+		rotated, err := repo.rotateInviteLink(ctx, f.groupID, link.LinkID, f.owner)
+		if err != nil {
+			return err
+		}
+		if err := expect(rotated.Token != link.Token && rotated.PendingRequests == 2 && rotated.Status == inviteStatusExhausted,
+			"yenilenen bağlantı: %+v", rotated); err != nil {
+			return err
+		}
+		if _, err := repo.addUserToGroupWithToken(users[2], rotated.Token); !errors.Is(err, errInviteExhausted) {
+			return fmt.Errorf("yenilenen dolu bağlantı için errInviteExhausted beklenirdi, gelen: %v", err)
+		}
+		if _, err := repo.addUserToGroupWithToken(users[2], link.Token); !errors.Is(err, errInviteRevoked) {
+			return fmt.Errorf("eski token için errInviteRevoked beklenirdi, gelen: %v", err)
+		}
+		if _, err := repo.rotateInviteLink(ctx, f.groupID, link.LinkID, f.owner); !errors.Is(err, errInviteRevoked) {
+			return fmt.Errorf("iptal edilen bağlantıyı yenilemek için errInviteRevoked beklenirdi, gelen: %v", err)
+		}
+
+		if _, err := repo.rejectAddRequest(requestIDs[0], f.owner); err != nil {
+			return err
+		}
+		if _, err := repo.acceptAddRequest(requestIDs[1], f.owner); err != nil {
+			return err
+		}
+		current, err := findLink(rotated.LinkID)
+		if err != nil {
+			return err
+		}
+		if err := expect(current.UseCount == 1 && current.PendingRequests == 0 && current.Status == inviteStatusActive &&
+			len(current.Uses) == 1 && current.Uses[0].UserID == users[1], "onay sonrası bağlantı: %+v", current); err != nil {
+			return err
+		}
+
+		if err := repo.revokeInviteLink(ctx, f.groupID, rotated.LinkID, f.owner); err != nil {
+			return err
+		}
+		if err := repo.revokeInviteLink(ctx, f.groupID, rotated.LinkID, f.owner); err != nil {
+			return fmt.Errorf("iptal edilen bağlantıyı tekrar iptal etmek hata vermemeli: %w", err)
+		}
+		if _, err := repo.addUserToGroupWithToken(users[2], rotated.Token); !errors.Is(err, errInviteRevoked) {
+			return fmt.Errorf("iptal edilen bağlantı için errInviteRevoked beklenirdi, gelen: %v", err)
+		}
+		if current, err = findLink(rotated.LinkID); err != nil {
+			return err
+		}
+		return expect(current.Status == inviteStatusRevoked && current.RevokedAt != nil, "iptal sonrası bağlantı: %+v", current)
+	}},
+
+	{"bildirim kutusu", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		ids := make([]int64, 3)
+		for i := range ids {
+			id, err := repo.SaveNotification(ctx, f.outsider, fmt.Sprint("Başlık ", i), "Gövde", map[string]string{"type": "nudge", "index": fmt.Sprint(i)})
+			if err != nil {
+				return err
+			}
+			ids[i] = id
+		}
+		othersID, err := repo.SaveNotification(ctx, f.member, "Başkası", "Gövde", nil)
+		if err != nil {
+			return err
+		}
+
+		page, err := repo.GetNotifications(ctx, f.outsider, 2, 0)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(page) == 2 && page[0].ID == ids[2] && page[1].ID == ids[1], "ilk sayfa en yeniden başlamalı: %+v", page); err != nil {
+			return err
+		}
+		n := page[0]
+		if err := expect(n.Type == "nudge" && n.Title == "Başlık 2" && n.Data["index"] == "2" && !n.IsRead && n.CreatedAt > 0,
+			"beklenmeyen bildirim: %+v", n); err != nil {
+			return err
+		}
+		if page, err = repo.GetNotifications(ctx, f.outsider, 2, 2); err != nil {
+			return err
+		}
+		if err := expect(len(page) == 1 && page[0].ID == ids[0], "ikinci sayfa: %+v", page); err != nil {
+			return err
+		}
+		others, err := repo.GetNotifications(ctx, f.member, 10, 0)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(others) == 1 && others[0].Type == "" && len(others[0].Data) == 0, "verisiz bildirim: %+v", others); err != nil {
+			return err
+		}
+
+		steps := []struct {
+			name   string
+			mark   func() (int64, error)
+			marked int64
+			unread int
+		}{
+			{"başkasının bildirimi işaretlenmez", func() (int64, error) { return repo.MarkNotificationsRead(ctx, f.outsider, []int64{othersID}) }, 0, 3},
+			{"seçilenler", func() (int64, error) { return repo.MarkNotificationsRead(ctx, f.outsider, []int64{ids[0], othersID}) }, 1, 2},
+			{"okunmuş tekrar sayılmaz", func() (int64, error) { return repo.MarkNotificationsRead(ctx, f.outsider, []int64{ids[0]}) }, 0, 2},
+			{"boş liste", func() (int64, error) { return repo.MarkNotificationsRead(ctx, f.outsider, nil) }, 0, 2},
+			{"tümü", func() (int64, error) { return repo.MarkAllNotificationsRead(ctx, f.outsider) }, 2, 0},
+		}
+		for _, step := range steps {
+			marked, err := step.mark()
+			if err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+			unread, err := repo.CountUnreadNotifications(ctx, f.outsider)
+			if err != nil {
+				return err
+			}
+			if err := expect(marked == step.marked && unread == step.unread, "%s: %d işaretlendi, %d okunmamış; beklenen %d, %d",
+				step.name, marked, unread, step.marked, step.unread); err != nil {
+				return err
+			}
+		}
+		unread, err := repo.CountUnreadNotifications(ctx, f.member)
+		if err != nil {
+			return err
+		}
+		if err := expect(unread == 1, "başkasının okunmamış bildirimi %d, beklenen 1", unread); err != nil {
+			return err
+		}
+		_, err = repo.MarkAllNotificationsRead(ctx, f.member)
+		return err
+	}},
+
+	{"ödeme hatırlatmaları", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		settings, err := repo.GetReminderSettings(ctx, f.groupID)
+		if err != nil {
+			return err
+		}
+		if err := expect(!settings.Enabled && settings.FirstReminderDays == 3 && settings.RepeatEveryDays == 7, "varsayılan ayarlar: %+v", settings); err != nil {
+			return err
+		}
+
+		// Önceki testlerden kalan borçlar ödenir, tutar yalnız bu harcamadan gelir
+		if err := repo.PayGroupExpense(f.member, f.owner, f.groupID); err != nil {
+			return err
+		}
+		share := 15.0
+		if _, err := repo.createGroupExpense(ctx, f.owner, CreateExpenseRequest{
+			GroupID: int(f.groupID), TotalAmount: 30, PaymentTitle: "Fatura",
+			Users: []ExpenseUser{{UserID: f.owner, Amount: &share}, {UserID: f.member, Amount: &share}},
+		}); err != nil {
+			return err
+		}
+		findDebt := func() (*OutstandingDebt, error) {
+			debts, err := repo.getOutstandingDebts(ctx, 0)
+			for i := range debts {
+				if debts[i].GroupID == f.groupID {
+					return &debts[i], err
+				}
+			}
+			return nil, err
+		}
+		if debt, err := findDebt(); err != nil || debt != nil {
+			return fmt.Errorf("hatırlatmaları kapalı grup taranmamalı: %+v: %v", debt, err)
+		}
+
+		if err := repo.SaveReminderSettings(ctx, f.owner, ReminderSettings{GroupID: f.groupID, Enabled: true, FirstReminderDays: 1, RepeatEveryDays: 2}); err != nil {
+			return err
+		}
+		enabled, err := repo.getEnabledReminderSettings(ctx)
+		if err != nil {
+			return err
+		}
+		if err := expect(enabled[f.groupID].Enabled && enabled[f.groupID].RepeatEveryDays == 2, "açık ayarlar: %+v", enabled); err != nil {
+			return err
+		}
+		debt, err := findDebt()
+		if err != nil {
+			return err
+		}
+		if err := expect(debt != nil && debt.CreditorID == f.owner && debt.DebtorID == f.member && debt.Amount == 15 &&
+			debt.Currency == "EUR" && debt.OldestUnpaid > 0, "ödenmemiş borç: %+v", debt); err != nil {
+			return err
+		}
+
+		// Aynı türde hatırlatma bekleme süresi içinde tekrar ayrılmaz, türler birbirini engellemez
+		steps := []struct {
+			name         string
+			reminderType string
+			want         bool
+		}{
+			{"ilk zamanlanmış", reminderTypeScheduled, true},
+			{"tekrar zamanlanmış", reminderTypeScheduled, false},
+			{"ilk dürtme", reminderTypeNudge, true},
+			{"tekrar dürtme", reminderTypeNudge, false},
+		}
+		for _, step := range steps {
+			reserved, err := repo.reservePaymentReminder(ctx, *debt, step.reminderType, time.Hour)
+			if err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+			if err := expect(reserved == step.want, "%s: ayrıldı %v, beklenen %v", step.name, reserved, step.want); err != nil {
+				return err
+			}
+		}
+		if err := backdate(ctx, repo, "payment_reminders", "sent_at", 2*60*60, "group_id = ? AND reminder_type = ?", f.groupID, reminderTypeScheduled); err != nil {
+			return err
+		}
+		reserved, err := repo.reservePaymentReminder(ctx, *debt, reminderTypeScheduled, time.Hour)
+		if err != nil {
+			return err
+		}
+		if err := expect(reserved, "bekleme süresi geçen hatırlatma ayrılmalıydı"); err != nil {
+			return err
+		}
+
+		if err := repo.SaveReminderSettings(ctx, f.owner, ReminderSettings{GroupID: f.groupID, Enabled: false, FirstReminderDays: 1, RepeatEveryDays: 2}); err != nil {
+			return err
+		}
+		return repo.PayGroupExpense(f.member, f.owner, f.groupID)
+	}},
+
+	{"aktivite kaydı", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		groupID, err := repo.CreateGroup(f.owner, "Aktivite", "conf-activity-"+f.suffix, "TRY")
+		if err != nil {
+			return err
+		}
+		if err := repo.SaveReminderSettings(ctx, f.owner, ReminderSettings{GroupID: groupID, Enabled: true, FirstReminderDays: 1, RepeatEveryDays: 1}); err != nil {
+			return err
+		}
+		if err := repo.setGroupJoinApproval(ctx, f.owner, GroupJoinSettings{GroupID: groupID, RequiresApproval: true}); err != nil {
+			return err
+		}
+		// Değişmeyen ayar kayıt yazmaz
+		if err := repo.setGroupJoinApproval(ctx, f.owner, GroupJoinSettings{GroupID: groupID, RequiresApproval: true}); err != nil {
+			return err
+		}
+		link, err := repo.createInviteLink(ctx, groupID, f.owner, CreateInviteLinkRequest{})
+		if err != nil {
+			return err
+		}
+		if err := repo.revokeInviteLink(ctx, groupID, link.LinkID, f.owner); err != nil {
+			return err
+		}
+
+		owner, err := repo.GetUserByID(f.owner)
+		if err != nil {
+			return err
+		}
+		activity, err := repo.GetGroupActivity(ctx, groupID, 10, 0)
+		if err != nil {
+			return err
+		}
+		want := []struct{ action, entityType, entityID string }{
+			{activityInviteLinkRevoked, "invite_link", fmt.Sprint(link.LinkID)},
+			{activityInviteLinkCreated, "invite_link", fmt.Sprint(link.LinkID)},
+			{activityJoinSettingsUpdated, "group", fmt.Sprint(groupID)},
+			{activityRemindersUpdated, "reminder_settings", fmt.Sprint(groupID)},
+			{activityGroupCreated, "group", fmt.Sprint(groupID)},
+		}
+		if err := expect(len(activity) == len(want), "%d aktivite, beklenen %d: %+v", len(activity), len(want), activity); err != nil {
+			return err
+		}
+		for i, w := range want {
+			a := activity[i]
+			if err := expect(a.GroupID == groupID && a.Action == w.action && a.EntityType == w.entityType && a.EntityID != nil && *a.EntityID == w.entityID &&
+				a.ActorID != nil && *a.ActorID == f.owner && a.ActorName != nil && *a.ActorName == owner.FullName && a.CreatedAt > 0,
+				"%d. aktivite %+v, beklenen %+v", i, a, w); err != nil {
+				return err
+			}
+		}
+
+		var before, after GroupJoinSettings
+		if err := json.Unmarshal(activity[2].Before, &before); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(activity[2].After, &after); err != nil {
+			return err
+		}
+		if err := expect(!before.RequiresApproval && after.RequiresApproval, "katılım ayarı değişikliği: %s -> %s", activity[2].Before, activity[2].After); err != nil {
+			return err
+		}
+		if err := expect(string(activity[4].Before) == "null" && string(activity[4].After) != "null", "grup oluşturma kaydı: %s -> %s",
+			activity[4].Before, activity[4].After); err != nil {
+			return err
+		}
+
+		page, err := repo.GetGroupActivity(ctx, groupID, 2, 3)
+		if err != nil {
+			return err
+		}
+		return expect(len(page) == 2 && page[0].ID == activity[3].ID && page[1].ID == activity[4].ID, "sayfalanmış aktivite: %+v", page)
+	}},
+
+	{"hesap silme ve anonimleştirme", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		leaver := "conf-leaver-" + f.suffix
+		email := leaver + "@example.com"
+		if err := repo.CreateUser(leaver, "Ayrılan", email, "hash", "TR99", "en"); err != nil {
+			return err
+		}
+
+		// Silinince temizlenecek kişisel veriler ve yerinde kalacak borç
+		if _, err := repo.addUserToGroupWithToken(leaver, "conf-token-"+f.suffix); err != nil {
+			return err
+		}
+		share := 5.0
+		if _, err := repo.createGroupExpense(ctx, f.owner, CreateExpenseRequest{
+			GroupID: int(f.groupID), TotalAmount: 10, PaymentTitle: "Çay",
+			Users: []ExpenseUser{{UserID: f.owner, Amount: &share}, {UserID: leaver, Amount: &share}},
+		}); err != nil {
+			return err
+		}
+		otherGroupID, err := repo.CreateGroup(f.owner, "Silme", "conf-deletion-"+f.suffix, "EUR")
+		if err != nil {
+			return err
+		}
+		if _, _, err := repo.sendAddGroupRequest(fmt.Sprint(otherGroupID), email, f.owner); err != nil {
+			return err
+		}
+		if err := repo.SaveFCMToken(leaver, "conf-fcm-"+f.suffix); err != nil {
+			return err
+		}
+		if _, err := repo.SaveNotification(ctx, leaver, "Başlık", "Gövde", nil); err != nil {
+			return err
+		}
+		requestID, _, err := repo.sendFriendRequest(ctx, f.owner, email)
+		if err != nil {
+			return err
+		}
+		if _, err := repo.respondFriendRequest(ctx, requestID, leaver, true); err != nil {
+			return err
+		}
+		if _, _, err := repo.sendFriendRequest(ctx, f.member, email); err != nil {
+			return err
+		}
+		if _, _, err := repo.createDataExport(ctx, leaver); err != nil {
+			return err
+		}
+
+		status, err := repo.GetAccountDeletionStatus(ctx, leaver)
+		if err != nil {
+			return err
+		}
+		if err := expect(status.Status == deletionStatusActive && status.RequestedAt == nil, "silme öncesi durum: %+v", status); err != nil {
+			return err
+		}
+		if err := repo.restoreAccount(ctx, leaver); !errors.Is(err, errNoPendingDeletion) {
+			return fmt.Errorf("bekleyen silme yokken errNoPendingDeletion beklenirdi, gelen: %v", err)
+		}
+		if status, err = repo.requestAccountDeletion(ctx, leaver, time.Hour); err != nil {
+			return err
+		}
+		if err := expect(status.Status == deletionStatusPending && status.RequestedAt != nil && status.ScheduledAt != nil &&
+			*status.ScheduledAt-*status.RequestedAt == 60*60, "silme talebi: %+v", status); err != nil {
+			return err
+		}
+		if err := repo.restoreAccount(ctx, leaver); err != nil {
+			return err
+		}
+		if status, err = repo.requestAccountDeletion(ctx, leaver, time.Hour); err != nil {
+			return err
+		}
+
+		dueStatus := func() (string, error) {
+			due, err := repo.getDueAccountDeletions(ctx, 100)
+			return due[leaver], err
+		}
+		if s, err := dueStatus(); err != nil || s != "" {
+			return fmt.Errorf("bekleme süresi dolmayan hesap silinmemeli: %q: %v", s, err)
+		}
+		if err := backdate(ctx, repo, "users", "deletion_scheduled_at", 60, "id = ?", leaver); err != nil {
+			return err
+		}
+		if s, err := dueStatus(); err != nil || s != deletionStatusPending {
+			return fmt.Errorf("bekleme süresi dolan hesap silinmeli: %q: %v", s, err)
+		}
+
+		if err := repo.anonymizeUser(ctx, leaver); err != nil {
+			return err
+		}
+		user, err := repo.GetUserByID(leaver)
+		if err != nil {
+			return err
+		}
+		if err := expect(user.FullName == T(defaultLocale, "account.deleted_user_name", nil) && user.Email == "deleted+"+leaver+"@kasa.invalid" &&
+			user.IBAN == "" && user.Deleted && user.DeletionStatus == deletionStatusAnonymized, "anonimleştirilen kullanıcı: %+v", user); err != nil {
+			return err
+		}
+
+		checks := []struct {
+			name string
+			run  func() (bool, error)
+		}{
+			{"email serbest kalır", func() (bool, error) {
+				id, err := repo.GetUserIDByEmail(email)
+				return id == "", err
+			}},
+			{"FCM token silinir", func() (bool, error) {
+				token, err := repo.GetFCMTokenByUserID(ctx, leaver)
+				return token == "", err
+			}},
+			{"gelen kutusu silinir", func() (bool, error) {
+				inbox, err := repo.GetNotifications(ctx, leaver, 10, 0)
+				return len(inbox) == 0, err
+			}},
+			{"arkadaşlık silinir", func() (bool, error) {
+				friends, err := repo.areFriends(ctx, f.owner, leaver)
+				return !friends, err
+			}},
+			{"arkadaşlık isteği kapanır", func() (bool, error) {
+				requests, err := repo.getMyFriendRequests(ctx, leaver)
+				return len(requests) == 0, err
+			}},
+			{"veri talebi kapanır", func() (bool, error) {
+				exports, err := repo.getDataExports(ctx, leaver)
+				return len(exports) == 1 && exports[0].Status == dataExportExpired, err
+			}},
+			{"grup daveti reddedilir", func() (bool, error) {
+				requests, err := repo.getGroupOutgoingRequests(ctx, otherGroupID, "")
+				return len(requests) == 1 && requests[0].Status == "rejected", err
+			}},
+			{"grup borcu kalır", func() (bool, error) {
+				debts, err := repo.getOutstandingDebts(ctx, f.groupID)
+				for _, d := range debts {
+					if d.DebtorID == leaver {
+						return d.Amount == 5, err
+					}
+				}
+				return false, err
+			}},
+		}
+		for _, c := range checks {
+			ok, err := c.run()
+			if err != nil {
+				return fmt.Errorf("%s: %w", c.name, err)
+			}
+			if !ok {
+				return fmt.Errorf("anonimleştirme sonrası: %s", c.name)
+			}
+		}
+
+		if s, err := dueStatus(); err != nil || s != deletionStatusAnonymized {
+			return fmt.Errorf("Firebase silmesi bekleyen hesap tekrar denenmeli: %q: %v", s, err)
+		}
+		if err := repo.completeAccountDeletion(ctx, leaver, errors.New("firebase kapalı")); err != nil {
+			return err
+		}
+		if s, err := dueStatus(); err != nil || s != deletionStatusAnonymized {
+			return fmt.Errorf("Firebase hatasından sonra hesap tekrar denenmeli: %q: %v", s, err)
+		}
+		if err := repo.completeAccountDeletion(ctx, leaver, nil); err != nil {
+			return err
+		}
+		if status, err = repo.GetAccountDeletionStatus(ctx, leaver); err != nil {
+			return err
+		}
+		if err := expect(status.Status == deletionStatusCompleted && status.CompletedAt != nil, "tamamlanan silme: %+v", status); err != nil {
+			return err
+		}
+		if s, err := dueStatus(); err != nil || s != "" {
+			return fmt.Errorf("tamamlanan silme tekrar denenmemeli: %q: %v", s, err)
+		}
+		return repo.PayGroupExpense(leaver, f.owner, f.groupID)
+	}},
+
+	{"CSV içe aktarma", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		groupID, err := repo.CreateGroup(f.owner, "İçe aktarma", "conf-import-"+f.suffix, "EUR")
+		if err != nil {
+			return err
+		}
+		if _, err := repo.addUserToGroupWithToken(f.member, "conf-import-"+f.suffix); err != nil {
+			return err
+		}
+		members, err := repo.getGroupMemberRefs(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		ids := map[string]bool{}
+		for _, m := range members {
+			ids[m.ID] = !m.IsPlaceholder
+		}
+		if err := expect(len(members) == 2 && ids[f.owner] && ids[f.member], "grup üyeleri: %+v", members); err != nil {
+			return err
+		}
+
+		owner, member := f.owner+"@example.com", f.member+"@example.com"
+		csv := "date,title,amount,payer,participants,currency\n" +
+			"2024-01-05,Market,30.00," + owner + "," + owner + ";" + member + ",EUR\n" +
+			"2024-01-06,Taksi,abc," + owner + "," + member + ",EUR\n" +
+			"2024-01-07,Yemek,20," + owner + "," + member + ";Zeynep,EUR\n" +
+			"2024-01-08,Otel,50," + owner + "," + member + ",USD\n" +
+			"dün,Kahve,5," + owner + "," + member + ",EUR\n"
+		mapping := ImportMapping{Date: "date", Title: "title", Amount: "amount", Payer: "payer", Participants: "participants", Currency: "currency"}
+
+		cases := []struct {
+			name              string
+			allowPlaceholders bool
+			errors            map[int]string
+			expenses          int
+		}{
+			{"geçici üye olmadan", false, map[int]string{
+				3: "import.issue.invalid_amount", 4: "import.issue.unknown_person", 5: "import.issue.currency_mismatch", 6: "import.issue.invalid_date",
+			}, 1},
+			{"geçici üyeyle", true, map[int]string{
+				3: "import.issue.invalid_amount", 5: "import.issue.currency_mismatch", 6: "import.issue.invalid_date",
+			}, 2},
+		}
+		var expenses []importedExpense
+		var placeholders []string
+		for _, c := range cases {
+			var report *ImportReport
+			report, expenses, placeholders = buildExpenseImport(importSourceGeneric, "EUR", parseGenericCSV(strings.NewReader(csv), mapping), members, nil, c.allowPlaceholders)
+			issues := map[int]string{}
+			for _, issue := range report.Errors {
+				issues[issue.Row] = issue.Code
+			}
+			if err := expect(fmt.Sprint(issues) == fmt.Sprint(c.errors) && report.RowsRead == 5 && report.ValidExpenses == c.expenses && len(expenses) == c.expenses,
+				"%s: hatalar %v, geçerli %d; beklenen %v, %d", c.name, issues, report.ValidExpenses, c.errors, c.expenses); err != nil {
+				return err
+			}
+		}
+
+		expenseIDs, created, err := repo.commitExpenseImport(ctx, groupID, f.owner, placeholders, expenses)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(expenseIDs) == 2 && len(created) == 1 && isPlaceholderID(created["Zeynep"]), "içe aktarılan: %v %v", expenseIDs, created); err != nil {
+			return err
+		}
+		report, err := repo.getGroupReport(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(report.Expenses) == 2 && report.Members[created["Zeynep"]] == "Zeynep", "içe aktarma sonrası rapor: %+v", report); err != nil {
+			return err
+		}
+		for _, e := range report.Expenses {
+			if e.Title == "Yemek" {
+				return expect(e.PayerID == f.owner && e.Amount == 20 && len(e.Participants) == 2, "geçici üyeli harcama: %+v", e)
+			}
+		}
+		return fmt.Errorf("Yemek harcaması raporda yok: %+v", report.Expenses)
+	}},
+
+	{"geçici üye sahiplenme", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		claimer := "conf-claimer-" + f.suffix
+		if err := repo.CreateUser(claimer, "Ayşe Yılmaz", claimer+"@example.com", "hash", "TR00", "tr"); err != nil {
+			return err
+		}
+		groupID, err := repo.CreateGroup(f.owner, "Geçici", "conf-placeholder-"+f.suffix, "EUR")
+		if err != nil {
+			return err
+		}
+		withEmail, err := repo.createPlaceholderMember(ctx, groupID, f.owner, "Ayşe", strings.ToUpper(claimer)+"@EXAMPLE.COM", "")
+		if err != nil {
+			return err
+		}
+		withoutEmail, err := repo.createPlaceholderMember(ctx, groupID, f.owner, "Ali", "", "+905550000000")
+		if err != nil {
+			return err
+		}
+		if err := expect(isPlaceholderID(withEmail.ID) && withEmail.GroupID == groupID && withEmail.GroupName == "Geçici" &&
+			withoutEmail.ContactEmail == nil && withoutEmail.ContactPhone != nil, "geçici üyeler: %+v %+v", withEmail, withoutEmail); err != nil {
+			return err
+		}
+
+		share := 10.0
+		if _, err := repo.createGroupExpense(ctx, f.owner, CreateExpenseRequest{
+			GroupID: int(groupID), TotalAmount: 30, PaymentTitle: "Piknik",
+			Users: []ExpenseUser{{UserID: f.owner, Amount: &share}, {UserID: withEmail.ID, Amount: &share}, {UserID: withoutEmail.ID, Amount: &share}},
+		}); err != nil {
+			return err
+		}
+		debts, err := repo.getOutstandingDebts(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(debts) == 0, "geçici üyelere hatırlatma gitmemeli: %+v", debts); err != nil {
+			return err
+		}
+
+		placeholders, err := repo.getGroupPlaceholders(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(placeholders) == 2, "gruptaki geçici üyeler: %+v", placeholders); err != nil {
+			return err
+		}
+		claimable, err := repo.getClaimablePlaceholders(ctx, claimer)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(claimable) == 1 && claimable[0].ID == withEmail.ID, "sahiplenilebilir geçici üyeler: %+v", claimable); err != nil {
+			return err
+		}
+
+		// Email eşleşmesi ya da grup üyeliği gerekir; birleştirilen geçici üye silinir
+		steps := []struct {
+			name          string
+			placeholderID string
+			want          error
+		}{
+			{"olmayan geçici üye", placeholderIDPrefix + "yok", errPlaceholderNotFound},
+			{"email eşleşmeyen ve üye olmayan", withoutEmail.ID, errPlaceholderForbidden},
+			{"email eşleşen", withEmail.ID, nil},
+			{"birleştirilmiş geçici üye", withEmail.ID, errPlaceholderNotFound},
+			{"üye olduktan sonra email eşleşmeyen", withoutEmail.ID, nil},
+		}
+		for _, step := range steps {
+			claimedGroupID, err := repo.claimPlaceholder(ctx, step.placeholderID, claimer)
+			if step.want != nil {
+				if !errors.Is(err, step.want) {
+					return fmt.Errorf("%s: %v beklenirdi, gelen: %v", step.name, step.want, err)
+				}
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+			if err := expect(claimedGroupID == groupID, "%s: grup %d, beklenen %d", step.name, claimedGroupID, groupID); err != nil {
+				return err
+			}
+		}
+
+		if placeholders, err = repo.getGroupPlaceholders(ctx, groupID); err != nil {
+			return err
+		}
+		if err := expect(len(placeholders) == 0, "sahiplenilen geçici üyeler kalmamalı: %+v", placeholders); err != nil {
+			return err
+		}
+		isMember, err := repo.isGroupMember(ctx, groupID, claimer)
+		if err != nil {
+			return err
+		}
+		if err := expect(isMember, "sahiplenen kullanıcı grubun üyesi olmalı"); err != nil {
+			return err
+		}
+		// İki geçici üyenin aynı harcamadaki payları tek satırda toplanır
+		if debts, err = repo.getOutstandingDebts(ctx, groupID); err != nil {
+			return err
+		}
+		return expect(len(debts) == 1 && debts[0].DebtorID == claimer && debts[0].Amount == 20, "birleştirme sonrası borçlar: %+v", debts)
+	}},
+
+	{"grup ekleme isteği süresi ve yeniden gönderme", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		invitee := "conf-invitee-" + f.suffix
+		if err := repo.CreateUser(invitee, "Davetli", invitee+"@example.com", "hash", "TR00", "en"); err != nil {
+			return err
+		}
+		sendRequest := func() (*OutgoingAddRequest, error) {
+			if _, _, err := repo.sendAddGroupRequest(f.group(), invitee+"@example.com", f.owner); err != nil {
+				return nil, err
+			}
+			requests, err := repo.getGroupOutgoingRequests(ctx, f.groupID, "pending")
+			for _, r := range requests {
+				if r.UserID == invitee {
+					return r, err
+				}
+			}
+			return nil, fmt.Errorf("gönderilen istek listede yok: %v", err)
+		}
+		req, err := sendRequest()
+		if err != nil {
+			return err
+		}
+
+		// Bekleme süresi son bildirimden, bildirim yoksa istek zamanından ölçülür
+		resend := func() (time.Duration, error) {
+			_, wait, err := repo.resendAddRequest(ctx, f.groupID, req.RequestID, f.owner, time.Hour)
+			return wait, err
+		}
+		if wait, err := resend(); !errors.Is(err, errAddRequestResendTooSoon) || wait <= 0 || wait > time.Hour {
+			return fmt.Errorf("yeni istek için errAddRequestResendTooSoon beklenirdi, gelen: %v (%s)", err, wait)
+		}
+		if err := backdate(ctx, repo, "group_add_requests", "requested_at", 2*60*60, "request_id = ?", req.RequestID); err != nil {
+			return err
+		}
+		if _, err := resend(); err != nil {
+			return err
+		}
+		if _, err := resend(); !errors.Is(err, errAddRequestResendTooSoon) {
+			return fmt.Errorf("yeni bildirimden sonra errAddRequestResendTooSoon beklenirdi, gelen: %v", err)
+		}
+		requests, err := repo.getGroupOutgoingRequests(ctx, f.groupID, "pending")
+		if err != nil {
+			return err
+		}
+		for _, r := range requests {
+			if r.RequestID == req.RequestID {
+				if err := expect(r.LastNotifiedAt != nil && *r.LastNotifiedAt > r.RequestedAt, "yeniden gönderilen istek: %+v", r); err != nil {
+					return err
+				}
+			}
+		}
+
+		expired, err := repo.expirePendingAddRequests(ctx, 7, 100)
+		if err != nil {
+			return err
+		}
+		if err := expect(expired == 0, "süresi dolmayan %d istek kapandı", expired); err != nil {
+			return err
+		}
+		if err := backdate(ctx, repo, "group_add_requests", "requested_at", 8*24*60*60, "request_id = ?", req.RequestID); err != nil {
+			return err
+		}
+		if expired, err = repo.expirePendingAddRequests(ctx, 7, 100); err != nil {
+			return err
+		}
+		if err := expect(expired == 1, "%d istek kapandı, beklenen 1", expired); err != nil {
+			return err
+		}
+		mine, err := repo.getMyAddRequests(invitee)
+		if err != nil {
+			return err
+		}
+		if err := expect(len(mine) == 1 && mine[0].RequestStatus == "expired", "davetlinin istekleri: %+v", mine); err != nil {
+			return err
+		}
+
+		// Yeni istek açılabilir; kapanan istekte işlem yapılamaz
+		next, err := sendRequest()
+		if err != nil {
+			return err
+		}
+		steps := []struct {
+			name string
+			run  func() error
+			want error
+		}{
+			{"süresi dolan isteği yeniden gönderme", func() error { _, err := resend(); return err }, errAddRequestNotPending},
+			{"süresi dolan isteği iptal", func() error { return repo.cancelAddRequest(ctx, f.groupID, req.RequestID, f.owner) }, errAddRequestNotPending},
+			{"başka grubun yolundan iptal", func() error { return repo.cancelAddRequest(ctx, f.groupID+1, next.RequestID, f.owner) }, errAddRequestNotFound},
+			{"bekleyen isteği iptal", func() error { return repo.cancelAddRequest(ctx, f.groupID, next.RequestID, f.owner) }, nil},
+			{"iptal edilen isteği kabul", func() error { _, err := repo.acceptAddRequest(next.RequestID, invitee); return err }, errAddRequestNotFound},
+		}
+		for _, step := range steps {
+			if err := step.run(); !errors.Is(err, step.want) {
+				return fmt.Errorf("%s: %v beklenirdi, gelen: %v", step.name, step.want, err)
+			}
+		}
+		all, err := repo.getGroupOutgoingRequests(ctx, f.groupID, "cancelled")
+		if err != nil {
+			return err
+		}
+		return expect(len(all) == 1 && all[0].RequestID == next.RequestID, "iptal edilen istekler: %+v", all)
+	}},
+
+	{"idempotency yanıt tekrarı", func(ctx context.Context, repo Repository, f *conformanceFixture) error {
+		key := "conf-replay-" + f.suffix
+		now := time.Now()
+		if existing, err := repo.reserveIdempotencyKey(ctx, f.owner, key, "h1", now, now.Add(time.Hour)); err != nil || existing != nil {
+			return fmt.Errorf("yeni anahtar ayrılmalıydı: %+v: %v", existing, err)
+		}
+		saved := &IdempotentResponse{RequestHash: "h1", StatusCode: http.StatusCreated, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+		if err := repo.saveIdempotentResponse(ctx, f.owner, key, saved); err != nil {
+			return err
+		}
+
+		// Kayıtlı yanıt aynı kullanıcıya ve süresi içinde, istek özeti ne olursa olsun döner;
+		// özeti karşılaştırmak IdempotencyMiddleware'in işidir
+		cases := []struct {
+			name   string
+			userID string
+			hash   string
+			at     time.Time
+			replay bool
+		}{
+			{"aynı istek", f.owner, "h1", now, true},
+			{"farklı istek", f.owner, "h2", now, true},
+			{"başka kullanıcı", f.member, "h1", now, false},
+			{"süresi dolmuş", f.owner, "h1", now.Add(2 * time.Hour), false},
+		}
+		for _, c := range cases {
+			existing, err := repo.reserveIdempotencyKey(ctx, c.userID, key, c.hash, c.at, c.at.Add(time.Hour))
+			if err != nil {
+				return fmt.Errorf("%s: %w", c.name, err)
+			}
+			if !c.replay {
+				if err := expect(existing == nil, "%s: yeni anahtar ayrılmalıydı: %+v", c.name, existing); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := expect(existing != nil && existing.RequestHash == saved.RequestHash && existing.StatusCode == saved.StatusCode &&
+				existing.ContentType == saved.ContentType && string(existing.Body) == string(saved.Body), "%s: kayıtlı yanıt %+v", c.name, existing); err != nil {
+				return err
+			}
+		}
+
+		// Süresi dolan kaydın yerine açılan anahtara başka özetle yanıt yazılamaz
+		later := now.Add(2 * time.Hour)
+		wrong := &IdempotentResponse{RequestHash: "h2", StatusCode: http.StatusOK, ContentType: "application/json", Body: []byte(`{}`)}
+		if err := repo.saveIdempotentResponse(ctx, f.owner, key, wrong); err != nil {
+			return err
+		}
+		existing, err := repo.reserveIdempotencyKey(ctx, f.owner, key, "h1", later, later.Add(time.Hour))
+		if err != nil {
+			return err
+		}
+		if err := expect(existing != nil && existing.StatusCode == 0 && existing.RequestHash == "h1", "işlenen anahtar: %+v", existing); err != nil {
+			return err
+		}
+		if err := repo.releaseIdempotencyKey(ctx, f.member, key); err != nil {
+			return err
+		}
+		return repo.releaseIdempotencyKey(ctx, f.owner, key)
+	}},
+}
+
+// backdate table'da where'e uyan satırların column değerini veritabanı saatine göre seconds
+// saniye öncesine çeker; zamana bağlı kuralları beklemeden denemek içindir
+func backdate(ctx context.Context, repo Repository, table, column string, seconds int64, where string, args ...interface{}) error {
+	kr, ok := repo.(*KasaRepository)
+	if !ok {
+		return fmt.Errorf("backdate KasaRepository ister, gelen %T", repo)
+	}
+	_, err := kr.DB.ExecContext(ctx, "UPDATE "+table+" SET "+column+" = "+kr.dialect.minus("CURRENT_TIMESTAMP", "SECOND")+" WHERE "+where,
+		append([]interface{}{seconds}, args...)...)
+	return err
+}
+
+// openAPICall bir handler'ı router'a uğramadan, kimliği doğrulanmış kullanıcıyla çağırır; pattern
+// router'daki yoldur, yol parametreleri pathValues'tan doldurulur. status beklenen durumdur.
+type openAPICall struct {
+	method     string
+	pattern    string
+	handler    http.Handler
+	userID     string
+	pathValues map[string]string
+	body       interface{}
+	status     int
+}
+
+// serve isteği yol parametreleri ve gövdeyle kurup handler'a verir
+func (c openAPICall) serve(ctx context.Context) *httptest.ResponseRecorder {
+	target := c.pattern
+	for name, value := range c.pathValues {
+		target = strings.ReplaceAll(target, "{"+name+"}", value)
+	}
+	var body io.Reader
+	if c.body != nil {
+		encoded, _ := json.Marshal(c.body)
+		body = bytes.NewReader(encoded)
+	}
+
+	ctx = context.WithValue(ctx, "userUID", c.userID)
+	r := httptest.NewRequest(c.method, target, body).WithContext(ctx)
+	for name, value := range c.pathValues {
+		r.SetPathValue(name, value)
+	}
+	w := httptest.NewRecorder()
+	c.handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dataExportDir = "./exports"
+
+// dataExportLinkTTL indirme bağlantısının ne kadar süre geçerli olacağı
+func dataExportLinkTTL() time.Duration {
+	return envDuration("DATA_EXPORT_LINK_HOURS", time.Hour, 48*time.Hour)
+}
+
+// dataExportStaleAfter 'processing' durumunda kalan bir talebin yeniden sıraya alınacağı süre
+func dataExportStaleAfter() time.Duration {
+	return envDuration("DATA_EXPORT_STALE_MINUTES", time.Minute, 30*time.Minute)
+}
+
+// publicURL sunucunun dışarıdan erişilen adresine göre bağlantı oluşturur
+func publicURL(p string) string {
+	base := os.Getenv("PUBLIC_BASE_URL")
+	if base == "" {
+		base = "https://kasa.bunyamin.app"
+	}
+	return strings.TrimRight(base, "/") + p
+}
+
+func dataExportDownloadURL(token string) string {
+	return publicURL(apiV1 + "/data-exports/download?token=" + url.QueryEscape(token))
+}
+
+// processDataExports sıradaki dışa aktarma taleplerini arşive çevirir ve süresi dolanları temizler
+func processDataExports(ctx context.Context, repo Repository) error {
+	if err := cleanupExpiredDataExports(ctx, repo); err != nil {
+		log.Println("Süresi dolan arşivler temizlenemedi:", err)
+	}
+
+	exports, err := repo.claimPendingDataExports(ctx, 10, dataExportStaleAfter())
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		token, filePath, err := buildDataExport(ctx, repo, export)
+		if err != nil {
+			log.Printf("Veri dışa aktarma başarısız (id=%d): %v", export.ID, err)
+			if err := repo.failDataExport(ctx, export.ID, err); err != nil {
+				log.Println("Dışa aktarma durumu kaydedilemedi:", err)
+			}
+			continue
+		}
+
+		if err := repo.completeDataExport(ctx, export.ID, token, filePath, dataExportLinkTTL()); err != nil {
+			log.Printf("Dışa aktarma tamamlanamadı (id=%d): %v", export.ID, err)
+			os.Remove(filePath)
+			if err := repo.failDataExport(ctx, export.ID, err); err != nil {
+				log.Println("Dışa aktarma durumu kaydedilemedi:", err)
+			}
+			continue
+		}
+
+		data := map[string]string{
+			"type":         "data_export_ready",
+			"export_id":    strconv.FormatInt(export.ID, 10),
+			"download_url": dataExportDownloadURL(token),
+		}
+		params := map[string]string{"hours": strconv.Itoa(int(dataExportLinkTTL().Hours()))}
+		if err := SendNotification(ctx, repo, export.userID, "notification.data_export_ready.title", "notification.data_export_ready.body", params, data); err != nil {
+			log.Printf("Dışa aktarma bildirimi gönderilemedi (user=%s): %v", export.userID, err)
+		}
+	}
+	return nil
+}
+
+func cleanupExpiredDataExports(ctx context.Context, repo DataExportStore) error {
+	expired, err := repo.getExpiredDataExports(ctx, 100)
+	if err != nil {
+		return err
+	}
+	for _, export := range expired {
+		if export.filePath != "" {
+			if err := os.Remove(export.filePath); err != nil && !os.IsNotExist(err) {
+				log.Printf("Arşiv dosyası silinemedi (%s): %v", export.filePath, err)
+				continue
+			}
+		}
+		if err := repo.markDataExportExpired(ctx, export.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildDataExport kullanıcının verilerini JSON, CSV ve fiş görsellerinden oluşan bir zip arşivine yazar
+func buildDataExport(ctx context.Context, repo DataExportStore, export DataExport) (string, string, error) {
+	data, err := repo.collectUserData(ctx, export.userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := generateToken(48)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.MkdirAll(dataExportDir, 0o700); err != nil {
+		return "", "", err
+	}
+	filePath := filepath.Join(dataExportDir, fmt.Sprintf("kasa-export-%d-%s.zip", export.ID, token[:8]))
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := writeDataExportArchive(f, data); err != nil {
+		f.Close()
+		os.Remove(filePath)
+		return "", "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(filePath)
+		return "", "", err
+	}
+	return token, filePath, nil
+}
+
+func writeDataExportArchive(w io.Writer, data *UserDataExport) error {
+	zw := zip.NewWriter(w)
+
+	jw, err := zw.Create("data.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(jw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return err
+	}
+
+	formatTime := func(ts int64) string {
+		return time.Unix(ts, 0).UTC().Format(time.RFC3339)
+	}
+	formatAmount := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+
+	p := data.Profile
+	if err := writeCSV(zw, "profile.csv",
+		[]string{"id", "email", "full_name", "iban", "locale", "created_at"},
+		[][]string{{p.ID, p.Email, p.FullName, p.IBAN, p.Locale, formatTime(p.CreatedAt)}},
+	); err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for _, g := range data.Groups {
+		rows = append(rows, []string{strconv.FormatInt(g.GroupID, 10), g.GroupName, strconv.FormatBool(g.IsCreator), formatTime(g.JoinedAt)})
+	}
+	if err := writeCSV(zw, "groups.csv", []string{"group_id", "group_name", "is_creator", "joined_at"}, rows); err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, e := range data.ExpensesPaid {
+		deletedAt := ""
+		if e.DeletedAt != nil {
+			deletedAt = formatTime(*e.DeletedAt)
+		}
+		rows = append(rows, []string{
+			strconv.FormatInt(e.ExpenseID, 10), strconv.FormatInt(e.GroupID, 10), e.GroupName, e.Title, e.Note,
+			formatAmount(e.Amount), formatTime(e.PaymentDate), e.BillImageURL, deletedAt,
+		})
+	}
+	if err := writeCSV(zw, "expenses_paid.csv",
+		[]string{"expense_id", "group_id", "group_name", "title", "note", "amount", "payment_date", "bill_image_url", "deleted_at"}, rows); err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, s := range data.SharesOwed {
+		rows = append(rows, []string{
+			strconv.FormatInt(s.ExpenseID, 10), strconv.FormatInt(s.GroupID, 10), s.GroupName, s.Title,
+			s.PayerID, s.PayerName, formatAmount(s.AmountShare), s.PaymentStatus, formatTime(s.PaymentDate),
+		})
+	}
+	if err := writeCSV(zw, "shares_owed.csv",
+		[]string{"expense_id", "group_id", "group_name", "title", "payer_id", "payer_name", "amount_share", "payment_status", "payment_date"}, rows); err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, s := range data.Settlements {
+		rows = append(rows, []string{
+			strconv.FormatInt(s.ExpenseID, 10), strconv.FormatInt(s.GroupID, 10), s.GroupName,
+			s.Direction, s.OtherUserID, s.OtherName, formatAmount(s.Amount),
+		})
+	}
+	if err := writeCSV(zw, "settlements.csv",
+		[]string{"expense_id", "group_id", "group_name", "direction", "other_user_id", "other_name", "amount"}, rows); err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, a := range data.AddRequests {
+		rows = append(rows, []string{strconv.FormatInt(a.RequestID, 10), strconv.FormatInt(a.GroupID, 10), a.GroupName, a.Status, formatTime(a.RequestedAt)})
+	}
+	if err := writeCSV(zw, "add_requests.csv", []string{"request_id", "group_id", "group_name", "status", "requested_at"}, rows); err != nil {
+		return err
+	}
+
+	// Fiş görselleri; sadece bu sunucuya yüklenmiş dosyalar eklenebilir
+	added := map[string]bool{}
+	for _, e := range data.ExpensesPaid {
+		name := uploadedFileName(e.BillImageURL)
+		if name == "" || added[name] {
+			continue
+		}
+		if err := addFileToZip(zw, "receipts/"+name, filepath.Join("./uploads", name)); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		added[name] = true
+	}
+
+	return zw.Close()
+}
+
+func writeCSV(zw *zip.Writer, name string, header []string, rows [][]string) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(fw)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	// Satırlar kullanıcı verisi taşır; formül olarak açılmasınlar
+	sw := spreadsheetCSVWriter{cw}
+	for _, row := range rows {
+		if err := sw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func addFileToZip(zw *zip.Writer, name, filePath string) error {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, src)
+	return err
+}
+
+// uploadedFileName /uploads/ altındaki bir görsel bağlantısından dosya adını çıkarır
+func uploadedFileName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.Contains(u.Path, "/uploads/") {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	return name
+}
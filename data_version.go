@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// /me ve /groups yanıtları kullanıcının veri sürümünden (users.data_version) türetilen ETag ile
+// döner; istemci If-None-Match ile sorduğunda veri değişmediyse ağır sorgular çalışmadan 304 alır.
+// Bu yanıtlarda görünen bir kayıt (kullanıcı, grup, üyelik, bekleyen istek, harcama, ödeme)
+// değiştiğinde, değişiklikle aynı transaction içinde yanıtı etkilenen kullanıcıların sürümü
+// artırılmalıdır. Artırılmazsa istemci eski veriyi tutmaya devam eder.
+
+// touchUsers kullanıcıların veri sürümünü artırır
+func touchUsers(ctx context.Context, db sqlExecer, userIDs ...string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+	_, err := db.ExecContext(ctx, `
+		UPDATE users SET data_version = data_version + 1
+		WHERE id IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(userIDs)), ", ")+`)
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("veri sürümü artırılamadı: %w", err)
+	}
+	return nil
+}
+
+// touchGroupMembers grubun tüm üyelerinin veri sürümünü artırır
+func touchGroupMembers(ctx context.Context, db sqlExecer, groupID int64) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE users SET data_version = data_version + 1
+		WHERE id IN (SELECT user_id FROM group_members WHERE group_id = ?)
+	`, groupID)
+	if err != nil {
+		return fmt.Errorf("grup üyelerinin veri sürümü artırılamadı: %w", err)
+	}
+	return nil
+}
+
+// touchUserAndGroupmates kullanıcının ve onu üye ya da bekleyen istek olarak gören herkesin veri
+// sürümünü artırır; ad, email ya da IBAN gibi diğer üyelerin yanıtında görünen alanlar değişince kullanılır
+func touchUserAndGroupmates(ctx context.Context, db sqlExecer, userID string) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE users SET data_version = data_version + 1
+		WHERE id = ? OR id IN (
+			SELECT gm.user_id FROM group_members gm
+			WHERE gm.group_id IN (
+				SELECT group_id FROM group_members WHERE user_id = ?
+				UNION
+				SELECT group_id FROM group_add_requests WHERE user_id = ? AND request_status = 'pending'
+			)
+		)
+	`, userID, userID, userID)
+	if err != nil {
+		return fmt.Errorf("veri sürümü artırılamadı: %w", err)
+	}
+	return nil
+}
+
+// profileChangedTx kayıtlı kullanıcının adı ya da IBAN'ı user'dakinden farklıysa true döner.
+// Google ile her girişte kullanıcı tekrar yazıldığı için sürüm sadece değişiklik varsa artırılır.
+func profileChangedTx(ctx context.Context, tx *sql.Tx, user User) (bool, error) {
+	var fullName, iban string
+	err := tx.QueryRowContext(ctx, "SELECT fullname, COALESCE(iban, '') FROM users WHERE id = ?", user.ID).Scan(&fullName, &iban)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return fullName != user.FullName || iban != user.IBAN, nil
+}
+
+// GetDataVersion kullanıcının veri sürümünü döner, kullanıcı yoksa sql.ErrNoRows
+func (repo *KasaRepository) GetDataVersion(ctx context.Context, userID string) (int64, error) {
+	var version int64
+	err := repo.DB.QueryRowContext(ctx, "SELECT data_version FROM users WHERE id = ?", userID).Scan(&version)
+	return version, err
+}
+
+// ETag'lerdeki yanıt adları; yanıtın JSON biçimi değişirse sondaki revizyon artırılmalıdır,
+// yoksa eski biçimi tutan istemciler 304 alır
+const (
+	etagResourceMe     = "me-1"
+	etagResourceGroups = "groups-1"
+)
+
+// dataVersionETag yanıtın güçlü ETag'idir
+func dataVersionETag(resource string, version int64) string {
+	return fmt.Sprintf(`"%s.%d"`, resource, version)
+}
+
+// checkNotModified ETag ve önbellek başlıklarını yazar. İstemcinin If-None-Match'i ETag'le
+// eşleşiyorsa 304 yazıp true döner, handler yanıt gövdesini oluşturmadan çıkmalıdır.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	// Yanıt kullanıcıya özel; önbellek saklayabilir ama her kullanımda sunucuya sormalı
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Add("Vary", "Authorization")
+
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS fcm_table;
+DROP TABLE IF EXISTS group_expense_participants;
+DROP TABLE IF EXISTS group_expenses;
+DROP TABLE IF EXISTS group_add_requests;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS users;
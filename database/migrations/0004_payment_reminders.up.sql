@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS group_reminder_settings (
+    group_id INT PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    first_reminder_days INT NOT NULL DEFAULT 3,
+    repeat_every_days INT NOT NULL DEFAULT 7,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS payment_reminders (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    creditor_id VARCHAR(100) NOT NULL,
+    debtor_id VARCHAR(100) NOT NULL,
+    reminder_type ENUM('scheduled', 'nudge') NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_payment_reminders_pair (group_id, creditor_id, debtor_id, reminder_type, sent_at),
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (creditor_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (debtor_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS group_activity (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    actor_id VARCHAR(100) NULL,
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NULL,
+    before_data JSON NULL,
+    after_data JSON NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_group_activity_group (group_id, id),
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
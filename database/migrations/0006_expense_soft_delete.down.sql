@@ -0,0 +1,7 @@
+-- Silinmiş harcamalar geri dönüşte görünür hale gelmesin diye kalıcı olarak silinir
+DELETE FROM group_expenses WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE group_expenses
+    DROP INDEX idx_group_expenses_deleted_at,
+    DROP COLUMN deleted_by,
+    DROP COLUMN deleted_at;
@@ -0,0 +1,4 @@
+ALTER TABLE group_expenses
+    ADD COLUMN deleted_at TIMESTAMP NULL AFTER bill_image_url,
+    ADD COLUMN deleted_by VARCHAR(100) NULL AFTER deleted_at,
+    ADD INDEX idx_group_expenses_deleted_at (deleted_at);
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    DROP COLUMN deletion_error,
+    DROP COLUMN deletion_completed_at,
+    DROP COLUMN deletion_scheduled_at,
+    DROP COLUMN deletion_requested_at,
+    DROP COLUMN deletion_status;
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN deletion_status ENUM('active', 'pending', 'anonymized', 'completed') NOT NULL DEFAULT 'active' AFTER locale,
+    ADD COLUMN deletion_requested_at TIMESTAMP NULL AFTER deletion_status,
+    ADD COLUMN deletion_scheduled_at TIMESTAMP NULL AFTER deletion_requested_at,
+    ADD COLUMN deletion_completed_at TIMESTAMP NULL AFTER deletion_scheduled_at,
+    ADD COLUMN deletion_error TEXT NULL AFTER deletion_completed_at;
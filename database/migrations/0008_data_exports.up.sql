@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS data_exports (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL,
+    status ENUM('pending', 'processing', 'ready', 'failed', 'expired') NOT NULL DEFAULT 'pending',
+    download_token VARCHAR(64) NULL UNIQUE,
+    file_path VARCHAR(255) NULL,
+    error_message TEXT NULL,
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,
+    INDEX idx_data_exports_status (status, requested_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
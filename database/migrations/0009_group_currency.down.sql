@@ -0,0 +1 @@
+ALTER TABLE groups DROP COLUMN currency;
@@ -0,0 +1 @@
+ALTER TABLE groups ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'TRY' AFTER group_token;
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    DROP COLUMN contact_phone,
+    DROP COLUMN contact_email,
+    DROP COLUMN placeholder_group_id,
+    DROP COLUMN is_placeholder;
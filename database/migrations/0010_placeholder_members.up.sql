@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN is_placeholder BOOLEAN NOT NULL DEFAULT FALSE AFTER deletion_error,
+    ADD COLUMN placeholder_group_id INT NULL AFTER is_placeholder,
+    ADD COLUMN contact_email VARCHAR(100) NULL AFTER placeholder_group_id,
+    ADD COLUMN contact_phone VARCHAR(20) NULL AFTER contact_email;
@@ -0,0 +1,5 @@
+DELETE FROM group_expenses WHERE group_id IS NULL;
+ALTER TABLE group_expenses MODIFY group_id INT NOT NULL;
+
+DROP TABLE IF EXISTS friendships;
+DROP TABLE IF EXISTS friend_requests;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS friend_requests (
+    request_id INT AUTO_INCREMENT PRIMARY KEY,
+    requester_id VARCHAR(100) NOT NULL,
+    user_id VARCHAR(100) NOT NULL,
+    request_status ENUM('pending', 'accepted', 'rejected') NOT NULL DEFAULT 'pending',
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    responded_at TIMESTAMP NULL,
+    INDEX idx_friend_requests_user (user_id, request_status),
+    FOREIGN KEY (requester_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS friendships (
+    user_id VARCHAR(100) NOT NULL,
+    friend_id VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, friend_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (friend_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Grup dışı harcamalar group_id olmadan tutulur
+ALTER TABLE group_expenses MODIFY group_id INT NULL;
@@ -0,0 +1,9 @@
+-- Katılım istekleri davet yönünde anlam taşımadığı için silinir
+DELETE FROM group_add_requests WHERE request_direction = 'join';
+
+ALTER TABLE group_add_requests
+    DROP COLUMN invite_link_id,
+    DROP COLUMN request_direction;
+
+DROP TABLE IF EXISTS group_invite_link_uses;
+DROP TABLE IF EXISTS group_invite_links;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS group_invite_links (
+    link_id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    token VARCHAR(255) NOT NULL UNIQUE,
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NULL,
+    max_uses INT NULL,
+    use_count INT NOT NULL DEFAULT 0,
+    requires_approval BOOLEAN NOT NULL DEFAULT FALSE,
+    revoked_at TIMESTAMP NULL,
+    revoked_by VARCHAR(100) NULL,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS group_invite_link_uses (
+    link_id INT NOT NULL,
+    user_id VARCHAR(100) NOT NULL,
+    used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (link_id, user_id),
+    FOREIGN KEY (link_id) REFERENCES group_invite_links(link_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+ALTER TABLE group_add_requests
+    ADD COLUMN request_direction ENUM('invite', 'join') NOT NULL DEFAULT 'invite' AFTER request_status,
+    ADD COLUMN invite_link_id INT NULL AFTER request_direction;
@@ -0,0 +1 @@
+ALTER TABLE groups ADD COLUMN join_requires_approval BOOLEAN NOT NULL DEFAULT FALSE AFTER currency;
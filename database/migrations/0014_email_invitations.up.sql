@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS email_invitations (
+    invitation_id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    email VARCHAR(100) NOT NULL,
+    invited_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    invitation_status ENUM('pending', 'converted', 'cancelled') DEFAULT 'pending',
+    request_id INT NULL,
+    converted_at TIMESTAMP NULL,
+    INDEX idx_email_invitations_email (email, invitation_status),
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id)
+);
@@ -0,0 +1,7 @@
+-- Eski enum'da karşılığı olmayan durumlar reddedilmiş sayılır
+UPDATE group_add_requests SET request_status = 'rejected' WHERE request_status IN ('cancelled', 'expired');
+
+ALTER TABLE group_add_requests
+    DROP COLUMN cancelled_by,
+    DROP COLUMN last_notified_at,
+    MODIFY request_status ENUM('pending', 'accepted', 'rejected') DEFAULT 'pending';
@@ -0,0 +1,4 @@
+ALTER TABLE group_add_requests
+    MODIFY request_status ENUM('pending', 'accepted', 'rejected', 'cancelled', 'expired') DEFAULT 'pending',
+    ADD COLUMN last_notified_at TIMESTAMP NULL AFTER invite_link_id,
+    ADD COLUMN cancelled_by VARCHAR(100) NULL AFTER last_notified_at;
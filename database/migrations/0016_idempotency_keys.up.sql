@@ -0,0 +1,15 @@
+-- Idempotency-Key başlığıyla gelen isteklerin ilk yanıtı; tekrar denemelerde aynen döner.
+-- status_code NULL ise ilk istek hâlâ işleniyordur.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id VARCHAR(100) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT NULL,
+    content_type VARCHAR(100) NULL,
+    response_body MEDIUMBLOB NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key),
+    INDEX idx_idempotency_keys_expires (expires_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
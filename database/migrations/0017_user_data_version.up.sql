@@ -0,0 +1,2 @@
+-- /me ve /groups yanıtlarının ETag'i bu sürümden türetilir, bkz. data_version.go
+ALTER TABLE users ADD COLUMN data_version BIGINT NOT NULL DEFAULT 0 AFTER locale;
@@ -0,0 +1,13 @@
+-- Yabancı anahtarlar index ister; MySQL yeni index eklenince kendi oluşturduğunu silmiş
+-- olabileceği için kaldırılan her index'in yerine tek kolonlu bir index eklenir
+ALTER TABLE group_members
+    ADD INDEX idx_group_members_user_id (user_id),
+    DROP INDEX idx_group_members_user;
+
+ALTER TABLE group_expenses
+    ADD INDEX idx_group_expenses_group_id (group_id),
+    DROP INDEX idx_group_expenses_group;
+
+ALTER TABLE group_add_requests
+    ADD INDEX idx_group_add_requests_group_id (group_id),
+    DROP INDEX idx_group_add_requests_group;
@@ -0,0 +1,9 @@
+-- Grup yanıtının küme sorguları (group_loader.go) için index'ler
+ALTER TABLE group_members
+    ADD INDEX idx_group_members_user (user_id, group_id);
+
+ALTER TABLE group_expenses
+    ADD INDEX idx_group_expenses_group (group_id, deleted_at, payment_date);
+
+ALTER TABLE group_add_requests
+    ADD INDEX idx_group_add_requests_group (group_id, request_status);
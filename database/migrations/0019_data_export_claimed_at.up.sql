@@ -0,0 +1,2 @@
+-- İşlenirken yarım kalan dışa aktarmalar claimed_at'ten bir süre sonra yeniden sıraya alınır
+ALTER TABLE data_exports ADD COLUMN claimed_at TIMESTAMP NULL AFTER requested_at;
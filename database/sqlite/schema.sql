@@ -0,0 +1,205 @@
+-- Testlerin ve DB_DRIVER=sqlite ile yerelde çalışan sunucunun kullandığı SQLite şeması (bkz. sqlite.go).
+-- MySQL migration'larının (database/migrations) son hâlinin karşılığıdır; yeni bir migration
+-- tablo ekliyor ya da değiştiriyorsa bu dosya da güncellenmelidir.
+
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(100) PRIMARY KEY,
+    fullname VARCHAR(50) NOT NULL,
+    email VARCHAR(100) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NULL,
+    iban VARCHAR(34),
+    deleted BOOLEAN DEFAULT FALSE,
+    locale VARCHAR(5) NOT NULL DEFAULT 'tr',
+    data_version INTEGER NOT NULL DEFAULT 0,
+    deletion_status TEXT NOT NULL DEFAULT 'active' CHECK (deletion_status IN ('active', 'pending', 'anonymized', 'completed')),
+    deletion_requested_at TIMESTAMP NULL,
+    deletion_scheduled_at TIMESTAMP NULL,
+    deletion_completed_at TIMESTAMP NULL,
+    deletion_error TEXT NULL,
+    is_placeholder BOOLEAN NOT NULL DEFAULT FALSE,
+    placeholder_group_id INTEGER NULL,
+    contact_email VARCHAR(100) NULL,
+    contact_phone VARCHAR(20) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_name VARCHAR(100) NOT NULL,
+    creator_id VARCHAR(100) NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    group_token VARCHAR(255) NOT NULL UNIQUE,
+    currency CHAR(3) NOT NULL DEFAULT 'TRY',
+    join_requires_approval BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id VARCHAR(100) NOT NULL REFERENCES users(id),
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_group_members_user ON group_members (user_id, group_id);
+
+CREATE TABLE IF NOT EXISTS group_add_requests (
+    request_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id VARCHAR(100) NOT NULL REFERENCES users(id),
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    request_status TEXT DEFAULT 'pending' CHECK (request_status IN ('pending', 'accepted', 'rejected', 'cancelled', 'expired')),
+    request_direction TEXT NOT NULL DEFAULT 'invite' CHECK (request_direction IN ('invite', 'join')),
+    invite_link_id INTEGER NULL,
+    last_notified_at TIMESTAMP NULL,
+    cancelled_by VARCHAR(100) NULL
+);
+CREATE INDEX IF NOT EXISTS idx_group_add_requests_group ON group_add_requests (group_id, request_status);
+
+CREATE TABLE IF NOT EXISTS email_invitations (
+    invitation_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    email VARCHAR(100) NOT NULL,
+    invited_by VARCHAR(100) NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    invitation_status TEXT DEFAULT 'pending' CHECK (invitation_status IN ('pending', 'converted', 'cancelled')),
+    request_id INTEGER NULL,
+    converted_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS idx_email_invitations_email ON email_invitations (email, invitation_status);
+
+CREATE TABLE IF NOT EXISTS group_invite_links (
+    link_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    token VARCHAR(255) NOT NULL UNIQUE,
+    created_by VARCHAR(100) NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NULL,
+    max_uses INTEGER NULL,
+    use_count INTEGER NOT NULL DEFAULT 0,
+    requires_approval BOOLEAN NOT NULL DEFAULT FALSE,
+    revoked_at TIMESTAMP NULL,
+    revoked_by VARCHAR(100) NULL
+);
+
+CREATE TABLE IF NOT EXISTS group_invite_link_uses (
+    link_id INTEGER NOT NULL REFERENCES group_invite_links(link_id) ON DELETE CASCADE,
+    user_id VARCHAR(100) NOT NULL REFERENCES users(id),
+    used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (link_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS group_expenses (
+    expense_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NULL REFERENCES groups(id) ON DELETE CASCADE,
+    payer_id VARCHAR(100) NOT NULL REFERENCES users(id),
+    amount DECIMAL(10, 2) NOT NULL,
+    description_note TEXT,
+    payment_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    payment_title VARCHAR(255) NOT NULL,
+    bill_image_url VARCHAR(255) NULL,
+    deleted_at TIMESTAMP NULL,
+    deleted_by VARCHAR(100) NULL
+);
+CREATE INDEX IF NOT EXISTS idx_group_expenses_deleted_at ON group_expenses (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_group_expenses_group ON group_expenses (group_id, deleted_at, payment_date);
+
+CREATE TABLE IF NOT EXISTS group_expense_participants (
+    expense_id INTEGER NOT NULL REFERENCES group_expenses(expense_id) ON DELETE CASCADE,
+    user_id VARCHAR(100) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount_share DECIMAL(10, 2),
+    payment_status TEXT DEFAULT 'unpaid' CHECK (payment_status IN ('paid', 'unpaid')),
+    PRIMARY KEY (expense_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS fcm_table (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    fcm_token TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(100) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    notification_type VARCHAR(50) NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    data TEXT NULL,
+    is_read BOOLEAN NOT NULL DEFAULT FALSE,
+    read_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS group_reminder_settings (
+    group_id INTEGER PRIMARY KEY REFERENCES groups(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    first_reminder_days INTEGER NOT NULL DEFAULT 3,
+    repeat_every_days INTEGER NOT NULL DEFAULT 7,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS payment_reminders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    creditor_id VARCHAR(100) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    debtor_id VARCHAR(100) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reminder_type TEXT NOT NULL CHECK (reminder_type IN ('scheduled', 'nudge')),
+    amount DECIMAL(10, 2) NOT NULL,
+    sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS group_activity (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    actor_id VARCHAR(100) NULL,
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NULL,
+    before_data TEXT NULL,
+    after_data TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_group_activity_group ON group_activity (group_id, id);
+
+CREATE TABLE IF NOT EXISTS data_exports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(100) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'ready', 'failed', 'expired')),
+    download_token VARCHAR(64) NULL UNIQUE,
+    file_path VARCHAR(255) NULL,
+    error_message TEXT NULL,
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    claimed_at TIMESTAMP NULL,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON data_exports (status, requested_at);
+
+CREATE TABLE IF NOT EXISTS friend_requests (
+    request_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    requester_id VARCHAR(100) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id VARCHAR(100) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    request_status TEXT NOT NULL DEFAULT 'pending' CHECK (request_status IN ('pending', 'accepted', 'rejected')),
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    responded_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS idx_friend_requests_user ON friend_requests (user_id, request_status);
+
+CREATE TABLE IF NOT EXISTS friendships (
+    user_id VARCHAR(100) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    friend_id VARCHAR(100) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, friend_id)
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id VARCHAR(100) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INTEGER NULL,
+    content_type VARCHAR(100) NULL,
+    response_body BLOB NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys (expires_at);
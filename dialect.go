@@ -0,0 +1,93 @@
+package main
+
+import "strings"
+
+// sqlDialect MySQL ile SQLite arasında farklı yazılan SQL parçalarını üretir. Sorguların geri
+// kalanı iki veritabanında da geçerli SQL ile yazılır (NOW() yerine CURRENT_TIMESTAMP, IF()
+// yerine CASE, UPDATE ... JOIN yerine alt sorgu). Sıfır değeri MySQL'dir; testler aynı
+// KasaRepository metotlarını dialectSQLite ile çalıştırır (bkz. sqlite.go).
+type sqlDialect int
+
+const (
+	dialectMySQL sqlDialect = iota
+	dialectSQLite
+)
+
+// unix zaman ifadesini unix saniyeye çevirir; NULL için NULL döner
+func (d sqlDialect) unix(expr string) string {
+	if d == dialectSQLite {
+		return "CAST(strftime('%s', " + expr + ") AS INTEGER)"
+	}
+	return "UNIX_TIMESTAMP(" + expr + ")"
+}
+
+// fromUnix ? parametresindeki unix saniyeyi zamana çevirir; NULL için NULL döner
+func (d sqlDialect) fromUnix() string {
+	if d == dialectSQLite {
+		return "datetime(?, 'unixepoch')"
+	}
+	return "FROM_UNIXTIME(?)"
+}
+
+// plus zaman ifadesine ? parametresi kadar unit (SECOND ya da DAY) ekler
+func (d sqlDialect) plus(expr, unit string) string {
+	return d.interval(expr, "+", unit)
+}
+
+// minus zaman ifadesinden ? parametresi kadar unit (SECOND ya da DAY) çıkarır
+func (d sqlDialect) minus(expr, unit string) string {
+	return d.interval(expr, "-", unit)
+}
+
+func (d sqlDialect) interval(expr, sign, unit string) string {
+	if d == dialectSQLite {
+		return "datetime(" + expr + ", '" + sign + "' || ? || ' " + strings.ToLower(unit) + "s')"
+	}
+	return expr + " " + sign + " INTERVAL ? " + unit
+}
+
+// jsonArrayAgg satırlardaki JSON nesnelerini diziye toplar; satır yoksa MySQL'deki gibi NULL döner
+func (d sqlDialect) jsonArrayAgg(object string) string {
+	if d == dialectSQLite {
+		return "CASE WHEN COUNT(*) = 0 THEN NULL ELSE json_group_array(" + object + ") END"
+	}
+	return "JSON_ARRAYAGG(" + object + ")"
+}
+
+// forUpdate okunan satırları transaction sonuna kadar kilitler. SQLite yazma transaction'larını
+// zaten sıraya koyduğu için orada boştur.
+func (d sqlDialect) forUpdate() string {
+	if d == dialectSQLite {
+		return ""
+	}
+	return "FOR UPDATE"
+}
+
+// insertIgnore anahtar çakışmasında satırı eklemeden geçen INSERT'tür
+func (d sqlDialect) insertIgnore() string {
+	if d == dialectSQLite {
+		return "INSERT OR IGNORE"
+	}
+	return "INSERT IGNORE"
+}
+
+// upsert anahtar (key kolonları) çakışınca columns'ı eklenmek istenen değerlerle günceller. key
+// boşsa MySQL'deki gibi herhangi bir benzersiz anahtardaki çakışma güncellemeye döner.
+func (d sqlDialect) upsert(key string, columns ...string) string {
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		if d == dialectSQLite {
+			set[i] = column + " = excluded." + column
+		} else {
+			set[i] = column + " = VALUES(" + column + ")"
+		}
+	}
+	if d == dialectSQLite {
+		target := ""
+		if key != "" {
+			target = "(" + key + ") "
+		}
+		return "ON CONFLICT " + target + "DO UPDATE SET " + strings.Join(set, ", ")
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ErrorResponse tüm hata yanıtlarının gövdesidir. Code, mesaj anahtarının "error." öneki olmadan
+// hâlidir (group.not_found gibi) ve istemcilerin dayanabileceği kararlı koddur: i18n.go'daki
+// "error." anahtarları kod kataloğudur, yeniden adlandırılmaz ve kaldırılan bir kod başka bir
+// hata için tekrar kullanılmaz. Message isteğin dilindedir, Details mesajdaki alanları taşır.
+type ErrorResponse struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details"`
+	RequestID string            `json:"request_id"`
+}
+
+// errorCode mesaj anahtarından istemciye dönen kodu üretir
+func errorCode(key string) string {
+	return strings.TrimPrefix(key, "error.")
+}
+
+// httpError hatayı isteğin dilinde, ErrorResponse biçiminde yazar
+func httpError(w http.ResponseWriter, r *http.Request, key string, status int, params map[string]string) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Code:      errorCode(key),
+		Message:   T(requestLocale(r), key, params),
+		Details:   params,
+		RequestID: requestIDFrom(r),
+	})
+}
+
+// ErrorKind depo hatalarının türüdür ve HTTP durum kodunu belirler.
+// errors.Is(err, ErrNotFound) gibi türe göre kontrol edilebilir.
+type ErrorKind string
+
+const (
+	ErrNotFound    ErrorKind = "not_found"
+	ErrForbidden   ErrorKind = "forbidden"
+	ErrConflict    ErrorKind = "conflict"
+	ErrValidation  ErrorKind = "validation"
+	ErrGone        ErrorKind = "gone"
+	ErrRateLimited ErrorKind = "rate_limited"
+)
+
+func (k ErrorKind) Error() string {
+	return string(k)
+}
+
+func (k ErrorKind) status() int {
+	switch k {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrForbidden:
+		return http.StatusForbidden
+	case ErrConflict:
+		return http.StatusConflict
+	case ErrValidation:
+		return http.StatusBadRequest
+	case ErrGone:
+		return http.StatusGone
+	case ErrRateLimited:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// DomainError depoların kullanıcıya gösterilebilecek hatasıdır: türü, istemciye dönecek mesaj
+// anahtarı ve loglar için açıklaması vardır. Depolar bu hataları paket düzeyinde değişken olarak
+// tanımlar, handler'lar errors.Is ile karşılaştırabilir ya da doğrudan writeError'a verebilir.
+type DomainError struct {
+	Kind ErrorKind
+	Key  string
+	Msg  string
+}
+
+func newDomainError(kind ErrorKind, key, msg string) *DomainError {
+	return &DomainError{Kind: kind, Key: key, Msg: msg}
+}
+
+func (e *DomainError) Error() string {
+	return e.Msg
+}
+
+func (e *DomainError) Is(target error) bool {
+	kind, ok := target.(ErrorKind)
+	return ok && kind == e.Kind
+}
+
+// writeError depo hatasını istemciye yazar. DomainError kendi durum kodu ve mesajıyla döner;
+// diğer hatalar loglanır ve fallbackKey ile 500 döner, iç ayrıntılar istemciye gönderilmez.
+func writeError(w http.ResponseWriter, r *http.Request, err error, fallbackKey string) {
+	var domainErr *DomainError
+	if errors.As(err, &domainErr) {
+		httpError(w, r, domainErr.Key, domainErr.Kind.status(), nil)
+		return
+	}
+	log.Printf("[%s] %s %s: %v", requestIDFrom(r), r.Method, r.URL.Path, err)
+	httpError(w, r, fallbackKey, http.StatusInternalServerError, nil)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+// testdata/golden altındaki dosyalar yanıtların istemcilerle paylaşılan örnekleridir. Modellerde
+// alan eklenir, silinir ya da tipi değişirse TestGoldenResponses bu dosyalarla birlikte
+// güncellenmelidir.
+
+//go:embed testdata/golden/group.json
+var goldenGroupJSON []byte
+
+//go:embed testdata/golden/expense_create.json
+var goldenExpenseCreateJSON []byte
+
+//go:embed testdata/golden/expense_delete.json
+var goldenExpenseDeleteJSON []byte
+
+//go:embed testdata/golden/expense_restore.json
+var goldenExpenseRestoreJSON []byte
+
+// matchJSONShape actual'ın golden ile aynı alanlara ve tiplere sahip olduğunu kontrol eder; değerler
+// karşılaştırılmaz. null sadece golden'da null olan yerlerde kabul edilir, dizilerin her elemanı
+// golden'daki ilk elemanla karşılaştırılır.
+func matchJSONShape(path string, golden, actual interface{}) error {
+	if golden == nil {
+		return nil
+	}
+	if actual == nil {
+		return fmt.Errorf("%s: null gelmemeliydi", path)
+	}
+	switch g := golden.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: nesne beklenirdi, gelen %T", path, actual)
+		}
+		keys := make([]string, 0, len(g)+len(a))
+		for key := range g {
+			keys = append(keys, key)
+		}
+		for key := range a {
+			if _, ok := g[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			gv, inGolden := g[key]
+			av, inActual := a[key]
+			if !inGolden {
+				return fmt.Errorf("%s.%s: sözleşmede olmayan alan", path, key)
+			}
+			if !inActual {
+				return fmt.Errorf("%s.%s: alan eksik", path, key)
+			}
+			if err := matchJSONShape(path+"."+key, gv, av); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: dizi beklenirdi, gelen %T", path, actual)
+		}
+		if len(g) == 0 {
+			return nil
+		}
+		for i, av := range a {
+			if err := matchJSONShape(fmt.Sprintf("%s[%d]", path, i), g[0], av); err != nil {
+				return err
+			}
+		}
+	default:
+		if fmt.Sprintf("%T", golden) != fmt.Sprintf("%T", actual) {
+			return fmt.Errorf("%s: %T beklenirdi, gelen %T", path, golden, actual)
+		}
+	}
+	return nil
+}
+
+// matchGolden actual JSON'unu golden dosyasıyla karşılaştırır; name hata mesajlarındaki köktür
+func matchGolden(name string, golden, actual []byte) error {
+	var g, a interface{}
+	if err := json.Unmarshal(golden, &g); err != nil {
+		return fmt.Errorf("%s golden dosyası okunamadı: %w", name, err)
+	}
+	if err := json.Unmarshal(actual, &a); err != nil {
+		return fmt.Errorf("%s yanıtı JSON değil: %w", name, err)
+	}
+	return matchJSONShape(name, g, a)
+}
+
+func TestMatchJSONShape(t *testing.T) {
+	golden := `{"id": 1, "note": null, "items": [{"name": "a"}]}`
+	cases := []struct {
+		actual string
+		ok     bool
+	}{
+		{`{"id": 2, "note": "x", "items": [{"name": "b"}]}`, true},
+		{`{"id": 2, "note": null, "items": []}`, true},
+		{`{"id": 2, "note": null, "items": null}`, false},
+		{`{"id": null, "note": null, "items": []}`, false},
+		{`{"id": "2", "note": null, "items": []}`, false},
+		{`{"id": 2, "note": null, "items": [{"name": null}]}`, false},
+		{`{"id": 2, "items": []}`, false},
+		{`{"id": 2, "note": null, "items": [], "extra": 1}`, false},
+	}
+	for _, c := range cases {
+		err := matchGolden("x", []byte(golden), []byte(c.actual))
+		if (err == nil) != c.ok {
+			t.Errorf("%s: hata %v, kabul beklenen %v", c.actual, err, c.ok)
+		}
+	}
+}
+
+// TestGoldenResponses harcama ekleme, silme ve geri alma yanıtlarını ve dolu bir grubu golden
+// dosyalarıyla karşılaştırır. Yanıtlar handler'lardan alınır; MySQL için TestRepositoryConformance
+// gibi KASA_TEST_MYSQL_DSN kullanılır.
+func TestGoldenResponses(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		repo, err := openSQLiteRepository(":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { repo.DB.Close() })
+		checkGoldenResponses(t, repo)
+	})
+	t.Run("mysql", func(t *testing.T) {
+		dsn := os.Getenv("KASA_TEST_MYSQL_DSN")
+		if dsn == "" {
+			t.Skip("KASA_TEST_MYSQL_DSN verilmedi")
+		}
+		checkGoldenResponses(t, openMySQLTestRepository(t, dsn))
+	})
+}
+
+func checkGoldenResponses(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	owner, member, invited := "golden-owner-"+suffix, "golden-member-"+suffix, "golden-invited-"+suffix
+
+	// Üyenin gözünden tüm listelerin dolu olduğu bir grup: üye sahibe borçlu, sahip de üyeye,
+	// ve bekleyen bir davet var
+	for _, id := range []string{owner, member, invited} {
+		if err := repo.CreateUser(id, "Kullanıcı "+id, id+"@example.com", "hash", "TR00", "tr"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	groupID, err := repo.CreateGroup(owner, "Ev", "golden-token-"+suffix, "TRY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := fmt.Sprint(groupID)
+	if _, _, err := repo.sendAddGroupRequest(group, member+"@example.com", owner); err != nil {
+		t.Fatal(err)
+	}
+	requests, err := repo.getMyAddRequests(member)
+	if err != nil || len(requests) != 1 {
+		t.Fatalf("üyeye gelen istekler: %v %v", requests, err)
+	}
+	if _, err := repo.acceptAddRequest(requests[0].RequestID, member); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := repo.sendAddGroupRequest(group, invited+"@example.com", owner); err != nil {
+		t.Fatal(err)
+	}
+	ownerShare, memberShare := 40.0, 60.0
+	if _, err := repo.createGroupExpense(ctx, owner, CreateExpenseRequest{
+		GroupID: int(groupID), TotalAmount: 100, Note: "Haftalık alışveriş", PaymentTitle: "Market",
+		Users: []ExpenseUser{{UserID: owner, Amount: &ownerShare}, {UserID: member, Amount: &memberShare}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	share := 25.0
+	w := openAPICall{"POST", "/api/v1/groups/{id}/expenses", handleCreateGroupExpense(repo), member,
+		map[string]string{"id": group}, CreateExpenseRequest{
+			TotalAmount: 50, Note: "Akşam yemeği", PaymentTitle: "Restoran",
+			Users: []ExpenseUser{{UserID: owner, Amount: &share}, {UserID: member, Amount: &share}},
+		}, http.StatusCreated}.serve(ctx)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("harcama ekleme: durum %d: %s", w.Code, w.Body.String())
+	}
+	if err := matchGolden("expense_create", goldenExpenseCreateJSON, w.Body.Bytes()); err != nil {
+		t.Error(err)
+	}
+	var created ExpenseWithParticipantsAndBalances
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	expense := map[string]string{"id": group, "expenseId": fmt.Sprint(created.Expense.ExpenseID)}
+
+	w = openAPICall{"DELETE", "/api/v1/groups/{id}/expenses/{expenseId}", handleDeleteExpense(repo), member,
+		expense, nil, http.StatusOK}.serve(ctx)
+	if w.Code != http.StatusOK {
+		t.Fatalf("harcama silme: durum %d: %s", w.Code, w.Body.String())
+	}
+	if err := matchGolden("expense_delete", goldenExpenseDeleteJSON, w.Body.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	w = openAPICall{"POST", "/api/v1/groups/{id}/expenses/{expenseId}/restore", handleRestoreExpense(repo), member,
+		expense, nil, http.StatusOK}.serve(ctx)
+	if w.Code != http.StatusOK {
+		t.Fatalf("harcama geri alma: durum %d: %s", w.Code, w.Body.String())
+	}
+	if err := matchGolden("expense_restore", goldenExpenseRestoreJSON, w.Body.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	details, err := repo.getGroupDetails(group, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := matchGolden("group", goldenGroupJSON, encoded); err != nil {
+		t.Error(err)
+	}
+}
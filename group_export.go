@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+const defaultCurrency = "TRY"
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// normalizeCurrency ISO 4217 kodunu büyük harfe çevirir, boşsa varsayılan para birimini döner
+func normalizeCurrency(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return defaultCurrency, true
+	}
+	return code, currencyPattern.MatchString(code)
+}
+
+// spreadsheetCell =, +, -, @, sekme veya satır başıyla başlayan metnin önüne ' ekler; böylece
+// kullanıcının girdiği grup adı, başlık, not ya da isim tabloda formül olarak çalışmaz.
+// Sayılar (negatif tutarlar dahil) olduğu gibi bırakılır.
+func spreadsheetCell(s string) string {
+	if s == "" || !strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return s
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return s
+	}
+	return "'" + s
+}
+
+type GroupMemberSummary struct {
+	UserID   string  `json:"user_id"`
+	FullName string  `json:"fullname"`
+	Paid     float64 `json:"paid"`  // ödediği harcamaların toplamı
+	Share    float64 `json:"share"` // harcamalardaki kendi payı
+	Net      float64 `json:"net"`   // pozitifse alacaklı, negatifse borçlu (ödenmemiş paylar)
+}
+
+type SuggestedSettlement struct {
+	FromID   string  `json:"from_id"`
+	FromName string  `json:"from_name"`
+	ToID     string  `json:"to_id"`
+	ToName   string  `json:"to_name"`
+	Amount   float64 `json:"amount"`
+}
+
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func fromCents(c int64) float64 {
+	return float64(c) / 100
+}
+
+// summarizeGroupReport üye toplamlarını ve net bakiyeleri hesaplar.
+// Net bakiye sadece ödenmemiş paylardan oluşur, böylece öneriler kalan borçları kapatır.
+func summarizeGroupReport(report *GroupReport) []GroupMemberSummary {
+	paid := map[string]int64{}
+	share := map[string]int64{}
+	net := map[string]int64{}
+
+	for _, e := range report.Expenses {
+		paid[e.PayerID] += toCents(e.Amount)
+		for _, p := range e.Participants {
+			share[p.UserID] += toCents(p.AmountShare)
+			if p.UserID != e.PayerID && p.PaymentStatus != "paid" {
+				net[e.PayerID] += toCents(p.AmountShare)
+				net[p.UserID] -= toCents(p.AmountShare)
+			}
+		}
+	}
+
+	summaries := make([]GroupMemberSummary, 0, len(report.Members))
+	for id, name := range report.Members {
+		summaries = append(summaries, GroupMemberSummary{
+			UserID:   id,
+			FullName: name,
+			Paid:     fromCents(paid[id]),
+			Share:    fromCents(share[id]),
+			Net:      fromCents(net[id]),
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].FullName != summaries[j].FullName {
+			return summaries[i].FullName < summaries[j].FullName
+		}
+		return summaries[i].UserID < summaries[j].UserID
+	})
+	return summaries
+}
+
+// suggestSettlements net bakiyeleri en az sayıda transferle sıfırlayan bir ödeme listesi üretir.
+// En büyük borçlu en büyük alacaklıya öder; bu açgözlü yöntem en fazla n-1 transfer üretir.
+func suggestSettlements(members []GroupMemberSummary) []SuggestedSettlement {
+	type balance struct {
+		id, name string
+		cents    int64
+	}
+	var creditors, debtors []balance
+	for _, m := range members {
+		c := toCents(m.Net)
+		switch {
+		case c > 0:
+			creditors = append(creditors, balance{m.UserID, m.FullName, c})
+		case c < 0:
+			debtors = append(debtors, balance{m.UserID, m.FullName, -c})
+		}
+	}
+	byAmount := func(list []balance) func(i, j int) bool {
+		return func(i, j int) bool {
+			if list[i].cents != list[j].cents {
+				return list[i].cents > list[j].cents
+			}
+			return list[i].id < list[j].id
+		}
+	}
+	sort.Slice(creditors, byAmount(creditors))
+	sort.Slice(debtors, byAmount(debtors))
+
+	settlements := []SuggestedSettlement{}
+	i, j := 0, 0
+	for i < len(debtors) && j < len(creditors) {
+		amount := min(debtors[i].cents, creditors[j].cents)
+		settlements = append(settlements, SuggestedSettlement{
+			FromID:   debtors[i].id,
+			FromName: debtors[i].name,
+			ToID:     creditors[j].id,
+			ToName:   creditors[j].name,
+			Amount:   fromCents(amount),
+		})
+		debtors[i].cents -= amount
+		creditors[j].cents -= amount
+		if debtors[i].cents == 0 {
+			i++
+		}
+		if creditors[j].cents == 0 {
+			j++
+		}
+	}
+	return settlements
+}
+
+type groupExportFormat struct {
+	contentType string
+	extension   string
+	render      func(w io.Writer, locale string, report *GroupReport, members []GroupMemberSummary, settlements []SuggestedSettlement) error
+}
+
+var groupExportFormats = map[string]groupExportFormat{
+	"csv":  {"text/csv; charset=utf-8", "csv", renderGroupReportCSV},
+	"xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx", renderGroupReportXLSX},
+	"pdf":  {"application/pdf", "pdf", renderGroupReportPDF},
+}
+
+func formatMoney(amount float64, currency string) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64) + " " + currency
+}
+
+func formatReportDate(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format("2006-01-02")
+}
+
+func paymentStatusLabel(locale, status string) string {
+	if status == "paid" {
+		return T(locale, "report.status.paid", nil)
+	}
+	return T(locale, "report.status.unpaid", nil)
+}
+
+func renderGroupReportCSV(w io.Writer, locale string, report *GroupReport, members []GroupMemberSummary, settlements []SuggestedSettlement) error {
+	// Excel'in Türkçe karakterleri doğru açması için UTF-8 BOM
+	if _, err := w.Write([]byte("\ufeff")); err != nil {
+		return err
+	}
+	csvw := csv.NewWriter(w)
+	cw := spreadsheetCSVWriter{csvw}
+	tr := func(key string) string { return T(locale, key, nil) }
+	amount := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+
+	cw.Write([]string{tr("report.title"), report.GroupName})
+	cw.Write([]string{tr("report.currency"), report.Currency})
+	cw.Write([]string{tr("report.generated_at"), time.Unix(report.GeneratedAt, 0).UTC().Format(time.RFC3339)})
+	cw.Write(nil)
+
+	// Her katılımcı payı ayrı satır
+	cw.Write([]string{tr("report.expenses")})
+	cw.Write([]string{
+		tr("report.col.expense_id"), tr("report.col.date"), tr("report.col.title"), tr("report.col.note"),
+		tr("report.col.payer"), tr("report.col.amount"), tr("report.col.participant"), tr("report.col.share"),
+		tr("report.col.status"), tr("report.currency"),
+	})
+	for _, e := range report.Expenses {
+		for _, p := range e.Participants {
+			cw.Write([]string{
+				strconv.FormatInt(e.ExpenseID, 10), formatReportDate(e.PaymentDate), e.Title, e.Note,
+				e.PayerName, amount(e.Amount), p.FullName, amount(p.AmountShare),
+				paymentStatusLabel(locale, p.PaymentStatus), report.Currency,
+			})
+		}
+	}
+	cw.Write(nil)
+
+	cw.Write([]string{tr("report.members")})
+	cw.Write([]string{tr("report.col.member"), tr("report.col.paid"), tr("report.col.share"), tr("report.col.net"), tr("report.currency")})
+	for _, m := range members {
+		cw.Write([]string{m.FullName, amount(m.Paid), amount(m.Share), amount(m.Net), report.Currency})
+	}
+	cw.Write(nil)
+
+	cw.Write([]string{tr("report.settlements")})
+	cw.Write([]string{tr("report.col.from"), tr("report.col.to"), tr("report.col.amount"), tr("report.currency")})
+	for _, s := range settlements {
+		cw.Write([]string{s.FromName, s.ToName, amount(s.Amount), report.Currency})
+	}
+
+	csvw.Flush()
+	return csvw.Error()
+}
+
+// spreadsheetCSVWriter her hücreyi spreadsheetCell'den geçirerek yazar
+type spreadsheetCSVWriter struct {
+	*csv.Writer
+}
+
+func (w spreadsheetCSVWriter) Write(record []string) error {
+	escaped := make([]string, len(record))
+	for i, cell := range record {
+		escaped[i] = spreadsheetCell(cell)
+	}
+	return w.Writer.Write(escaped)
+}
+
+func renderGroupReportXLSX(w io.Writer, locale string, report *GroupReport, members []GroupMemberSummary, settlements []SuggestedSettlement) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	tr := func(key string) string { return T(locale, key, nil) }
+
+	moneyFormat := fmt.Sprintf(`#,##0.00 "%s"`, report.Currency)
+	moneyStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &moneyFormat})
+	if err != nil {
+		return err
+	}
+	headerStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	writeSheet := func(sheet string, header []string, rows [][]interface{}, moneyCols ...int) error {
+		if err := f.SetSheetRow(sheet, "A1", &header); err != nil {
+			return err
+		}
+		last, _ := excelize.CoordinatesToCellName(len(header), 1)
+		if err := f.SetCellStyle(sheet, "A1", last, headerStyle); err != nil {
+			return err
+		}
+		for i, row := range rows {
+			for j, v := range row {
+				if text, ok := v.(string); ok {
+					row[j] = spreadsheetCell(text)
+				}
+			}
+			cell, _ := excelize.CoordinatesToCellName(1, i+2)
+			if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+				return err
+			}
+		}
+		for _, col := range moneyCols {
+			from, _ := excelize.CoordinatesToCellName(col, 2)
+			to, _ := excelize.CoordinatesToCellName(col, len(rows)+1)
+			if err := f.SetCellStyle(sheet, from, to, moneyStyle); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	expensesSheet := tr("report.expenses")
+	if err := f.SetSheetName("Sheet1", expensesSheet); err != nil {
+		return err
+	}
+	var rows [][]interface{}
+	for _, e := range report.Expenses {
+		for _, p := range e.Participants {
+			rows = append(rows, []interface{}{
+				e.ExpenseID, formatReportDate(e.PaymentDate), e.Title, e.Note, e.PayerName, e.Amount,
+				p.FullName, p.AmountShare, paymentStatusLabel(locale, p.PaymentStatus),
+			})
+		}
+	}
+	err = writeSheet(expensesSheet, []string{
+		tr("report.col.expense_id"), tr("report.col.date"), tr("report.col.title"), tr("report.col.note"),
+		tr("report.col.payer"), tr("report.col.amount"), tr("report.col.participant"), tr("report.col.share"),
+		tr("report.col.status"),
+	}, rows, 6, 8)
+	if err != nil {
+		return err
+	}
+
+	membersSheet := tr("report.members")
+	if _, err := f.NewSheet(membersSheet); err != nil {
+		return err
+	}
+	rows = nil
+	for _, m := range members {
+		rows = append(rows, []interface{}{m.FullName, m.Paid, m.Share, m.Net})
+	}
+	err = writeSheet(membersSheet, []string{tr("report.col.member"), tr("report.col.paid"), tr("report.col.share"), tr("report.col.net")}, rows, 2, 3, 4)
+	if err != nil {
+		return err
+	}
+
+	settlementsSheet := tr("report.settlements")
+	if _, err := f.NewSheet(settlementsSheet); err != nil {
+		return err
+	}
+	rows = nil
+	for _, s := range settlements {
+		rows = append(rows, []interface{}{s.FromName, s.ToName, s.Amount})
+	}
+	err = writeSheet(settlementsSheet, []string{tr("report.col.from"), tr("report.col.to"), tr("report.col.amount")}, rows, 3)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteTo(w)
+	return err
+}
+
+// Yerleşik PDF fontları cp1252 kullanır; bu kod sayfasında olmayan Türkçe harfler yaklaşık karşılıklarıyla yazılır.
+// PDF_FONT_FILE ile bir TTF verilirse metin olduğu gibi basılır.
+var pdfTurkishReplacer = strings.NewReplacer("ş", "s", "Ş", "S", "ğ", "g", "Ğ", "G", "ı", "i", "İ", "I")
+
+func renderGroupReportPDF(w io.Writer, locale string, report *GroupReport, members []GroupMemberSummary, settlements []SuggestedSettlement) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	family := "Helvetica"
+	text := func(s string) string { return s }
+
+	if fontFile := os.Getenv("PDF_FONT_FILE"); fontFile != "" {
+		fontBytes, err := os.ReadFile(fontFile)
+		if err != nil {
+			return fmt.Errorf("PDF fontu okunamadı: %w", err)
+		}
+		family = "ReportFont"
+		pdf.AddUTF8FontFromBytes(family, "", fontBytes)
+		pdf.AddUTF8FontFromBytes(family, "B", fontBytes)
+	} else {
+		toCP1252 := pdf.UnicodeTranslatorFromDescriptor("")
+		text = func(s string) string { return toCP1252(pdfTurkishReplacer.Replace(s)) }
+	}
+	tr := func(key string) string { return text(T(locale, key, nil)) }
+
+	pdf.SetTitle(report.GroupName, true)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	pdf.SetFont(family, "B", 16)
+	pdf.CellFormat(0, 10, text(report.GroupName), "", 1, "L", false, 0, "")
+	pdf.SetFont(family, "", 9)
+	pdf.CellFormat(0, 5, fmt.Sprintf("%s: %s", tr("report.currency"), report.Currency), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, fmt.Sprintf("%s: %s", tr("report.generated_at"), time.Unix(report.GeneratedAt, 0).UTC().Format("2006-01-02 15:04 UTC")), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	section := func(title string) {
+		pdf.SetFont(family, "B", 12)
+		pdf.CellFormat(0, 8, title, "", 1, "L", false, 0, "")
+	}
+	tableRow := func(widths []float64, cols []string, bold bool, aligns string) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont(family, style, 9)
+		for i, col := range cols {
+			pdf.CellFormat(widths[i], 6, col, "B", 0, string(aligns[i]), false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	// Harcamalar ve altında katılımcı payları
+	section(tr("report.expenses"))
+	expenseWidths := []float64{22, 68, 50, 40}
+	tableRow(expenseWidths, []string{tr("report.col.date"), tr("report.col.title"), tr("report.col.payer"), tr("report.col.amount")}, true, "LLLR")
+	for _, e := range report.Expenses {
+		tableRow(expenseWidths, []string{formatReportDate(e.PaymentDate), text(e.Title), text(e.PayerName), formatMoney(e.Amount, report.Currency)}, false, "LLLR")
+		pdf.SetFont(family, "", 8)
+		for _, p := range e.Participants {
+			pdf.CellFormat(22, 5, "", "", 0, "L", false, 0, "")
+			pdf.CellFormat(68, 5, "  "+text(p.FullName), "", 0, "L", false, 0, "")
+			pdf.CellFormat(50, 5, text(paymentStatusLabel(locale, p.PaymentStatus)), "", 0, "L", false, 0, "")
+			pdf.CellFormat(40, 5, formatMoney(p.AmountShare, report.Currency), "", 1, "R", false, 0, "")
+		}
+	}
+	pdf.Ln(4)
+
+	section(tr("report.members"))
+	memberWidths := []float64{60, 40, 40, 40}
+	tableRow(memberWidths, []string{tr("report.col.member"), tr("report.col.paid"), tr("report.col.share"), tr("report.col.net")}, true, "LRRR")
+	for _, m := range members {
+		tableRow(memberWidths, []string{text(m.FullName), formatMoney(m.Paid, report.Currency), formatMoney(m.Share, report.Currency), formatMoney(m.Net, report.Currency)}, false, "LRRR")
+	}
+	pdf.Ln(4)
+
+	section(tr("report.settlements"))
+	if len(settlements) == 0 {
+		pdf.SetFont(family, "", 9)
+		pdf.CellFormat(0, 6, tr("report.no_settlements"), "", 1, "L", false, 0, "")
+	} else {
+		settlementWidths := []float64{70, 70, 40}
+		tableRow(settlementWidths, []string{tr("report.col.from"), tr("report.col.to"), tr("report.col.amount")}, true, "LLR")
+		for _, s := range settlements {
+			tableRow(settlementWidths, []string{text(s.FromName), text(s.ToName), formatMoney(s.Amount, report.Currency)}, false, "LLR")
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestSpreadsheetCell(t *testing.T) {
+	cases := map[string]string{
+		"":                  "",
+		"Market":            "Market",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+90 555":           "'+90 555",
+		"-Ali":              "'-Ali",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"\t=1+1":            "'\t=1+1",
+		"-12.50":            "-12.50",
+		"+3":                "+3",
+	}
+	for in, want := range cases {
+		if got := spreadsheetCell(in); got != want {
+			t.Errorf("spreadsheetCell(%q) = %q, beklenen %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// Grup yanıtı (üyeler, bekleyen istekler, harcamalar ve kullanıcının borç/alacakları) tek bir
+// büyük sorgu yerine her liste için bir küme sorgusuyla okunur ve Go'da birleştirilir. Sorgular
+// aynı transaction içinde çalışır, böylece listeler aynı anın görüntüsünü taşır. Üyelerin
+// total_share'i ve borç/alacaklar katılımcı satırlarından hesaplanır, ayrı sorgu gerekmez.
+// Sorgular 0018_group_query_indexes'teki index'lere dayanır.
+
+// groupScope yüklenecek grupları seçen koşuldur; where grup kolonu için %s içerir
+type groupScope struct {
+	where string
+	arg   interface{}
+}
+
+func (s groupScope) on(column string) string {
+	return fmt.Sprintf(s.where, column)
+}
+
+// memberGroupsScope kullanıcının üyesi olduğu gruplardır
+func memberGroupsScope(userID string) groupScope {
+	return groupScope{where: "%s IN (SELECT group_id FROM group_members WHERE user_id = ?)", arg: userID}
+}
+
+// singleGroupScope tek bir gruptur
+func singleGroupScope(groupID string) groupScope {
+	return groupScope{where: "%s = ?", arg: groupID}
+}
+
+// groupLoader grup sorgularını çalıştırır; sadece zaman damgası ifadesi veritabanına özeldir
+type groupLoader struct {
+	dialect sqlDialect
+}
+
+// expenseRow harcama ve borç/alacak hesabı için ödeyenin bilgileridir
+type expenseRow struct {
+	Expense
+	payerKnown bool
+	payerIBAN  *string
+	shares     []participantRow
+}
+
+type participantRow struct {
+	Participant
+	iban *string
+}
+
+// load kapsamdaki grupları currentUserID'nin gözünden, oluşturulma tarihine göre yeniden eskiye döner
+func (l groupLoader) load(ctx context.Context, db *sql.DB, scope groupScope, currentUserID string) ([]*Group, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	groups, byID, err := l.loadGroups(ctx, tx, scope, currentUserID)
+	if err != nil || len(groups) == 0 {
+		return groups, err
+	}
+	if err := l.loadMembers(ctx, tx, scope, byID); err != nil {
+		return nil, err
+	}
+	if err := l.loadPendingRequests(ctx, tx, scope, byID); err != nil {
+		return nil, err
+	}
+	expenses, err := l.loadExpenses(ctx, tx, scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	assembleExpenses(byID, expenses, currentUserID)
+	return groups, nil
+}
+
+func (l groupLoader) loadGroups(ctx context.Context, tx *sql.Tx, scope groupScope, currentUserID string) ([]*Group, map[int64]*Group, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT g.id, g.group_token, g.group_name, g.currency, `+l.dialect.unix("g.created_at")+`,
+			u.id, u.fullname, u.email
+		FROM groups g
+		JOIN users u ON u.id = g.creator_id
+		WHERE `+scope.on("g.id")+`
+		ORDER BY g.created_at DESC, g.id DESC
+	`, scope.arg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gruplar alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*Group
+	byID := map[int64]*Group{}
+	for rows.Next() {
+		var g Group
+		var token sql.NullString
+		if err := rows.Scan(&g.ID, &token, &g.Name, &g.Currency, &g.CreatedAt, &g.Creator.ID, &g.Creator.FullName, &g.Creator.Email); err != nil {
+			return nil, nil, err
+		}
+		if token.Valid {
+			g.GroupToken = &token.String
+		}
+		g.IsAdmin = g.Creator.ID == currentUserID
+		groups = append(groups, &g)
+		byID[g.ID] = &g
+	}
+	return groups, byID, rows.Err()
+}
+
+func (l groupLoader) loadMembers(ctx context.Context, tx *sql.Tx, scope groupScope, byID map[int64]*Group) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT gm.group_id, u.id, u.fullname, u.email
+		FROM group_members gm
+		JOIN users u ON u.id = gm.user_id
+		WHERE `+scope.on("gm.group_id")+`
+		ORDER BY gm.group_id, gm.joined_at, gm.user_id
+	`, scope.arg)
+	if err != nil {
+		return fmt.Errorf("grup üyeleri alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var groupID int64
+		var m Member
+		if err := rows.Scan(&groupID, &m.ID, &m.FullName, &m.Email); err != nil {
+			return err
+		}
+		if g := byID[groupID]; g != nil {
+			g.Members = append(g.Members, m)
+		}
+	}
+	return rows.Err()
+}
+
+func (l groupLoader) loadPendingRequests(ctx context.Context, tx *sql.Tx, scope groupScope, byID map[int64]*Group) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT r.group_id, r.request_id, r.user_id, u.fullname, u.email, `+l.dialect.unix("r.requested_at")+`,
+			r.request_status, r.request_direction
+		FROM group_add_requests r
+		JOIN users u ON u.id = r.user_id
+		WHERE `+scope.on("r.group_id")+` AND r.request_status = 'pending'
+		ORDER BY r.group_id, r.requested_at, r.request_id
+	`, scope.arg)
+	if err != nil {
+		return fmt.Errorf("bekleyen istekler alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var req AddRequest
+		if err := rows.Scan(&req.GroupID, &req.RequestID, &req.UserID, &req.FullName, &req.Email, &req.RequestedAt, &req.RequestStatus, &req.RequestDirection); err != nil {
+			return err
+		}
+		if g := byID[req.GroupID]; g != nil {
+			req.GroupName = g.Name
+			g.PendingRequests = append(g.PendingRequests, req)
+		}
+	}
+	return rows.Err()
+}
+
+// loadExpenses silinmemiş harcamaları ödeme tarihine göre, katılımcılarıyla birlikte döner
+func (l groupLoader) loadExpenses(ctx context.Context, tx *sql.Tx, scope groupScope) ([]*expenseRow, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT e.expense_id, e.group_id, e.amount, e.description_note, `+l.dialect.unix("e.payment_date")+`,
+			e.payment_title, e.bill_image_url, e.payer_id, pu.fullname, pu.iban
+		FROM group_expenses e
+		LEFT JOIN users pu ON pu.id = e.payer_id
+		WHERE `+scope.on("e.group_id")+` AND e.deleted_at IS NULL
+		ORDER BY e.group_id, e.payment_date, e.expense_id
+	`, scope.arg)
+	if err != nil {
+		return nil, fmt.Errorf("harcamalar alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	var expenses []*expenseRow
+	byID := map[int64]*expenseRow{}
+	for rows.Next() {
+		var e expenseRow
+		var note, billURL, payerName, payerIBAN sql.NullString
+		err := rows.Scan(&e.ExpenseID, &e.GroupID, &e.Amount, &note, &e.PaymentDate,
+			&e.PaymentTitle, &billURL, &e.PayerID, &payerName, &payerIBAN)
+		if err != nil {
+			return nil, err
+		}
+		e.DescriptionNote = nullStringPtr(note)
+		e.BillImageURL = nullStringPtr(billURL)
+		e.PayerName = payerName.String
+		e.payerKnown = payerName.Valid
+		e.payerIBAN = nullStringPtr(payerIBAN)
+		expenses = append(expenses, &e)
+		byID[e.ExpenseID] = &e
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = tx.QueryContext(ctx, `
+		SELECT p.expense_id, p.user_id, u.fullname, u.iban, p.amount_share, p.payment_status
+		FROM group_expense_participants p
+		JOIN group_expenses e ON e.expense_id = p.expense_id
+		JOIN users u ON u.id = p.user_id
+		WHERE `+scope.on("e.group_id")+` AND e.deleted_at IS NULL
+		ORDER BY p.expense_id, p.user_id
+	`, scope.arg)
+	if err != nil {
+		return nil, fmt.Errorf("harcama katılımcıları alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var expenseID int64
+		var p participantRow
+		var iban, status sql.NullString
+		var share sql.NullFloat64
+		if err := rows.Scan(&expenseID, &p.UserID, &p.UserName, &iban, &share, &status); err != nil {
+			return nil, err
+		}
+		p.iban = nullStringPtr(iban)
+		p.AmountShare = share.Float64
+		p.PaymentStatus = status.String
+		if e := byID[expenseID]; e != nil {
+			e.shares = append(e.shares, p)
+		}
+	}
+	return expenses, rows.Err()
+}
+
+// assembleExpenses harcamaları gruplarına ekler; üyelerin paylarını ve kullanıcının
+// borç/alacaklarını katılımcılardan hesaplar
+func assembleExpenses(byID map[int64]*Group, expenses []*expenseRow, currentUserID string) {
+	type memberKey struct {
+		groupID int64
+		userID  string
+	}
+	totals := map[memberKey]float64{}
+
+	for _, e := range expenses {
+		g := byID[e.GroupID]
+		if g == nil {
+			continue
+		}
+		for _, p := range e.shares {
+			e.Participants = append(e.Participants, p.Participant)
+			totals[memberKey{e.GroupID, p.UserID}] += p.AmountShare
+
+			switch {
+			case p.UserID == currentUserID && e.PayerID != currentUserID && e.payerKnown:
+				g.Debts = append(g.Debts, Debt{
+					UserID:   e.PayerID,
+					Username: e.PayerName,
+					IBAN:     e.payerIBAN,
+					Amount:   p.AmountShare,
+					Status:   p.PaymentStatus,
+					Expenses: []int64{e.ExpenseID},
+				})
+			case e.PayerID == currentUserID && p.UserID != currentUserID:
+				g.Credits = append(g.Credits, Credit{
+					UserID:   p.UserID,
+					Username: p.UserName,
+					IBAN:     p.iban,
+					Amount:   p.AmountShare,
+					Status:   p.PaymentStatus,
+					Expenses: []int64{e.ExpenseID},
+				})
+			}
+		}
+		g.Expenses = append(g.Expenses, e.Expense)
+	}
+
+	for _, g := range byID {
+		for i := range g.Members {
+			// Paylar kuruş hassasiyetindedir; float toplamının artığı yanıta taşınmasın
+			total := totals[memberKey{g.ID, g.Members[i].ID}]
+			g.Members[i].TotalShare = math.Round(total*100) / 100
+		}
+	}
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// loadGroupDetails tek grubu döner, grup yoksa sql.ErrNoRows
+func (l groupLoader) loadGroupDetails(ctx context.Context, db *sql.DB, groupID, currentUserID string) (*Group, error) {
+	groups, err := l.load(ctx, db, singleGroupScope(groupID), currentUserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return groups[0], nil
+}
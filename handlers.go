@@ -1,29 +1,28 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
-func RegisterUserHandler(repo *KasaRepository) http.HandlerFunc {
+func RegisterUserHandler(repo Repository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Yalnızca POST metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
-
 		var req UserRegisterRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON formatı", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
@@ -32,16 +31,20 @@ func RegisterUserHandler(repo *KasaRepository) http.HandlerFunc {
 		req.Email = strings.TrimSpace(req.Email)
 		req.Password = strings.TrimSpace(req.Password)
 
-		if req.FullName == "" || req.Email == "" || req.Password == "" {
-			http.Error(w, "Tüm alanlar zorunludur", http.StatusBadRequest)
-			return
+		locale := localeFromAcceptLanguage(r.Header.Get("Accept-Language"))
+		if req.Locale != "" {
+			if !isSupportedLocale(req.Locale) {
+				httpError(w, r, "error.user.unsupported_locale", http.StatusBadRequest, map[string]string{"locale": req.Locale})
+				return
+			}
+			locale = req.Locale
 		}
 
 		// 1. Firebase'de kullanıcı oluştur
 		firebaseUID, err := CreateFirebaseUser(req.Email, req.Password)
 		if err != nil {
 			log.Println("Firebase kullanıcı oluşturma hatası:", err)
-			http.Error(w, "Kullanıcı Firebase'de oluşturulamadı", http.StatusInternalServerError)
+			httpError(w, r, "error.user.firebase_create", http.StatusInternalServerError, nil)
 			return
 		}
 
@@ -49,12 +52,12 @@ func RegisterUserHandler(repo *KasaRepository) http.HandlerFunc {
 		hashedPwd, err := HashPassword(req.Password)
 		if err != nil {
 			log.Println("Hashleme hatası:", err)
-			http.Error(w, "Sunucu hatası", http.StatusInternalServerError)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
 			return
 		}
 
 		// 3. Veritabanına kaydet
-		err = repo.CreateUser(firebaseUID, req.FullName, req.Email, hashedPwd, req.Iban)
+		err = repo.CreateUser(firebaseUID, req.FullName, req.Email, hashedPwd, req.Iban, locale)
 		if err != nil {
 			log.Println("DB hatası:", err)
 
@@ -64,15 +67,18 @@ func RegisterUserHandler(repo *KasaRepository) http.HandlerFunc {
 				log.Printf("❗ Firebase kullanıcı silinemedi: %v", delErr)
 			}
 
-			http.Error(w, "Kullanıcı oluşturulamadı", http.StatusInternalServerError)
+			httpError(w, r, "error.user.create_failed", http.StatusInternalServerError, nil)
 			return
 		}
 
+		// Bu email'e kayıttan önce gönderilmiş grup davetleri isteğe dönüşür
+		convertInvitationsForNewUser(r.Context(), repo, firebaseUID, req.Email)
+
 		// 4. Firebase Auth token al
 		authResult, err := AuthenticateFirebaseUser(req.Email, req.Password)
 		if err != nil {
 			log.Println("Firebase kimlik doğrulama hatası:", err)
-			http.Error(w, "Kimlik doğrulama başarısız", http.StatusUnauthorized)
+			httpError(w, r, "error.auth.failed", http.StatusUnauthorized, nil)
 			return
 		}
 
@@ -85,7 +91,7 @@ func RegisterUserHandler(repo *KasaRepository) http.HandlerFunc {
 		})
 		if err != nil {
 			log.Println("JWT oluşturma hatası:", err)
-			http.Error(w, "Sunucu hatası", http.StatusInternalServerError)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
 			return
 		}
 
@@ -93,23 +99,17 @@ func RegisterUserHandler(repo *KasaRepository) http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message":   "Kullanıcı başarıyla oluşturuldu",
+			"message":   T(requestLocale(r), "message.user_created", nil),
 			"jwtToken":  jwtToken,
 			"expiresIn": authResult.ExpiresIn + "s",
 		})
 	}
 }
 
-func LoginUserHandler(repo *KasaRepository) http.HandlerFunc {
+func LoginUserHandler(repo UserStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Yalnızca POST metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
-
-		var req UserRegisterRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON formatı", http.StatusBadRequest)
+		var req UserLoginRequest
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
@@ -117,30 +117,26 @@ func LoginUserHandler(repo *KasaRepository) http.HandlerFunc {
 		req.Email = strings.TrimSpace(req.Email)
 		req.Password = strings.TrimSpace(req.Password)
 
-		if req.Email == "" || req.Password == "" {
-			http.Error(w, "Email ve şifre zorunludur", http.StatusBadRequest)
-			return
-		}
-
 		authResult, err := AuthenticateFirebaseUser(req.Email, req.Password)
 		if err != nil {
 			log.Println("Firebase kimlik doğrulama hatası:", err)
-			http.Error(w, "Geçersiz email veya şifre", http.StatusUnauthorized)
+			httpError(w, r, "error.auth.invalid_creds", http.StatusUnauthorized, nil)
 			return
 		}
 
 		userData, err := repo.GetUserByID(authResult.UID)
 		if err != nil {
 			log.Println("Kullanıcı bilgileri alınamadı:", err)
-			http.Error(w, "Kullanıcı bilgileri alınamadı", http.StatusInternalServerError)
+			httpError(w, r, "error.user.fetch_failed", http.StatusInternalServerError, nil)
 			return
 		}
 		if userData == nil {
-			http.Error(w, "Kullanıcı bulunamadı", http.StatusNotFound)
+			httpError(w, r, "error.user.not_found", http.StatusNotFound, nil)
 			return
 		}
-		if userData.Deleted {
-			http.Error(w, "Kullanıcı silinmiş", http.StatusUnauthorized)
+		// Silme talebi bekleyen kullanıcı geri alabilmek için giriş yapabilir
+		if userData.Deleted && userData.DeletionStatus != deletionStatusPending {
+			httpError(w, r, "error.user.deleted", http.StatusUnauthorized, nil)
 			return
 		}
 
@@ -153,7 +149,7 @@ func LoginUserHandler(repo *KasaRepository) http.HandlerFunc {
 		})
 		if err != nil {
 			log.Println("JWT oluşturma hatası:", err)
-			http.Error(w, "Sunucu hatası", http.StatusInternalServerError)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
 			return
 		}
 
@@ -161,412 +157,197 @@ func LoginUserHandler(repo *KasaRepository) http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message":   "Giriş başarılı",
-			"jwtToken":  jwtToken,
-			"expiresIn": authResult.ExpiresIn + "s",
+			"message":         T(requestLocale(r), "message.login_success", nil),
+			"jwtToken":        jwtToken,
+			"expiresIn":       authResult.ExpiresIn + "s",
+			"deletion_status": userData.DeletionStatus,
 		})
 	}
 }
 
 type CreateGroupRequest struct {
-	GroupName string `json:"group_name"`
+	GroupName string `json:"group_name" validate:"required,max=100"`
+	Currency  string `json:"currency"` // opsiyonel, ISO 4217 kodu (varsayılan TRY)
 }
 
-func CreateGroupHandler(repo *KasaRepository) http.HandlerFunc {
+func CreateGroupHandler(repo GroupStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Yalnızca POST isteği kabul edilir
-		if r.Method != http.MethodPost {
-			http.Error(w, "Yalnızca POST metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
-
 		// Kimlik doğrulama
 		userUID, ok := r.Context().Value("userUID").(string)
 		if !ok || userUID == "" {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
 		// İstek gövdesini çözümle
 		var req CreateGroupRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
 
-		// Boş grup adı kontrolü
 		req.GroupName = strings.TrimSpace(req.GroupName)
-		if req.GroupName == "" {
-			http.Error(w, "group_name alanı zorunludur", http.StatusBadRequest)
+
+		currency, ok := normalizeCurrency(req.Currency)
+		if !ok {
+			httpError(w, r, "error.group.invalid_currency", http.StatusBadRequest, nil)
 			return
 		}
 
 		groupToken, err := generateToken(8)
 		if err != nil {
 			log.Println("Token oluşturulamadı:", err)
-			http.Error(w, "Sunucu hatası", http.StatusInternalServerError)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
 			return
 		}
 		groupToken += fmt.Sprintf("%d", time.Now().Unix())
 
-		_, err = repo.CreateGroup(userUID, req.GroupName, groupToken)
+		_, err = repo.CreateGroup(userUID, req.GroupName, groupToken, currency)
 		if err != nil {
 			log.Println("Grup oluşturulamadı:", err)
-			http.Error(w, "Grup oluşturulamadı", http.StatusInternalServerError)
+			httpError(w, r, "error.group.create_failed", http.StatusInternalServerError, nil)
 			return
 		}
 
 		// Kullanıcının grup listesini getir
-		rows, err := repo.getMyGroups(userUID)
+		myGroups, err := repo.getMyGroups(userUID)
 		if err != nil {
 			log.Println("Grup bilgileri alınamadı:", err)
-			http.Error(w, "Grup bilgileri alınamadı", http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
-
-		var groups []map[string]interface{}
-		for rows.Next() {
-			var groupID int64
-			var groupToken sql.NullString
-			var groupName, creatorID, creatorName, creatorEmail string
-			var createdAt int64
-			var membersJSON, requestsJSON, expensesJSON, debtsJSON, creditsJSON []byte
-
-			if err := rows.Scan(
-				&groupID,
-				&groupToken,
-				&groupName,
-				&createdAt,
-				&creatorID,
-				&creatorName,
-				&creatorEmail,
-				&membersJSON,
-				&requestsJSON,
-				&expensesJSON,
-				&debtsJSON,
-				&creditsJSON,
-			); err != nil {
-				log.Println("Satır okunamadı:", err)
-				http.Error(w, "Grup bilgileri alınamadı", http.StatusInternalServerError)
-				return
-			}
-
-			var members, requests, expenses, debts, credits []map[string]interface{}
-			_ = json.Unmarshal(membersJSON, &members)
-			_ = json.Unmarshal(requestsJSON, &requests)
-			_ = json.Unmarshal(expensesJSON, &expenses)
-			_ = json.Unmarshal(debtsJSON, &debts)
-			_ = json.Unmarshal(creditsJSON, &credits)
-
-			groups = append(groups, map[string]interface{}{
-				"id": groupID,
-				"group_token": func() interface{} {
-					if groupToken.Valid {
-						return groupToken.String
-					}
-					return nil
-				}(),
-				"name":       groupName,
-				"created_at": createdAt,
-				"is_admin":   creatorID == userUID,
-				"creator": map[string]interface{}{
-					"id":       creatorID,
-					"fullname": creatorName,
-					"email":    creatorEmail,
-				},
-				"members":          members,
-				"pending_requests": requests,
-				"expenses":         expenses,
-				"debts":            debts,
-				"credits":          credits,
-			})
+			httpError(w, r, "error.group.fetch_failed", http.StatusInternalServerError, nil)
+			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated) // Status code'u burda set et
 
-		if err := json.NewEncoder(w).Encode(groups); err != nil {
+		if err := json.NewEncoder(w).Encode(myGroups); err != nil {
 			log.Println("Yanıt gönderilemedi:", err)
 		}
 
 	}
 }
 
-func GetGroups(repo *KasaRepository) http.HandlerFunc {
+func GetGroups(repo Repository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Yalnızca GET metodu desteklenir", http.StatusMethodNotAllowed)
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
-		userUID := r.Context().Value("userUID")
-		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+		// Sürüm gruplardan önce okunur; arada gelen değişiklik bir sonraki istekte ETag'i değiştirir
+		version, err := repo.GetDataVersion(r.Context(), userUID.(string))
+		if err != nil {
+			log.Println("Veri sürümü alınamadı:", err)
+			httpError(w, r, "error.group.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		if checkNotModified(w, r, dataVersionETag(etagResourceGroups, version)) {
 			return
 		}
 
-		rows, err := repo.getMyGroups(userUID.(string))
+		myGroups, err := repo.getMyGroups(userUID.(string))
 		if err != nil {
 			log.Println("Grup bilgileri alınamadı:", err)
-			http.Error(w, "Grup bilgileri alınamadı", http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
-
-		var groups []map[string]interface{}
-		for rows.Next() {
-			var groupID int64
-			var groupToken sql.NullString
-			var groupName, creatorID, creatorName, creatorEmail string
-			var createdAt int64
-			var membersJSON, requestsJSON, expensesJSON, debtsJSON, creditsJSON []byte
-
-			if err := rows.Scan(
-				&groupID,
-				&groupToken,
-				&groupName,
-				&createdAt,
-				&creatorID,
-				&creatorName,
-				&creatorEmail,
-				&membersJSON,
-				&requestsJSON,
-				&expensesJSON,
-				&debtsJSON,
-				&creditsJSON,
-			); err != nil {
-				log.Println("Satır okunamadı:", err)
-				http.Error(w, "Grup bilgileri alınamadı", http.StatusInternalServerError)
-				return
-			}
-
-			var members, requests, expenses, debts, credits []map[string]interface{}
-			_ = json.Unmarshal(membersJSON, &members)
-			_ = json.Unmarshal(requestsJSON, &requests)
-			_ = json.Unmarshal(expensesJSON, &expenses)
-			_ = json.Unmarshal(debtsJSON, &debts)
-			_ = json.Unmarshal(creditsJSON, &credits)
-
-			groups = append(groups, map[string]interface{}{
-				"id": groupID,
-				"group_token": func() interface{} {
-					if groupToken.Valid {
-						return groupToken.String
-					}
-					return nil
-				}(),
-				"name":       groupName,
-				"created_at": createdAt,
-				"is_admin":   creatorID == userUID.(string),
-				"creator": map[string]interface{}{
-					"id":       creatorID,
-					"fullname": creatorName,
-					"email":    creatorEmail,
-				},
-				"members":          members,
-				"pending_requests": requests,
-				"expenses":         expenses,
-				"debts":            debts,
-				"credits":          credits,
-			})
+			httpError(w, r, "error.group.fetch_failed", http.StatusInternalServerError, nil)
+			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(groups)
+		json.NewEncoder(w).Encode(myGroups)
 	}
 }
 
 type AddGroupRequest struct {
-	GroupID     string `json:"group_id"`
-	AddedMember string `json:"added_member"`
+	GroupID     string `json:"group_id"` // eski yol; /api/v1 yolunda yoldan gelir
+	AddedMember string `json:"added_member" validate:"required,email,max=100"`
 }
 
-func SendAddRequest(repo *KasaRepository) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Yalnızca POST metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
+// SendAddRequestResponse güncel grubu döner; hesabı olmayan email için açılan davet de eklenir
+type SendAddRequestResponse struct {
+	*Group
+	EmailInvitation *EmailInvitation `json:"email_invitation,omitempty"`
+}
 
+func SendAddRequest(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		userUID := r.Context().Value("userUID")
 		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
 		var req AddGroupRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
+		if groupID := r.PathValue("id"); groupID != "" {
+			req.GroupID = groupID
+		}
 
 		req.GroupID = strings.TrimSpace(req.GroupID)
 		req.AddedMember = strings.TrimSpace(req.AddedMember)
 		if req.AddedMember == "" || req.GroupID == "" {
-			http.Error(w, "group_id ve added_member alanları zorunludur", http.StatusBadRequest)
-			return
-		}
-
-		row, err := repo.sendAddGroupRequest(req.GroupID, req.AddedMember, userUID.(string))
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		var (
-			groupID      int
-			groupToken   sql.NullString
-			groupName    string
-			createdTS    int64
-			creatorID    string
-			creatorName  string
-			creatorEmail string
-			membersJSON,
-			requestsJSON,
-			expensesJSON,
-			debtsJSON,
-			creditsJSON sql.NullString
-		)
-
-		err = row.Scan(
-			&groupID,
-			&groupToken,
-			&groupName,
-			&createdTS,
-			&creatorID,
-			&creatorName,
-			&creatorEmail,
-			&membersJSON,
-			&requestsJSON,
-			&expensesJSON,
-			&debtsJSON,
-			&creditsJSON,
-		)
-		rows, err := repo.GetUserByEmail(req.AddedMember)
-		if err != nil {
-			log.Printf("Kullanıcı bulunamadı: %v", err)
+			httpError(w, r, "error.request.fields_required", http.StatusBadRequest, nil)
 			return
 		}
-		defer rows.Close()
 
-		var userID string
-		if rows.Next() {
-			if err := rows.Scan(&userID); err != nil {
-				log.Printf("Kullanıcı ID okunamadı: %v", err)
-				return
-			}
-		} else {
-			log.Println("Kullanıcı bulunamadı")
+		group, invitation, err := repo.sendAddGroupRequest(req.GroupID, req.AddedMember, userUID.(string))
+		if err != nil {
+			writeError(w, r, err, "error.request.send_failed")
 			return
 		}
 
-		userIDStr := fmt.Sprintf("%v", userID)
-
-		notificationTitle := "Yeni grup isteği"
-		notificationBody := fmt.Sprintf("%s sizi gruba eklemek istedi.", creatorName)
+		if invitation != nil {
+			// Hesabı olmayan kişiye push yerine email gönderilir
+			go sendInvitationEmail(invitation, requestLocale(r))
+		} else {
+			userID, err := repo.GetUserIDByEmail(req.AddedMember)
+			if err != nil {
+				log.Printf("Kullanıcı bulunamadı: %v", err)
+				return
+			}
+			if userID == "" {
+				log.Println("Kullanıcı bulunamadı")
+				return
+			}
 
-		data := map[string]string{
-			"type": "new_request",
-		}
+			params := map[string]string{
+				"name":  group.Creator.FullName,
+				"group": group.Name,
+			}
 
-		err = SendNotification(r.Context(), repo, userIDStr, notificationTitle, notificationBody, data)
-		if err != nil {
-			log.Printf("Bildirim gönderilemedi: %v", err)
-		}
+			data := map[string]string{
+				"type": "new_request",
+			}
 
-		resp := map[string]interface{}{
-			"id": groupID,
-			"group_token": func() interface{} {
-				if groupToken.Valid {
-					return groupToken.String
-				}
-				return nil
-			}(),
-			"name":       groupName,
-			"created_at": createdTS,
-			"is_admin":   creatorID == userUID.(string),
-			"creator": map[string]interface{}{
-				"id":       creatorID,
-				"fullname": creatorName,
-				"email":    creatorEmail,
-			},
+			err = SendNotification(r.Context(), repo, userID, "notification.add_request.title", "notification.add_request.body", params, data)
+			if err != nil {
+				log.Printf("Bildirim gönderilemedi: %v", err)
+			}
 		}
 
-		if membersJSON.Valid {
-			var members []map[string]interface{}
-			_ = json.Unmarshal([]byte(membersJSON.String), &members)
-			resp["members"] = members
-		}
-		if requestsJSON.Valid {
-			var requests []map[string]interface{}
-			_ = json.Unmarshal([]byte(requestsJSON.String), &requests)
-			resp["pending_requests"] = requests
-		}
-		if expensesJSON.Valid {
-			var expenses []map[string]interface{}
-			_ = json.Unmarshal([]byte(expensesJSON.String), &expenses)
-			resp["expenses"] = expenses
-		}
-		if debtsJSON.Valid {
-			var debts []map[string]interface{}
-			_ = json.Unmarshal([]byte(debtsJSON.String), &debts)
-			resp["debts"] = debts
-		}
-		if creditsJSON.Valid {
-			var credits []map[string]interface{}
-			_ = json.Unmarshal([]byte(creditsJSON.String), &credits)
-			resp["credits"] = credits
-		}
+		resp := SendAddRequestResponse{Group: group, EmailInvitation: invitation}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(resp)
 	}
 }
 
-func handleGetAddRequests(repo *KasaRepository) http.HandlerFunc {
+func handleGetAddRequests(repo AddRequestStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Yalnızca GET metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userUID := r.Context().Value("userUID")
 		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
-		rows, err := repo.getMyAddRequests(userUID.(string))
+		requests, err := repo.getMyAddRequests(userUID.(string))
 		if err != nil {
-			http.Error(w, "Grup ekleme istekleri alınamadı", http.StatusInternalServerError)
+			httpError(w, r, "error.request.fetch_failed", http.StatusInternalServerError, nil)
 			return
 		}
-		defer rows.Close()
-
-		requests := make([]map[string]interface{}, 0)
-		for rows.Next() {
-			var requestID int64
-			var groupID int64
-			var groupName string
-			var requestedAt int64
-			var requestStatus string
-
-			if err := rows.Scan(&requestID, &groupID, &groupName, &requestedAt, &requestStatus); err != nil {
-				http.Error(w, "Grup ekleme istekleri okunamadı", http.StatusInternalServerError)
-				return
-			}
-
-			requests = append(requests, map[string]interface{}{
-				"request_id":     requestID,
-				"group_id":       groupID,
-				"group_name":     groupName,
-				"requested_at":   requestedAt,
-				"request_status": requestStatus,
-			})
-		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(requests)
@@ -577,142 +358,65 @@ type AcceptAddRequest struct {
 	RequestID int64 `json:"request_id"`
 }
 
-func handleAcceptAddRequest(repo *KasaRepository) http.HandlerFunc {
+func handleAcceptAddRequest(repo Repository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Yalnızca POST metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userUID := r.Context().Value("userUID")
 		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
 		var req AcceptAddRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON", http.StatusBadRequest)
+		// /api/v1 yolunda ID yoldan gelir, eski yol gövdeden okur
+		if id, ok := pathInt64(r, "requestId"); ok {
+			req.RequestID = id
+		} else if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
 		log.Printf("📥 request_id geldi: %d\n", req.RequestID)
-		err := repo.acceptAddRequest(req.RequestID, userUID.(string))
+		decision, err := repo.acceptAddRequest(req.RequestID, userUID.(string))
 		if err != nil {
-			http.Error(w, "Grup ekleme isteği kabul edilemedi", http.StatusInternalServerError)
+			writeError(w, r, err, "error.request.accept_failed")
 			return
 		}
+		notifyJoinDecision(repo, decision, "notification.join_approved.title", "notification.join_approved.body")
 
-		reqRows, err := repo.getMyAddRequests(userUID.(string))
+		requests, err := repo.getMyAddRequests(userUID.(string))
 		if err != nil {
-			http.Error(w, "Grup ekleme istekleri alınamadı", http.StatusInternalServerError)
+			httpError(w, r, "error.request.fetch_failed", http.StatusInternalServerError, nil)
 			return
 		}
-		defer reqRows.Close()
-
-		var requests []map[string]interface{}
-		for reqRows.Next() {
-			var requestID int64
-			var groupID int64
-			var groupName string
-			var requestedAt int64
-			var requestStatus string
-
-			if err := reqRows.Scan(&requestID, &groupID, &groupName, &requestedAt, &requestStatus); err != nil {
-				http.Error(w, "Grup ekleme istekleri okunamadı", http.StatusInternalServerError)
-				return
-			}
-
-			requests = append(requests, map[string]interface{}{
-				"request_id":     requestID,
-				"group_id":       groupID,
-				"group_name":     groupName,
-				"requested_at":   requestedAt,
-				"request_status": requestStatus,
-			})
-		}
 
-		rows, err := repo.getMyGroups(userUID.(string))
+		myGroups, err := repo.getMyGroups(userUID.(string))
 		if err != nil {
 			log.Println("Grup bilgileri alınamadı:", err)
-			http.Error(w, "Grup bilgileri alınamadı", http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
-
-		var groups []map[string]interface{}
-		for rows.Next() {
-			var groupID int64
-			var groupToken sql.NullString
-			var groupName, creatorID, creatorName, creatorEmail string
-			var createdAt int64
-			var membersJSON, requestsJSON, expensesJSON, debtsJSON, creditsJSON []byte
-
-			if err := rows.Scan(
-				&groupID,
-				&groupToken,
-				&groupName,
-				&createdAt,
-				&creatorID,
-				&creatorName,
-				&creatorEmail,
-				&membersJSON,
-				&requestsJSON,
-				&expensesJSON,
-				&debtsJSON,
-				&creditsJSON,
-			); err != nil {
-				log.Println("Satır okunamadı:", err)
-				http.Error(w, "Grup bilgileri alınamadı", http.StatusInternalServerError)
-				return
-			}
-			// Bildirim gönder
-			notificationTitle := "Grup isteği kabul edildi"
-			notificationBody := fmt.Sprintf("%s kullanıcı isteğinizi kabul etti.", userUID.(string))
+			httpError(w, r, "error.group.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
 
-			// Bildirimi asenkron gönder (istek gecikmesin diye)
-			go func() {
-				if err := SendNotification(r.Context(), repo, creatorID, notificationTitle, notificationBody, nil); err != nil {
-					log.Printf("Bildirim gönderilemedi: %v", err)
-				}
-			}()
+		// Bildirimde UID yerine kullanıcının adı görünsün
+		params := map[string]string{"name": userUID.(string)}
+		if accepter, err := repo.GetUserByID(userUID.(string)); err == nil {
+			params["name"] = accepter.FullName
+		}
 
-			var members, requests, expenses, debts, credits []map[string]interface{}
-			_ = json.Unmarshal(membersJSON, &members)
-			_ = json.Unmarshal(requestsJSON, &requests)
-			_ = json.Unmarshal(expensesJSON, &expenses)
-			_ = json.Unmarshal(debtsJSON, &debts)
-			_ = json.Unmarshal(creditsJSON, &credits)
-
-			groups = append(groups, map[string]interface{}{
-				"id": groupID,
-				"group_token": func() interface{} {
-					if groupToken.Valid {
-						return groupToken.String
+		for _, g := range myGroups {
+			// Bildirimi asenkron gönder (istek gecikmesin diye); katılım isteğini onaylayan yöneticiye gönderilmez
+			if g.Creator.ID != userUID.(string) {
+				go func() {
+					if err := SendNotification(r.Context(), repo, g.Creator.ID, "notification.request_accepted.title", "notification.request_accepted.body", params, nil); err != nil {
+						log.Printf("Bildirim gönderilemedi: %v", err)
 					}
-					return nil
-				}(),
-				"name":       groupName,
-				"created_at": createdAt,
-				"is_admin":   creatorID == userUID.(string),
-				"creator": map[string]interface{}{
-					"id":       creatorID,
-					"fullname": creatorName,
-					"email":    creatorEmail,
-				},
-				"members":          members,
-				"pending_requests": requests,
-				"expenses":         expenses,
-				"debts":            debts,
-				"credits":          credits,
-			})
+				}()
+			}
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message":  "Grup ekleme isteği kabul edildi",
+			"message":  T(requestLocale(r), "message.request_accepted", nil),
 			"requests": requests,
-			"groups":   groups,
+			"groups":   myGroups,
 		})
 	}
 }
@@ -721,60 +425,35 @@ type RejectAddRequest struct {
 	RequestID int64 `json:"request_id"`
 }
 
-func handleRejectAddRequest(repo *KasaRepository) http.HandlerFunc {
+func handleRejectAddRequest(repo Repository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Yalnızca POST metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userUID := r.Context().Value("userUID")
 		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
 		var req RejectAddRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON", http.StatusBadRequest)
+		// /api/v1 yolunda ID yoldan gelir, eski yol gövdeden okur
+		if id, ok := pathInt64(r, "requestId"); ok {
+			req.RequestID = id
+		} else if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
 
-		err := repo.rejectAddRequest(req.RequestID, userUID.(string))
+		decision, err := repo.rejectAddRequest(req.RequestID, userUID.(string))
 		if err != nil {
-			http.Error(w, "Grup ekleme isteği red edilemedi", http.StatusInternalServerError)
+			writeError(w, r, err, "error.request.reject_failed")
 			return
 		}
+		notifyJoinDecision(repo, decision, "notification.join_rejected.title", "notification.join_rejected.body")
 
-		rows, err := repo.getMyAddRequests(userUID.(string))
+		requests, err := repo.getMyAddRequests(userUID.(string))
 		if err != nil {
-			http.Error(w, "Grup ekleme istekleri alınamadı", http.StatusInternalServerError)
+			httpError(w, r, "error.request.fetch_failed", http.StatusInternalServerError, nil)
 			return
 		}
-		defer rows.Close()
-
-		var requests []map[string]interface{}
-		for rows.Next() {
-			var requestID int64
-			var groupID int64
-			var groupName string
-			var requestedAt int64
-			var requestStatus string
-
-			if err := rows.Scan(&requestID, &groupID, &groupName, &requestedAt, &requestStatus); err != nil {
-				http.Error(w, "Grup ekleme istekleri okunamadı", http.StatusInternalServerError)
-				return
-			}
-
-			requests = append(requests, map[string]interface{}{
-				"request_id":     requestID,
-				"group_id":       groupID,
-				"group_name":     groupName,
-				"requested_at":   requestedAt,
-				"request_status": requestStatus,
-			})
-		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(requests)
@@ -782,57 +461,58 @@ func handleRejectAddRequest(repo *KasaRepository) http.HandlerFunc {
 }
 
 type ExpenseUser struct {
-	UserID string   `json:"user_id"`
-	Amount *float64 `json:"amount"`
+	UserID string   `json:"user_id" validate:"required,max=100"`
+	Amount *float64 `json:"amount" validate:"required,min=0"`
 }
 
+// CreateExpenseRequest'in alan kuralları gövdeye aittir; katılımcıların grup üyesi olması ve
+// ödeyenin katılımcılar arasında bulunması depo tarafından kontrol edilir.
 type CreateExpenseRequest struct {
 	GroupID      int           `json:"group_id"`
-	TotalAmount  float64       `json:"total_amount"`
-	Note         string        `json:"note"`
-	PaymentTitle string        `json:"payment_title"`
-	Users        []ExpenseUser `json:"users"`
-	BillImageURL string        `json:"bill_image_url"` // optional
+	TotalAmount  float64       `json:"total_amount" validate:"gt=0"`
+	Note         string        `json:"note" validate:"max=1000"`
+	PaymentTitle string        `json:"payment_title" validate:"required,max=255"`
+	Users        []ExpenseUser `json:"users" validate:"required,max=100,unique=user_id"`
+	BillImageURL string        `json:"bill_image_url" validate:"max=255"` // optional
+
+	// İçe aktarılan harcamalarda orijinal tarih korunur (unix saniye), API'den gelmez
+	PaymentDate int64 `json:"-"`
 }
 
-func handleCreateGroupExpense(repo *KasaRepository) http.HandlerFunc {
+func handleCreateGroupExpense(repo Repository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Sadece POST desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userUIDVal := r.Context().Value("userUID")
 		userUID, ok := userUIDVal.(string)
 		if !ok || userUID == "" {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
 		var req CreateExpenseRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON: "+err.Error(), http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
-
-		if req.TotalAmount <= 0 {
-			http.Error(w, "Tutar 0'dan büyük olmalı", http.StatusBadRequest)
-			return
-		}
-		if len(req.Users) == 0 {
-			http.Error(w, "En az bir katılımcı olmalı", http.StatusBadRequest)
-			return
+		if groupID, ok := pathInt64(r, "id"); ok {
+			req.GroupID = int(groupID)
 		}
 
 		expense, err := repo.createGroupExpense(r.Context(), userUID, req)
 		if err != nil {
-			http.Error(w, "Harcama oluşturulamadı: "+err.Error(), http.StatusInternalServerError)
+			writeError(w, r, err, "error.expense.create_failed")
 			return
 		}
 
-		notificationTitle := "Yeni Harcama Eklendi"
-		notificationBody := fmt.Sprintf("'%s' başlıklı harcama oluşturuldu. Tutar: %.2f TL", req.PaymentTitle, req.TotalAmount)
+		currency, err := repo.getGroupCurrency(r.Context(), int64(req.GroupID))
+		if err != nil {
+			log.Printf("Grup para birimi alınamadı (groupID=%d): %v", req.GroupID, err)
+			currency = defaultCurrency
+		}
+		params := map[string]string{
+			"title":    req.PaymentTitle,
+			"amount":   fmt.Sprintf("%.2f", req.TotalAmount),
+			"currency": currency,
+		}
 
 		go func() {
 			for _, user := range req.Users {
@@ -843,7 +523,7 @@ func handleCreateGroupExpense(repo *KasaRepository) http.HandlerFunc {
 				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer cancel()
 
-				err := SendNotification(ctx, repo, user.UserID, notificationTitle, notificationBody, nil)
+				err := SendNotification(ctx, repo, user.UserID, "notification.expense_created.title", "notification.expense_created.body", params, nil)
 				if err != nil {
 					log.Printf("Bildirim gönderilemedi (userID=%s): %v", user.UserID, err)
 				}
@@ -856,56 +536,65 @@ func handleCreateGroupExpense(repo *KasaRepository) http.HandlerFunc {
 	}
 }
 
-func LoginWGoogleHandler(repo *KasaRepository) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		type RequestBody struct {
-			UserID   string `json:"userId"`
-			Email    string `json:"email"`
-			IDToken  string `json:"idToken"`
-			FullName string `json:"fullName"` // opsiyonel ama ilk kayıtta lazım olabilir
-			IBAN     string `json:"iban"`     // opsiyonel
-		}
+type GoogleLoginRequest struct {
+	UserID   string `json:"userId" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email,max=100"`
+	IDToken  string `json:"idToken" validate:"required"`
+	FullName string `json:"fullName" validate:"max=50"` // opsiyonel ama ilk kayıtta lazım olabilir
+	IBAN     string `json:"iban" validate:"max=34"`     // opsiyonel
+	Locale   string `json:"locale"`                     // opsiyonel, boşsa Accept-Language kullanılır
+}
 
-		var req RequestBody
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz istek", http.StatusBadRequest)
+func LoginWGoogleHandler(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req GoogleLoginRequest
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
 		// === 1. Token doğrulama ve UID/email eşleşmesi ===
 		err := ValidateFirebaseTokenWithUser(req.IDToken, req.UserID, req.Email)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Firebase doğrulama hatası: %v", err), http.StatusUnauthorized)
+			log.Printf("[%s] Firebase doğrulama hatası: %v", requestIDFrom(r), err)
+			httpError(w, r, "error.auth.firebase", http.StatusUnauthorized, nil)
 			return
 		}
 
 		// === 2. Kullanıcı veritabanında var mı kontrol et ===
 		user, err := repo.GetUserByID(req.UserID)
 		if err != nil && err != sql.ErrNoRows {
-			http.Error(w, "Veritabanı hatası", http.StatusInternalServerError)
+			httpError(w, r, "error.database", http.StatusInternalServerError, nil)
 			return
 		}
-		if user.Deleted {
-			http.Error(w, "Kullanıcı silinmiş", http.StatusUnauthorized)
+		if user != nil && user.Deleted && user.DeletionStatus != deletionStatusPending {
+			httpError(w, r, "error.user.deleted", http.StatusUnauthorized, nil)
 			return
 		}
 
 		// === 3. Kullanıcı veritabanında yoksa ekle ===
 		if user == nil {
+			locale := req.Locale
+			if !isSupportedLocale(locale) {
+				locale = localeFromAcceptLanguage(r.Header.Get("Accept-Language"))
+			}
 			err := repo.InsertUser(User{
 				ID:       req.UserID,
 				Email:    req.Email,
 				FullName: req.FullName,
 				IBAN:     req.IBAN,
+				Locale:   locale,
 			})
 			if err != nil {
-				http.Error(w, "Kullanıcı kaydı başarısız", http.StatusInternalServerError)
+				httpError(w, r, "error.user.register_failed", http.StatusInternalServerError, nil)
 				return
 			}
+
+			// Bu email'e kayıttan önce gönderilmiş grup davetleri isteğe dönüşür
+			convertInvitationsForNewUser(r.Context(), repo, req.UserID, req.Email)
 		} else {
 			// === 4. Email uyuşmazsa hata ver ===
 			if user.Email != req.Email {
-				http.Error(w, "Email uyuşmazlığı", http.StatusUnauthorized)
+				httpError(w, r, "error.auth.email_mismatch", http.StatusUnauthorized, nil)
 				return
 			}
 		}
@@ -916,7 +605,7 @@ func LoginWGoogleHandler(repo *KasaRepository) http.HandlerFunc {
 			"email": req.Email,
 		})
 		if err != nil {
-			http.Error(w, "JWT oluşturulamadı", http.StatusInternalServerError)
+			httpError(w, r, "error.auth.jwt_create_failed", http.StatusInternalServerError, nil)
 			return
 		}
 
@@ -927,17 +616,27 @@ func LoginWGoogleHandler(repo *KasaRepository) http.HandlerFunc {
 	}
 }
 
-func getMeHandler(repo *KasaRepository) http.HandlerFunc {
+func getMeHandler(repo UserStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Yalnızca GET metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
-
 		// JWT'den gelen kullanıcı UID'si
 		userUID := r.Context().Value("userUID")
 		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		// Veri değişmediyse kullanıcı okunmadan 304 döner
+		version, err := repo.GetDataVersion(r.Context(), userUID.(string))
+		if err == sql.ErrNoRows {
+			httpError(w, r, "error.user.not_found", http.StatusNotFound, nil)
+			return
+		}
+		if err != nil {
+			log.Println("Veri sürümü alınamadı:", err)
+			httpError(w, r, "error.database", http.StatusInternalServerError, nil)
+			return
+		}
+		if checkNotModified(w, r, dataVersionETag(etagResourceMe, version)) {
 			return
 		}
 
@@ -945,10 +644,10 @@ func getMeHandler(repo *KasaRepository) http.HandlerFunc {
 		user, err := repo.GetUserByID(userUID.(string))
 		if err != nil {
 			if err == sql.ErrNoRows {
-				http.Error(w, "Kullanıcı bulunamadı", http.StatusNotFound)
+				httpError(w, r, "error.user.not_found", http.StatusNotFound, nil)
 				return
 			}
-			http.Error(w, "Veritabanı hatası", http.StatusInternalServerError)
+			httpError(w, r, "error.database", http.StatusInternalServerError, nil)
 			return
 		}
 
@@ -958,51 +657,55 @@ func getMeHandler(repo *KasaRepository) http.HandlerFunc {
 	}
 }
 
-func updateUserHandler(repo *KasaRepository) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPatch {
-			http.Error(w, "Yalnızca PATCH metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
+type UpdateUserRequest struct {
+	FullName *string `json:"fullName,omitempty" validate:"min=1,max=50"`
+	IBAN     *string `json:"iban,omitempty" validate:"max=34"`
+	Locale   *string `json:"locale,omitempty"`
+}
 
+func updateUserHandler(repo UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		// Context'ten userUID'yi al ve string olarak atama yap
 		userUIDVal := r.Context().Value("userUID")
 		userUID, ok := userUIDVal.(string)
 		if !ok || userUID == "" {
-			http.Error(w, "Yetkisiz erişim: Kullanıcı ID alınamadı", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized_no_uid", http.StatusUnauthorized, nil)
 			return
 		}
 
-		var updateData struct {
-			FullName *string `json:"fullName,omitempty"`
-			IBAN     *string `json:"iban,omitempty"`
-		}
+		var updateData UpdateUserRequest
 
-		if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
-			http.Error(w, "Geçersiz JSON formatı", http.StatusBadRequest)
+		if !decodeJSON(w, r, &updateData) {
 			return
 		}
 
-		if updateData.FullName == nil && updateData.IBAN == nil {
-			http.Error(w, "Güncellenecek alan belirtilmedi", http.StatusBadRequest)
+		if updateData.FullName == nil && updateData.IBAN == nil && updateData.Locale == nil {
+			httpError(w, r, "error.user.no_update_fields", http.StatusBadRequest, nil)
 			return
 		}
 
 		user, err := repo.GetUserByID(userUID)
 		if err != nil {
-			http.Error(w, "Kullanıcı bulunamadı", http.StatusNotFound)
+			httpError(w, r, "error.user.not_found", http.StatusNotFound, nil)
 			return
 		}
 
 		if updateData.FullName != nil {
-			user.FullName = *updateData.FullName
+			user.FullName = strings.TrimSpace(*updateData.FullName)
 		}
 		if updateData.IBAN != nil {
 			user.IBAN = *updateData.IBAN
 		}
+		if updateData.Locale != nil {
+			if !isSupportedLocale(*updateData.Locale) {
+				httpError(w, r, "error.user.unsupported_locale", http.StatusBadRequest, map[string]string{"locale": *updateData.Locale})
+				return
+			}
+			user.Locale = *updateData.Locale
+		}
 
 		if err := repo.UpdateUser(user); err != nil {
-			http.Error(w, "Kullanıcı güncelleme işlemi başarısız oldu", http.StatusInternalServerError)
+			httpError(w, r, "error.user.update_failed", http.StatusInternalServerError, nil)
 			return
 		}
 
@@ -1011,46 +714,47 @@ func updateUserHandler(repo *KasaRepository) http.HandlerFunc {
 	}
 }
 
-func handlePayGroupExpense(repo *KasaRepository) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Yalnızca POST metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
+type PayGroupExpenseRequest struct {
+	SendedUserID string `json:"sended_user_id" validate:"required,max=100"`
+	GroupID      int64  `json:"group_id"`
+}
 
+func handlePayGroupExpense(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		userUID := r.Context().Value("userUID")
 		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
-		var req struct {
-			SendedUserID string `json:"sended_user_id"`
-			GroupID      int64  `json:"group_id"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON formatı", http.StatusBadRequest)
+		var req PayGroupExpenseRequest
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
+		if groupID, ok := pathInt64(r, "id"); ok {
+			req.GroupID = groupID
+		}
 
 		err := repo.PayGroupExpense(userUID.(string), req.SendedUserID, req.GroupID)
 		if err != nil {
 			log.Println("Harcama ödeme hatası:", err)
-			http.Error(w, "Harcama ödemesi başarısız", http.StatusInternalServerError)
+			httpError(w, r, "error.expense.pay_failed", http.StatusInternalServerError, nil)
 			return
 		}
 
-		title := "Harcama Ödendi"
-		body := fmt.Sprintf("%s tarafından bir harcama ödendi.", userUID.(string))
+		params := map[string]string{"name": userUID.(string)}
+		if payer, err := repo.GetUserByID(userUID.(string)); err == nil {
+			params["name"] = payer.FullName
+		}
 		sentUser, err := repo.GetUserByID(req.SendedUserID)
 		if err != nil {
 			log.Printf("Kullanıcı bulunamadı: %v", err)
-			http.Error(w, "Kullanıcı bulunamadı", http.StatusNotFound)
+			httpError(w, r, "error.user.not_found", http.StatusNotFound, nil)
 			return
 		}
 
-		err = SendNotification(r.Context(), repo, sentUser.ID, title, body, nil)
+		err = SendNotification(r.Context(), repo, sentUser.ID, "notification.expense_paid.title", "notification.expense_paid.body", params, nil)
 		if err != nil {
 			log.Printf("Bildirim gönderilemedi: %v", err)
 			// Bildirim başarısızlığı uygulamanın çalışmasını engellememeli
@@ -1059,29 +763,24 @@ func handlePayGroupExpense(repo *KasaRepository) http.HandlerFunc {
 		w.WriteHeader(http.StatusOK)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{
-			"message": "Harcama başarıyla ödendi",
+			"message": T(requestLocale(r), "message.expense_paid", nil),
 		})
 		log.Println("Harcama başarıyla ödendi:", req.SendedUserID)
 	}
 }
 
-func uploadPhotoHandler(repo *KasaRepository) http.HandlerFunc {
+func uploadPhotoHandler(repo Repository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		// Parse multipart form (max 10 MB)
 		err := r.ParseMultipartForm(10 << 20)
 		if err != nil {
-			http.Error(w, "Could not parse multipart form", http.StatusBadRequest)
+			httpError(w, r, "error.upload.parse_failed", http.StatusBadRequest, nil)
 			return
 		}
 
 		file, handler, err := r.FormFile("photo")
 		if err != nil {
-			http.Error(w, "Photo file is required", http.StatusBadRequest)
+			httpError(w, r, "error.upload.photo_missing", http.StatusBadRequest, nil)
 			return
 		}
 		defer file.Close()
@@ -1094,7 +793,7 @@ func uploadPhotoHandler(repo *KasaRepository) http.HandlerFunc {
 
 		dst, err := os.Create(filePath)
 		if err != nil {
-			http.Error(w, "Unable to save the file", http.StatusInternalServerError)
+			httpError(w, r, "error.upload.save_failed", http.StatusInternalServerError, nil)
 			return
 		}
 		defer dst.Close()
@@ -1102,7 +801,7 @@ func uploadPhotoHandler(repo *KasaRepository) http.HandlerFunc {
 		// Dosyayı kaydet
 		_, err = io.Copy(dst, file)
 		if err != nil {
-			http.Error(w, "Failed to save file", http.StatusInternalServerError)
+			httpError(w, r, "error.upload.save_failed", http.StatusInternalServerError, nil)
 			return
 		}
 
@@ -1113,147 +812,102 @@ func uploadPhotoHandler(repo *KasaRepository) http.HandlerFunc {
 	}
 }
 
-func handleSaveFCMToken(repo *KasaRepository) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Yalnızca POST metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
+type SaveFCMTokenRequest struct {
+	Token string `json:"token" validate:"required,max=4096"`
+}
 
+func handleSaveFCMToken(repo DeviceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		userUID := r.Context().Value("userUID")
 		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
-		var req struct {
-			Token string `json:"token"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON formatı", http.StatusBadRequest)
+		var req SaveFCMTokenRequest
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
 
-		if req.Token == "" {
-			http.Error(w, "FCM token zorunludur", http.StatusBadRequest)
-			return
-		}
-
 		err := repo.SaveFCMToken(userUID.(string), req.Token)
 		if err != nil {
 			log.Println("FCM token kaydetme hatası:", err)
-			http.Error(w, "FCM token kaydedilemedi", http.StatusInternalServerError)
+			httpError(w, r, "error.fcm.save_failed", http.StatusInternalServerError, nil)
 			return
 		}
 
 		w.WriteHeader(http.StatusOK)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{
-			"message": "FCM token başarıyla kaydedildi",
+			"message": T(requestLocale(r), "message.fcm_saved", nil),
 		})
 	}
 }
 
-func addGroupWithTokenHandler(repo *KasaRepository) http.HandlerFunc {
+func addGroupWithTokenHandler(repo Repository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Yalnızca POST metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userUID := r.Context().Value("userUID")
 		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
 		var req struct {
 			GroupToken string `json:"group_token"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON formatı", http.StatusBadRequest)
+		// /api/v1 yolunda token yoldan gelir, eski yol gövdeden okur
+		if token := r.PathValue("token"); token != "" {
+			req.GroupToken = token
+		} else if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
 
 		if req.GroupToken == "" {
-			http.Error(w, "group_token zorunludur", http.StatusBadRequest)
+			httpError(w, r, "error.group.token_required", http.StatusBadRequest, nil)
 			return
 		}
 
-		var newGroupID int64
-		newGroupID, err := repo.addUserToGroupWithToken(userUID.(string), req.GroupToken)
+		joined, err := repo.addUserToGroupWithToken(userUID.(string), req.GroupToken)
 		if err != nil {
-			log.Println("Grup ekleme hatası:", err)
-			http.Error(w, "Gruba eklenemedi", http.StatusInternalServerError)
+			writeError(w, r, err, "error.group.join_failed")
 			return
 		}
 
-		rows, err := repo.getMyGroups(userUID.(string))
-		if err != nil {
-			log.Println("Grup bilgileri alınamadı:", err)
-			http.Error(w, "Grup bilgileri alınamadı", http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
-
-		var groups []map[string]interface{}
-		for rows.Next() {
-			var groupID int64
-			var groupToken sql.NullString
-			var groupName, creatorID, creatorName, creatorEmail string
-			var createdAt int64
-			var membersJSON, requestsJSON, expensesJSON, debtsJSON, creditsJSON []byte
-
-			if err := rows.Scan(
-				&groupID,
-				&groupToken,
-				&groupName,
-				&createdAt,
-				&creatorID,
-				&creatorName,
-				&creatorEmail,
-				&membersJSON,
-				&requestsJSON,
-				&expensesJSON,
-				&debtsJSON,
-				&creditsJSON,
-			); err != nil {
-				log.Println("Satır okunamadı:", err)
-				http.Error(w, "Grup bilgileri alınamadı", http.StatusInternalServerError)
-				return
+		// Onay gerektiren bağlantıda kullanıcı henüz gruba eklenmedi, yönetici bilgilendirilir
+		if joined.Pending {
+			params := map[string]string{"name": userUID.(string), "group": joined.GroupName}
+			if requester, err := repo.GetUserByID(userUID.(string)); err == nil && requester != nil {
+				params["name"] = requester.FullName
 			}
+			data := map[string]string{
+				"type":       "join_request",
+				"group_id":   strconv.FormatInt(joined.GroupID, 10),
+				"request_id": strconv.FormatInt(joined.RequestID, 10),
+			}
+			go func() {
+				if err := SendNotification(context.Background(), repo, joined.AdminID, "notification.join_request.title", "notification.join_request.body", params, data); err != nil {
+					log.Printf("Bildirim gönderilemedi: %v", err)
+				}
+			}()
 
-			var members, requests, expenses, debts, credits []map[string]interface{}
-			_ = json.Unmarshal(membersJSON, &members)
-			_ = json.Unmarshal(requestsJSON, &requests)
-			_ = json.Unmarshal(expensesJSON, &expenses)
-			_ = json.Unmarshal(debtsJSON, &debts)
-			_ = json.Unmarshal(creditsJSON, &credits)
-
-			groups = append(groups, map[string]interface{}{
-				"id": groupID,
-				"group_token": func() interface{} {
-					if groupToken.Valid {
-						return groupToken.String
-					}
-					return nil
-				}(),
-				"name":       groupName,
-				"created_at": createdAt,
-				"is_admin":   creatorID == userUID,
-				"creator": map[string]interface{}{
-					"id":       creatorID,
-					"fullname": creatorName,
-					"email":    creatorEmail,
-				},
-				"members":          members,
-				"pending_requests": requests,
-				"expenses":         expenses,
-				"debts":            debts,
-				"credits":          credits,
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message":    T(requestLocale(r), "message.group_join_requested", nil),
+				"group_id":   joined.GroupID,
+				"request_id": joined.RequestID,
 			})
+			return
+		}
+		newGroupID := joined.GroupID
+
+		myGroups, err := repo.getMyGroups(userUID.(string))
+		if err != nil {
+			log.Println("Grup bilgileri alınamadı:", err)
+			httpError(w, r, "error.group.fetch_failed", http.StatusInternalServerError, nil)
+			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -1261,9 +915,9 @@ func addGroupWithTokenHandler(repo *KasaRepository) http.HandlerFunc {
 
 		if err := json.NewEncoder(w).Encode(
 			map[string]interface{}{
-				"message":      "Grup başarıyla eklendi",
+				"message":      T(requestLocale(r), "message.group_joined", nil),
 				"new_group_id": newGroupID,
-				"groups":       groups,
+				"groups":       myGroups,
 			},
 		); err != nil {
 			log.Println("Yanıt gönderilemedi:", err)
@@ -1271,86 +925,1499 @@ func addGroupWithTokenHandler(repo *KasaRepository) http.HandlerFunc {
 	}
 }
 
-func handleDeleteExpense(repo *KasaRepository) http.HandlerFunc {
+func handleDeleteExpense(repo ExpenseStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodDelete {
-			http.Error(w, "Yalnızca DELETE metodu desteklenir", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userUID := r.Context().Value("userUID")
 		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
 		var req struct {
 			ExpenseID int64 `json:"expense_id"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Geçersiz JSON formatı", http.StatusBadRequest)
+		// /api/v1 yolunda grup ve harcama ID'si yoldan gelir, harcama o grubun olmalıdır;
+		// eski yol harcama ID'sini gövdeden okur ve grubu kontrol etmez
+		var groupID int64
+		if raw := r.PathValue("expenseId"); raw != "" {
+			groupID, _ = strconv.ParseInt(r.PathValue("id"), 10, 64)
+			if groupID <= 0 {
+				httpError(w, r, "error.group.invalid_id", http.StatusBadRequest, nil)
+				return
+			}
+			req.ExpenseID, _ = strconv.ParseInt(raw, 10, 64)
+		} else if !decodeJSON(w, r, &req) {
 			return
 		}
 		defer r.Body.Close()
 
 		if req.ExpenseID <= 0 {
-			http.Error(w, "Geçersiz harcama ID'si", http.StatusBadRequest)
+			httpError(w, r, "error.expense.invalid_id", http.StatusBadRequest, nil)
 			return
 		}
 
-		// Create a transaction for the delete operation
-		tx, err := repo.DB.BeginTx(r.Context(), nil)
+		expenseRes, err := repo.deleteGroupExpense(r.Context(), userUID.(string), groupID, req.ExpenseID)
 		if err != nil {
-			log.Println("Veritabanı transaction başlatılamadı:", err)
-			http.Error(w, "Sunucu hatası", http.StatusInternalServerError)
+			writeError(w, r, err, "error.expense.delete_failed")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(expenseRes)
+	}
+}
+
+func handleDeleteAccount(repo AccountStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
-		expenseRes, err := repo.deleteGroupExpense(r.Context(), tx, userUID.(string), req.ExpenseID)
+		status, err := repo.requestAccountDeletion(r.Context(), userUID.(string), accountDeletionGracePeriod())
 		if err != nil {
-			tx.Rollback()
-			log.Println("Harcama silme hatası:", err)
-			http.Error(w, "Harcama silinemedi", http.StatusInternalServerError)
+			log.Println("Hesap silme hatası:", err)
+			httpError(w, r, "error.account.delete_failed", http.StatusInternalServerError, nil)
+			return
+		}
+
+		// Silme işlemi arka planda, bekleme süresi dolunca yapılır
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  T(requestLocale(r), "message.account_deletion_scheduled", nil),
+			"deletion": status,
+		})
+		log.Println("Hesap silme talebi alındı:", userUID)
+	}
+}
+
+func handleRestoreAccount(repo AccountStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
 			return
 		}
 
-		if err := tx.Commit(); err != nil {
-			log.Println("Transaction commit hatası:", err)
-			http.Error(w, "Sunucu hatası", http.StatusInternalServerError)
+		if err := repo.restoreAccount(r.Context(), userUID); err != nil {
+			writeError(w, r, err, "error.account.restore_failed")
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		_ = json.NewEncoder(w).Encode(expenseRes)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": T(requestLocale(r), "message.account_restored", nil),
+		})
 	}
 }
 
-func handleDeleteAccount(repo *KasaRepository) http.HandlerFunc {
+func handleGetAccountDeletionStatus(repo AccountStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodDelete {
-			http.Error(w, "Yalnızca DELETE metodu desteklenir", http.StatusMethodNotAllowed)
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		status, err := repo.GetAccountDeletionStatus(r.Context(), userUID)
+		if err != nil {
+			log.Println("Hesap silme durumu alınamadı:", err)
+			httpError(w, r, "error.account.status_failed", http.StatusInternalServerError, nil)
 			return
 		}
 
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// parsePagination limit/offset sorgu parametrelerini okur
+func parsePagination(r *http.Request, defaultLimit, maxLimit int) (int, int) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func handleGetNotifications(repo NotificationStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		userUID := r.Context().Value("userUID")
 		if userUID == nil {
-			http.Error(w, "Yetkisiz erişim", http.StatusUnauthorized)
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		limit, offset := parsePagination(r, 20, 100)
+
+		// Bir fazlasını çekerek sonraki sayfa olup olmadığını anla
+		notifications, err := repo.GetNotifications(r.Context(), userUID.(string), limit+1, offset)
+		if err != nil {
+			log.Println("Bildirimler alınamadı:", err)
+			httpError(w, r, "error.notification.fetch_failed", http.StatusInternalServerError, nil)
 			return
 		}
+		hasMore := len(notifications) > limit
+		if hasMore {
+			notifications = notifications[:limit]
+		}
 
-		_, err := repo.deleteAccount(r.Context(), userUID.(string))
+		unread, err := repo.CountUnreadNotifications(r.Context(), userUID.(string))
 		if err != nil {
-			log.Println("Hesap silme hatası:", err)
-			http.Error(w, "Hesap silinemedi", http.StatusInternalServerError)
+			log.Println("Okunmamış bildirim sayısı alınamadı:", err)
+			httpError(w, r, "error.notification.fetch_failed", http.StatusInternalServerError, nil)
 			return
 		}
-		// Hesap başarıyla silindiğinde yanıt dönebilir veya boş bırakılabilir
-		w.WriteHeader(http.StatusNoContent) // 204 No Content
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"notifications": notifications,
+			"unread_count":  unread,
+			"limit":         limit,
+			"offset":        offset,
+			"has_more":      hasMore,
+		})
+	}
+}
+
+func handleGetUnreadNotificationCount(repo NotificationStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		unread, err := repo.CountUnreadNotifications(r.Context(), userUID.(string))
+		if err != nil {
+			log.Println("Okunmamış bildirim sayısı alınamadı:", err)
+			httpError(w, r, "error.notification.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{
+			"unread_count": unread,
+		})
+	}
+}
+
+type MarkNotificationsReadRequest struct {
+	IDs []int64 `json:"ids" validate:"required,max=500"`
+}
+
+func handleMarkNotificationsRead(repo NotificationStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		var req MarkNotificationsReadRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		defer r.Body.Close()
+
+		updated, err := repo.MarkNotificationsRead(r.Context(), userUID.(string), req.IDs)
+		if err != nil {
+			log.Println("Bildirimler okundu olarak işaretlenemedi:", err)
+			httpError(w, r, "error.notification.update_failed", http.StatusInternalServerError, nil)
+			return
+		}
+
+		unread, err := repo.CountUnreadNotifications(r.Context(), userUID.(string))
+		if err != nil {
+			log.Println("Okunmamış bildirim sayısı alınamadı:", err)
+			httpError(w, r, "error.notification.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"updated":      updated,
+			"unread_count": unread,
+		})
+	}
+}
+
+func handleMarkAllNotificationsRead(repo NotificationStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		updated, err := repo.MarkAllNotificationsRead(r.Context(), userUID.(string))
+		if err != nil {
+			log.Println("Bildirimler okundu olarak işaretlenemedi:", err)
+			httpError(w, r, "error.notification.update_failed", http.StatusInternalServerError, nil)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"updated":      updated,
+			"unread_count": 0,
+		})
+	}
+}
+
+func handleGetReminderSettings(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupMemberFromPath(w, r, repo, userUID.(string))
+		if !ok {
+			return
+		}
+
+		settings, err := repo.GetReminderSettings(r.Context(), groupID)
+		if err != nil {
+			log.Println("Hatırlatma ayarları alınamadı:", err)
+			httpError(w, r, "error.reminder.settings_fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(settings)
+	}
+}
+
+func handleSaveReminderSettings(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		var req ReminderSettings
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		defer r.Body.Close()
+		req.GroupID, _ = strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+		if req.GroupID <= 0 {
+			httpError(w, r, "error.group.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+
+		// Ayarları sadece grup yöneticisi değiştirebilir
+		creatorID, err := repo.getGroupCreatorID(r.Context(), req.GroupID)
+		if err == sql.ErrNoRows {
+			httpError(w, r, "error.group.not_found", http.StatusNotFound, nil)
+			return
+		} else if err != nil {
+			log.Println("Grup yöneticisi alınamadı:", err)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+			return
+		}
+		if creatorID != userUID.(string) {
+			httpError(w, r, "error.group.admin_only", http.StatusForbidden, nil)
+			return
+		}
+
+		if err := repo.SaveReminderSettings(r.Context(), userUID.(string), req); err != nil {
+			log.Println("Hatırlatma ayarları kaydedilemedi:", err)
+			httpError(w, r, "error.reminder.settings_save_failed", http.StatusInternalServerError, nil)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(req)
+	}
+}
+
+type NudgeDebtorRequest struct {
+	DebtorID string `json:"debtor_id" validate:"max=100"`
+}
+
+func handleNudgeDebtor(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		var req NudgeDebtorRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		defer r.Body.Close()
+		groupID, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+		if groupID <= 0 || req.DebtorID == "" {
+			httpError(w, r, "error.reminder.fields_required", http.StatusBadRequest, nil)
+			return
+		}
+
+		debt, err := nudgeDebtor(r.Context(), repo, groupID, userUID.(string), req.DebtorID)
+		if err != nil {
+			writeError(w, r, err, "error.reminder.send_failed")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   T(requestLocale(r), "message.nudge_sent", nil),
+			"group_id":  debt.GroupID,
+			"debtor_id": debt.DebtorID,
+			"amount":    debt.Amount,
+		})
+	}
+}
+
+func handleGetGroupActivity(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || groupID <= 0 {
+			httpError(w, r, "error.group.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+
+		isMember, err := repo.isGroupMember(r.Context(), groupID, userUID.(string))
+		if err != nil {
+			log.Println("Üyelik kontrolü başarısız:", err)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+			return
+		}
+		if !isMember {
+			httpError(w, r, "error.group.not_member", http.StatusForbidden, nil)
+			return
+		}
+
+		limit, offset := parsePagination(r, 50, 200)
+		activity, err := repo.GetGroupActivity(r.Context(), groupID, limit+1, offset)
+		if err != nil {
+			log.Println("Aktivite kaydı alınamadı:", err)
+			httpError(w, r, "error.activity.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		hasMore := len(activity) > limit
+		if hasMore {
+			activity = activity[:limit]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"activity": activity,
+			"limit":    limit,
+			"offset":   offset,
+			"has_more": hasMore,
+		})
+	}
+}
+
+func handleRestoreExpense(repo ExpenseStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		// Grup ve harcama ID'si yoldan gelir, harcama o grubun olmalıdır
+		groupID, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if groupID <= 0 {
+			httpError(w, r, "error.group.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+		expenseID, _ := strconv.ParseInt(r.PathValue("expenseId"), 10, 64)
+		if expenseID <= 0 {
+			httpError(w, r, "error.expense.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+
+		expenseRes, err := repo.restoreGroupExpense(r.Context(), userUID.(string), groupID, expenseID)
+		if err != nil {
+			writeError(w, r, err, "error.expense.restore_failed")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(expenseRes)
+	}
+}
+
+func handleGetDeletedExpenses(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || groupID <= 0 {
+			httpError(w, r, "error.group.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+
+		isMember, err := repo.isGroupMember(r.Context(), groupID, userUID.(string))
+		if err != nil {
+			log.Println("Üyelik kontrolü başarısız:", err)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+			return
+		}
+		if !isMember {
+			httpError(w, r, "error.group.not_member", http.StatusForbidden, nil)
+			return
+		}
+
+		expenses, err := repo.getDeletedExpenses(r.Context(), groupID)
+		if err != nil {
+			log.Println("Silinmiş harcamalar alınamadı:", err)
+			httpError(w, r, "error.expense.fetch_deleted_failed", http.StatusInternalServerError, nil)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(expenses)
+	}
+}
+
+func handleGetDataExports(repo DataExportStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		exports, err := repo.getDataExports(r.Context(), userUID)
+		if err != nil {
+			log.Println("Dışa aktarma talepleri alınamadı:", err)
+			httpError(w, r, "error.export.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		for i := range exports {
+			if exports[i].Status == dataExportReady && exports[i].token != "" {
+				exports[i].DownloadURL = dataExportDownloadURL(exports[i].token)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(exports)
+	}
+}
+
+// Arşiv arka planda hazırlanır, hazır olunca bildirim gönderilir
+func handleRequestDataExport(repo DataExportStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		export, created, err := repo.createDataExport(r.Context(), userUID)
+		if err != nil {
+			log.Println("Dışa aktarma talebi oluşturulamadı:", err)
+			httpError(w, r, "error.export.create_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		if created {
+			log.Printf("Veri dışa aktarma talebi alındı (user=%s, id=%d)", userUID, export.ID)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": T(requestLocale(r), "message.data_export_requested", nil),
+			"export":  export,
+		})
+	}
+}
+
+// Bağlantıdaki token yeterlidir, böylece arşiv tarayıcıdan da indirilebilir
+func handleDownloadDataExport(repo DataExportStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			httpError(w, r, "error.export.link_invalid", http.StatusNotFound, nil)
+			return
+		}
+
+		export, err := repo.getDownloadableDataExport(r.Context(), token)
+		if err == sql.ErrNoRows {
+			httpError(w, r, "error.export.link_invalid", http.StatusNotFound, nil)
+			return
+		}
+		if err != nil {
+			log.Println("Dışa aktarma bağlantısı kontrol edilemedi:", err)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+			return
+		}
+
+		f, err := os.Open(export.filePath)
+		if err != nil {
+			log.Println("Arşiv dosyası açılamadı:", err)
+			httpError(w, r, "error.export.link_invalid", http.StatusNotFound, nil)
+			return
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="kasa-export-%d.zip"`, export.ID))
+		w.Header().Set("Cache-Control", "no-store")
+		http.ServeContent(w, r, "", time.Unix(export.RequestedAt, 0), f)
+	}
+}
+
+func handleExportGroup(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID")
+		if userUID == nil {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || groupID <= 0 {
+			httpError(w, r, "error.group.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+
+		formatName := strings.ToLower(r.URL.Query().Get("format"))
+		if formatName == "" {
+			formatName = "csv"
+		}
+		format, ok := groupExportFormats[formatName]
+		if !ok {
+			httpError(w, r, "error.export.invalid_format", http.StatusBadRequest, nil)
+			return
+		}
+
+		isMember, err := repo.isGroupMember(r.Context(), groupID, userUID.(string))
+		if err != nil {
+			log.Println("Üyelik kontrolü başarısız:", err)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+			return
+		}
+		if !isMember {
+			httpError(w, r, "error.group.not_member", http.StatusForbidden, nil)
+			return
+		}
+
+		report, err := repo.getGroupReport(r.Context(), groupID)
+		if err != nil {
+			log.Println("Grup raporu alınamadı:", err)
+			httpError(w, r, "error.export.group_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		members := summarizeGroupReport(report)
+		settlements := suggestSettlements(members)
+
+		// Yarım kalmış bir dosya göndermemek için önce belleğe yaz
+		var buf bytes.Buffer
+		if err := format.render(&buf, requestLocale(r), report, members, settlements); err != nil {
+			log.Println("Grup raporu oluşturulamadı:", err)
+			httpError(w, r, "error.export.group_failed", http.StatusInternalServerError, nil)
+			return
+		}
+
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="kasa-group-%d.%s"`, groupID, format.extension))
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		buf.WriteTo(w)
+	}
+}
+
+// handleImportExpenses Splitwise ya da genel CSV dosyasındaki harcamaları gruba aktarır.
+// Varsayılan olarak sadece doğrulama raporu döner; dry_run=false ile kayıtlar oluşturulur.
+func handleImportExpenses(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || groupID <= 0 {
+			httpError(w, r, "error.group.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+
+		// Geçmişi topluca yazdığı için sadece grup yöneticisi içe aktarabilir
+		creatorID, err := repo.getGroupCreatorID(r.Context(), groupID)
+		if err == sql.ErrNoRows {
+			httpError(w, r, "error.group.not_found", http.StatusNotFound, nil)
+			return
+		} else if err != nil {
+			log.Println("Grup yöneticisi alınamadı:", err)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+			return
+		}
+		if creatorID != userUID {
+			httpError(w, r, "error.group.admin_only", http.StatusForbidden, nil)
+			return
+		}
+
+		if err := r.ParseMultipartForm(5 << 20); err != nil {
+			httpError(w, r, "error.upload.parse_failed", http.StatusBadRequest, nil)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			httpError(w, r, "error.import.file_required", http.StatusBadRequest, nil)
+			return
+		}
+		defer file.Close()
+
+		source, err := describeImportSource(r.FormValue("source"))
+		if err != nil {
+			httpError(w, r, "error.import.invalid_source", http.StatusBadRequest, nil)
+			return
+		}
+
+		var mapping ImportMapping
+		if source == importSourceGeneric {
+			if err := json.Unmarshal([]byte(r.FormValue("mapping")), &mapping); err != nil {
+				httpError(w, r, "error.import.invalid_mapping", http.StatusBadRequest, nil)
+				return
+			}
+		}
+		people := map[string]string{}
+		if raw := r.FormValue("people"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &people); err != nil {
+				httpError(w, r, "error.import.invalid_mapping", http.StatusBadRequest, nil)
+				return
+			}
+		}
+		dryRun := r.FormValue("dry_run") != "false"
+		allowPlaceholders := r.FormValue("create_placeholders") != "false"
+
+		var parsed importParseResult
+		if source == importSourceSplitwise {
+			parsed = parseSplitwiseCSV(file)
+		} else {
+			parsed = parseGenericCSV(file, mapping)
+		}
+
+		members, err := repo.getGroupMemberRefs(r.Context(), groupID)
+		if err != nil {
+			log.Println("Grup üyeleri alınamadı:", err)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+			return
+		}
+		report, err := repo.getGroupReport(r.Context(), groupID)
+		if err != nil {
+			log.Println("Grup bilgisi alınamadı:", err)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+			return
+		}
+
+		importReport, expenses, placeholders := buildExpenseImport(source, report.Currency, parsed, members, people, allowPlaceholders)
+		importReport.DryRun = dryRun
+		locale := requestLocale(r)
+
+		w.Header().Set("Content-Type", "application/json")
+		if dryRun {
+			importReport.localize(locale)
+			json.NewEncoder(w).Encode(importReport)
+			return
+		}
+
+		// Hatalı satır varken yarım içe aktarma yapılmaz
+		if len(importReport.Errors) > 0 || len(expenses) == 0 {
+			importReport.localize(locale)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(importReport)
+			return
+		}
+
+		expenseIDs, created, err := repo.commitExpenseImport(r.Context(), groupID, userUID, placeholders, expenses)
+		if err != nil {
+			log.Println("İçe aktarma başarısız:", err)
+			httpError(w, r, "error.import.failed", http.StatusInternalServerError, nil)
+			return
+		}
+		log.Printf("İçe aktarma tamamlandı (group=%d, expenses=%d, placeholders=%d)", groupID, len(expenseIDs), len(created))
+
+		importReport.Committed = true
+		importReport.CreatedExpenseIDs = expenseIDs
+		importReport.CreatedPlaceholders = created
+		importReport.localize(locale)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(importReport)
+	}
+}
+
+type CreatePlaceholderRequest struct {
+	FullName string `json:"fullname" validate:"required,max=50"`
+	Email    string `json:"email" validate:"email,max=100"` // opsiyonel, kişi kayıt olunca otomatik eşleştirmek için
+	Phone    string `json:"phone" validate:"max=20"`        // opsiyonel
+}
+
+var placeholderPhonePattern = regexp.MustCompile(`^\+?[0-9 ]{6,20}$`)
+
+func handleGetGroupPlaceholders(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupMemberFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+
+		placeholders, err := repo.getGroupPlaceholders(r.Context(), groupID)
+		if err != nil {
+			log.Println("Geçici üyeler alınamadı:", err)
+			httpError(w, r, "error.placeholder.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(placeholders)
+	}
+}
+
+func handleCreateGroupPlaceholder(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupMemberFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+
+		var req CreatePlaceholderRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		req.FullName = strings.TrimSpace(req.FullName)
+		req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+		req.Phone = strings.TrimSpace(req.Phone)
+
+		if req.Phone != "" && !placeholderPhonePattern.MatchString(req.Phone) {
+			httpError(w, r, "error.placeholder.invalid_phone", http.StatusBadRequest, nil)
+			return
+		}
+
+		placeholder, err := repo.createPlaceholderMember(r.Context(), groupID, userUID, req.FullName, req.Email, req.Phone)
+		if err != nil {
+			log.Println("Geçici üye oluşturulamadı:", err)
+			httpError(w, r, "error.placeholder.create_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(placeholder)
+	}
+}
+
+func handleGetClaimablePlaceholders(repo PlaceholderStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		placeholders, err := repo.getClaimablePlaceholders(r.Context(), userUID)
+		if err != nil {
+			log.Println("Sahiplenilebilir geçici üyeler alınamadı:", err)
+			httpError(w, r, "error.placeholder.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(placeholders)
+	}
+}
+
+func handleClaimPlaceholder(repo PlaceholderStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		placeholderID := r.PathValue("id")
+		if !isPlaceholderID(placeholderID) {
+			httpError(w, r, "error.placeholder.not_found", http.StatusNotFound, nil)
+			return
+		}
+
+		groupID, err := repo.claimPlaceholder(r.Context(), placeholderID, userUID)
+		if err != nil {
+			writeError(w, r, err, "error.placeholder.claim_failed")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  T(requestLocale(r), "message.placeholder_claimed", nil),
+			"group_id": groupID,
+		})
+	}
+}
+
+type FriendRequestBody struct {
+	Email string `json:"email" validate:"required,email,max=100"`
+}
+
+type FriendRequestResponseBody struct {
+	RequestID int64 `json:"request_id"`
+}
+
+func handleSendFriendRequest(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		var req FriendRequestBody
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		req.Email = strings.TrimSpace(req.Email)
+
+		requestID, friendID, err := repo.sendFriendRequest(r.Context(), userUID, req.Email)
+		if err != nil {
+			writeError(w, r, err, "error.friend.request_failed")
+			return
+		}
+
+		requester, err := repo.GetUserByID(userUID)
+		if err == nil && requester != nil {
+			params := map[string]string{"name": requester.FullName}
+			data := map[string]string{"type": "new_friend_request", "request_id": strconv.FormatInt(requestID, 10)}
+			if err := SendNotification(r.Context(), repo, friendID, "notification.friend_request.title", "notification.friend_request.body", params, data); err != nil {
+				log.Printf("Bildirim gönderilemedi: %v", err)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":    T(requestLocale(r), "message.friend_request_sent", nil),
+			"request_id": requestID,
+		})
+	}
+}
+
+func handleGetFriendRequests(repo FriendStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		requests, err := repo.getMyFriendRequests(r.Context(), userUID)
+		if err != nil {
+			log.Println("Arkadaşlık istekleri alınamadı:", err)
+			httpError(w, r, "error.friend.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(requests)
+	}
+}
+
+func handleRespondFriendRequest(repo Repository, accept bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		requestID, _ := strconv.ParseInt(r.PathValue("requestId"), 10, 64)
+		if requestID <= 0 {
+			httpError(w, r, "error.request.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+
+		requesterID, err := repo.respondFriendRequest(r.Context(), requestID, userUID, accept)
+		if err != nil {
+			writeError(w, r, err, "error.friend.respond_failed")
+			return
+		}
+
+		messageKey := "message.friend_request_rejected"
+		if accept {
+			messageKey = "message.friend_request_accepted"
+			if me, err := repo.GetUserByID(userUID); err == nil && me != nil {
+				params := map[string]string{"name": me.FullName}
+				data := map[string]string{"type": "friend_request_accepted", "user_id": userUID}
+				go func() {
+					if err := SendNotification(context.Background(), repo, requesterID, "notification.friend_accepted.title", "notification.friend_accepted.body", params, data); err != nil {
+						log.Printf("Bildirim gönderilemedi: %v", err)
+					}
+				}()
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": T(requestLocale(r), messageKey, nil),
+		})
+	}
+}
+
+// handleGetFriends arkadaşları ve ortak grup ya da grup dışı borcu olan herkesi net bakiyeyle listeler
+func handleGetFriends(repo FriendStore, friendsOnly bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		balances, err := repo.getCounterpartyBalances(r.Context(), userUID)
+		if err != nil {
+			log.Println("Bakiyeler alınamadı:", err)
+			httpError(w, r, "error.friend.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		if friendsOnly {
+			friends := balances[:0]
+			for _, b := range balances {
+				if b.IsFriend {
+					friends = append(friends, b)
+				}
+			}
+			balances = friends
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(balances)
+	}
+}
+
+func handleRemoveFriend(repo FriendStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		err := repo.removeFriend(r.Context(), userUID, r.PathValue("id"))
+		if errors.Is(err, errNotFriends) {
+			httpError(w, r, "error.friend.not_friends", http.StatusNotFound, nil)
+			return
+		}
+		if err != nil {
+			log.Println("Arkadaşlık silinemedi:", err)
+			httpError(w, r, "error.friend.remove_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func handleGetFriendExpenses(repo FriendStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		// Arkadaşlıktan çıkarılsa da ortak geçmiş görülebilir
+		expenses, err := repo.getFriendExpenses(r.Context(), userUID, r.PathValue("id"))
+		if err != nil {
+			log.Println("Grup dışı harcamalar alınamadı:", err)
+			httpError(w, r, "error.friend.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(expenses)
+	}
+}
+
+func handleSettleFriend(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+		friendID := r.PathValue("id")
+
+		settled, err := repo.settleFriendBalance(r.Context(), userUID, friendID)
+		if err != nil {
+			log.Println("Grup dışı ödeme kaydedilemedi:", err)
+			httpError(w, r, "error.expense.pay_failed", http.StatusInternalServerError, nil)
+			return
+		}
+
+		if settled > 0 {
+			if me, err := repo.GetUserByID(userUID); err == nil && me != nil {
+				params := map[string]string{"name": me.FullName}
+				data := map[string]string{"type": "friend_settled", "user_id": userUID}
+				if err := SendNotification(r.Context(), repo, friendID, "notification.expense_paid.title", "notification.expense_paid.body", params, data); err != nil {
+					log.Printf("Bildirim gönderilemedi: %v", err)
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":        T(requestLocale(r), "message.expense_paid", nil),
+			"settled_shares": settled,
+		})
+	}
+}
+
+func handleCreateFriendExpense(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		var req CreateExpenseRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		defer r.Body.Close()
+
+		// Paylar toplamı tutarı karşılamalı; katılımcılar createFriendExpense'te kontrol edilir
+		var sum float64
+		for _, u := range req.Users {
+			sum += *u.Amount
+		}
+		if math.Abs(sum-req.TotalAmount) > 0.01 {
+			httpError(w, r, "error.expense.shares_mismatch", http.StatusBadRequest, nil)
+			return
+		}
+
+		expense, err := repo.createFriendExpense(r.Context(), userUID, req)
+		if err != nil {
+			writeError(w, r, err, "error.expense.create_failed")
+			return
+		}
+
+		// Arkadaş harcamalarının grubu yok; varsayılan para birimi kullanılır
+		params := map[string]string{
+			"title":    req.PaymentTitle,
+			"amount":   fmt.Sprintf("%.2f", req.TotalAmount),
+			"currency": defaultCurrency,
+		}
+		go func() {
+			for _, user := range req.Users {
+				if user.UserID == userUID {
+					continue
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := SendNotification(ctx, repo, user.UserID, "notification.expense_created.title", "notification.expense_created.body", params, nil); err != nil {
+					log.Printf("Bildirim gönderilemedi (userID=%s): %v", user.UserID, err)
+				}
+				cancel()
+			}
+		}()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(expense)
+	}
+}
+
+func handleDeleteFriendExpense(repo FriendStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		expenseID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || expenseID <= 0 {
+			httpError(w, r, "error.expense.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+
+		err = repo.deleteFriendExpense(r.Context(), userUID, expenseID)
+		if err != nil {
+			writeError(w, r, err, "error.expense.delete_failed")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// groupMemberFromPath yoldaki grup ID'sini okur ve kullanıcının grubun üyesi olduğunu doğrular.
+// Hata yanıtı yazıldıysa false döner.
+func groupMemberFromPath(w http.ResponseWriter, r *http.Request, repo GroupStore, userUID string) (int64, bool) {
+	groupID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || groupID <= 0 {
+		httpError(w, r, "error.group.invalid_id", http.StatusBadRequest, nil)
+		return 0, false
+	}
+
+	isMember, err := repo.isGroupMember(r.Context(), groupID, userUID)
+	if err != nil {
+		log.Println("Üyelik kontrolü başarısız:", err)
+		httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+		return 0, false
+	}
+	if !isMember {
+		httpError(w, r, "error.group.not_member", http.StatusForbidden, nil)
+		return 0, false
+	}
+	return groupID, true
+}
+
+// groupAdminFromPath yoldaki grup ID'sini okur ve kullanıcının grubun yöneticisi olduğunu doğrular.
+// Hata yanıtı yazıldıysa false döner.
+func groupAdminFromPath(w http.ResponseWriter, r *http.Request, repo GroupStore, userUID string) (int64, bool) {
+	groupID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || groupID <= 0 {
+		httpError(w, r, "error.group.invalid_id", http.StatusBadRequest, nil)
+		return 0, false
+	}
+
+	creatorID, err := repo.getGroupCreatorID(r.Context(), groupID)
+	if err == sql.ErrNoRows {
+		httpError(w, r, "error.group.not_found", http.StatusNotFound, nil)
+		return 0, false
+	} else if err != nil {
+		log.Println("Grup yöneticisi alınamadı:", err)
+		httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+		return 0, false
+	}
+	if creatorID != userUID {
+		httpError(w, r, "error.group.admin_only", http.StatusForbidden, nil)
+		return 0, false
+	}
+	return groupID, true
+}
+
+// handleGetInviteLinks grubun davet bağlantılarını listeler
+func handleGetInviteLinks(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupAdminFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+
+		links, err := repo.getGroupInviteLinks(r.Context(), groupID)
+		if err != nil {
+			log.Println("Davet bağlantıları alınamadı:", err)
+			httpError(w, r, "error.invite.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(links)
+	}
+}
+
+// handleCreateInviteLink grup için yeni bir davet bağlantısı oluşturur
+func handleCreateInviteLink(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupAdminFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+
+		var req CreateInviteLinkRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		defer r.Body.Close()
+
+		link, err := repo.createInviteLink(r.Context(), groupID, userUID, req)
+		if err != nil {
+			log.Println("Davet bağlantısı oluşturulamadı:", err)
+			httpError(w, r, "error.invite.create_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(link)
+	}
+}
+
+func handleRevokeInviteLink(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupAdminFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+		linkID, err := strconv.ParseInt(r.PathValue("linkId"), 10, 64)
+		if err != nil || linkID <= 0 {
+			httpError(w, r, "error.invite.not_found", http.StatusNotFound, nil)
+			return
+		}
+
+		err = repo.revokeInviteLink(r.Context(), groupID, linkID, userUID)
+		if err != nil {
+			writeError(w, r, err, "error.invite.revoke_failed")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleRotateInviteLink bağlantıyı yeni bir token ile değiştirir, eski token çalışmaz
+func handleRotateInviteLink(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupAdminFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+		linkID, err := strconv.ParseInt(r.PathValue("linkId"), 10, 64)
+		if err != nil || linkID <= 0 {
+			httpError(w, r, "error.invite.not_found", http.StatusNotFound, nil)
+			return
+		}
+
+		link, err := repo.rotateInviteLink(r.Context(), groupID, linkID, userUID)
+		switch {
+		case errors.Is(err, errInviteNotFound):
+			httpError(w, r, "error.invite.not_found", http.StatusNotFound, nil)
+			return
+		case errors.Is(err, errInviteRevoked):
+			httpError(w, r, "error.invite.revoked", http.StatusConflict, nil)
+			return
+		case err != nil:
+			log.Println("Davet bağlantısı yenilenemedi:", err)
+			httpError(w, r, "error.invite.create_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(link)
+	}
+}
+
+// notifyJoinDecision katılım isteği yanıtlandığında isteği açan kullanıcıya haber verir; davetlerde bir şey yapmaz
+func notifyJoinDecision(repo Repository, decision *AddRequestDecision, titleKey, bodyKey string) {
+	if decision == nil || decision.Direction != requestDirectionJoin {
+		return
+	}
+	params := map[string]string{"group": decision.GroupName}
+	data := map[string]string{"type": "join_request_answered", "group_id": strconv.FormatInt(decision.GroupID, 10)}
+	go func() {
+		if err := SendNotification(context.Background(), repo, decision.UserID, titleKey, bodyKey, params, data); err != nil {
+			log.Printf("Bildirim gönderilemedi: %v", err)
+		}
+	}()
+}
+
+// handleGetGroupJoinSettings grubun katılım onayı modunu üyelere gösterir
+func handleGetGroupJoinSettings(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupMemberFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+
+		settings, err := repo.getGroupJoinSettings(r.Context(), groupID)
+		if err != nil {
+			log.Println("Katılım ayarları alınamadı:", err)
+			httpError(w, r, "error.group.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(settings)
+	}
+}
+
+// handleUpdateGroupJoinSettings grubun katılım onayı modunu değiştirir, sadece yönetici
+func handleUpdateGroupJoinSettings(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupAdminFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+
+		var req GroupJoinSettings
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		defer r.Body.Close()
+		req.GroupID = groupID
+
+		if err := repo.setGroupJoinApproval(r.Context(), userUID, req); err != nil {
+			log.Println("Katılım ayarları kaydedilemedi:", err)
+			httpError(w, r, "error.group.join_settings_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(req)
+	}
+}
+
+// handleGetOutgoingRequests yöneticinin grubundan gönderilen davetleri listeler (?status=pending gibi filtrelenebilir)
+func handleGetOutgoingRequests(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupAdminFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", "pending", "accepted", "rejected", "cancelled", "expired":
+		default:
+			httpError(w, r, "error.request.invalid_status", http.StatusBadRequest, nil)
+			return
+		}
+
+		requests, err := repo.getGroupOutgoingRequests(r.Context(), groupID, status)
+		if err != nil {
+			log.Println("Gönderilen istekler alınamadı:", err)
+			httpError(w, r, "error.request.fetch_failed", http.StatusInternalServerError, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(requests)
+	}
+}
+
+func handleCancelAddRequest(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupAdminFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+		requestID, err := strconv.ParseInt(r.PathValue("requestId"), 10, 64)
+		if err != nil || requestID <= 0 {
+			httpError(w, r, "error.request.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+
+		err = repo.cancelAddRequest(r.Context(), groupID, requestID, userUID)
+		if err != nil {
+			writeError(w, r, err, "error.request.cancel_failed")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": T(requestLocale(r), "message.request_cancelled", nil),
+		})
+	}
+}
+
+// handleResendAddRequest bekleyen davet için bildirimi tekrar gönderir; sıklığı sınırlıdır
+func handleResendAddRequest(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID, ok := r.Context().Value("userUID").(string)
+		if !ok || userUID == "" {
+			httpError(w, r, "error.unauthorized", http.StatusUnauthorized, nil)
+			return
+		}
+
+		groupID, ok := groupAdminFromPath(w, r, repo, userUID)
+		if !ok {
+			return
+		}
+		requestID, err := strconv.ParseInt(r.PathValue("requestId"), 10, 64)
+		if err != nil || requestID <= 0 {
+			httpError(w, r, "error.request.invalid_id", http.StatusBadRequest, nil)
+			return
+		}
+
+		req, wait, err := repo.resendAddRequest(r.Context(), groupID, requestID, userUID, addRequestResendCooldown())
+		if errors.Is(err, errAddRequestResendTooSoon) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		}
+		if err != nil {
+			writeError(w, r, err, "error.request.resend_failed")
+			return
+		}
+
+		params := map[string]string{"name": userUID, "group": req.GroupName}
+		if inviter, err := repo.GetUserByID(userUID); err == nil && inviter != nil {
+			params["name"] = inviter.FullName
+		}
+		data := map[string]string{"type": "new_request"}
+		if err := SendNotification(r.Context(), repo, req.UserID, "notification.add_request.title", "notification.add_request.body", params, data); err != nil {
+			log.Printf("Bildirim gönderilemedi: %v", err)
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{
-			"message": "Hesap başarıyla silindi",
+			"message": T(requestLocale(r), "message.request_resent", nil),
 		})
-		log.Println("Hesap başarıyla silindi:", userUID)
 	}
 }
@@ -0,0 +1,611 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Varsayılan dil, kullanıcının dili bilinmediğinde kullanılır
+const defaultLocale = "tr"
+
+var supportedLocales = []string{"tr", "en"}
+
+// Mesaj kataloğu: dil -> anahtar -> şablon.
+// Şablonlardaki {isim} alanları T fonksiyonuna verilen parametrelerle doldurulur.
+var messages = map[string]map[string]string{
+	"tr": {
+		// Genel hatalar
+		"error.invalid_json":        "Geçersiz JSON formatı",
+		"error.server":              "Sunucu hatası",
+		"error.database":            "Veritabanı hatası",
+		"error.unauthorized":        "Yetkisiz erişim",
+		"error.unauthorized_no_uid": "Yetkisiz erişim: Kullanıcı ID alınamadı",
+
+		// İstek doğrulama, bkz. validation.go
+		"error.validation":       "Bazı alanlar geçersiz",
+		"error.unknown_field":    "Bilinmeyen alan: {field}",
+		"error.body_too_large":   "İstek gövdesi en fazla {limit} bayt olabilir",
+		"error.body_read_failed": "İstek gövdesi okunamadı",
+		"validation.required":    "Bu alan zorunludur",
+		"validation.min_length":  "En az {param} karakter olmalı",
+		"validation.max_length":  "En fazla {param} karakter olabilir",
+		"validation.min_items":   "En az {param} öğe olmalı",
+		"validation.max_items":   "En fazla {param} öğe olabilir",
+		"validation.min":         "{param} veya daha büyük olmalı",
+		"validation.max":         "{param} veya daha küçük olmalı",
+		"validation.gt":          "{param} değerinden büyük olmalı",
+		"validation.email":       "Geçerli bir email adresi olmalı",
+		"validation.oneof":       "Şunlardan biri olmalı: {param}",
+		"validation.unique":      "Aynı {param} birden fazla kez kullanılamaz",
+		"validation.type":        "Değerin tipi geçersiz",
+
+		// Idempotency-Key, bkz. idempotency.go
+		"error.idempotency.invalid_key": "Idempotency-Key boşluksuz ve en fazla 255 karakter olmalı",
+		"error.idempotency.key_reused":  "Bu Idempotency-Key farklı bir istekle kullanılmış",
+		"error.idempotency.in_progress": "Aynı Idempotency-Key ile gönderilen istek hâlâ işleniyor",
+
+		// Kimlik doğrulama
+		"error.auth.token_missing":     "Yetkisiz erişim: token eksik",
+		"error.auth.invalid_jwt":       "Geçersiz JWT token",
+		"error.auth.invalid_jwt_uid":   "Geçersiz JWT token: UID eksik",
+		"error.auth.uid_mismatch":      "Yetkisiz erişim: UID uyuşmuyor",
+		"error.auth.failed":            "Kimlik doğrulama başarısız",
+		"error.auth.invalid_creds":     "Geçersiz email veya şifre",
+		"error.auth.firebase":          "Firebase doğrulaması başarısız",
+		"error.auth.email_mismatch":    "Email uyuşmazlığı",
+		"error.auth.jwt_create_failed": "JWT oluşturulamadı",
+
+		// Kullanıcı
+		"error.user.firebase_create":    "Kullanıcı Firebase'de oluşturulamadı",
+		"error.user.create_failed":      "Kullanıcı oluşturulamadı",
+		"error.user.register_failed":    "Kullanıcı kaydı başarısız",
+		"error.user.fetch_failed":       "Kullanıcı bilgileri alınamadı",
+		"error.user.not_found":          "Kullanıcı bulunamadı",
+		"error.user.deleted":            "Kullanıcı silinmiş",
+		"error.user.no_update_fields":   "Güncellenecek alan belirtilmedi",
+		"error.user.update_failed":      "Kullanıcı güncelleme işlemi başarısız oldu",
+		"error.user.unsupported_locale": "Desteklenmeyen dil: {locale}",
+		"error.account.delete_failed":   "Hesap silinemedi",
+
+		// Gruplar
+		"error.group.create_failed":        "Grup oluşturulamadı",
+		"error.group.fetch_failed":         "Grup bilgileri alınamadı",
+		"error.group.token_required":       "group_token zorunludur",
+		"error.group.join_failed":          "Gruba eklenemedi",
+		"error.request.fields_required":    "group_id ve added_member alanları zorunludur",
+		"error.request.fetch_failed":       "Grup ekleme istekleri alınamadı",
+		"error.request.accept_failed":      "Grup ekleme isteği kabul edilemedi",
+		"error.request.reject_failed":      "Grup ekleme isteği red edilemedi",
+		"error.expense.participant_needed": "En az bir katılımcı olmalı",
+		"error.expense.create_failed":      "Harcama oluşturulamadı",
+		"error.expense.pay_failed":         "Harcama ödemesi başarısız",
+		"error.expense.invalid_id":         "Geçersiz harcama ID'si",
+		"error.expense.delete_failed":      "Harcama silinemedi",
+
+		// Dosya ve cihaz
+		"error.upload.parse_failed":  "Multipart form çözümlenemedi",
+		"error.upload.photo_missing": "Fotoğraf dosyası zorunludur",
+		"error.upload.save_failed":   "Dosya kaydedilemedi",
+		"error.fcm.save_failed":      "FCM token kaydedilemedi",
+
+		// Gelen kutusu
+		"error.notification.fetch_failed":  "Bildirimler alınamadı",
+		"error.notification.update_failed": "Bildirimler güncellenemedi",
+
+		// Başarı mesajları
+		"message.user_created":     "Kullanıcı başarıyla oluşturuldu",
+		"message.login_success":    "Giriş başarılı",
+		"message.request_accepted": "Grup ekleme isteği kabul edildi",
+		"message.expense_paid":     "Harcama başarıyla ödendi",
+		"message.fcm_saved":        "FCM token başarıyla kaydedildi",
+		"message.group_joined":     "Grup başarıyla eklendi",
+
+		// Bildirimler
+		"notification.add_request.title":      "Yeni grup isteği",
+		"notification.add_request.body":       "{name} sizi {group} grubuna eklemek istedi.",
+		"notification.request_accepted.title": "Grup isteği kabul edildi",
+		"notification.request_accepted.body":  "{name} kullanıcı isteğinizi kabul etti.",
+		"notification.expense_created.title":  "Yeni Harcama Eklendi",
+		"notification.expense_created.body":   "'{title}' başlıklı harcama oluşturuldu. Tutar: {amount} {currency}",
+		"notification.expense_paid.title":     "Harcama Ödendi",
+		"notification.expense_paid.body":      "{name} tarafından bir harcama ödendi.",
+
+		// Hatırlatmalar
+		"error.method_not_allowed":             "Bu metod desteklenmiyor",
+		"error.group.invalid_id":               "Geçersiz grup ID'si",
+		"error.group.not_found":                "Grup bulunamadı",
+		"error.group.not_member":               "Bu grubun üyesi değilsiniz",
+		"error.group.admin_only":               "Bu işlemi sadece grup yöneticisi yapabilir",
+		"error.reminder.settings_fetch_failed": "Hatırlatma ayarları alınamadı",
+		"error.reminder.settings_save_failed":  "Hatırlatma ayarları kaydedilemedi",
+		"error.reminder.fields_required":       "group_id ve debtor_id alanları zorunludur",
+		"error.reminder.no_debt":               "Bu kullanıcının size ödenmemiş borcu yok",
+		"error.reminder.rate_limited":          "Bu kullanıcıya son 24 saat içinde zaten hatırlatma gönderildi",
+		"error.reminder.send_failed":           "Hatırlatma gönderilemedi",
+		"message.nudge_sent":                   "Hatırlatma gönderildi",
+		"notification.payment_reminder.title":  "Ödeme hatırlatması",
+		"notification.payment_reminder.body":   "{group} grubunda {name} adlı kişiye {amount} {currency} borcunuz var.",
+
+		// Aktivite
+		"error.activity.fetch_failed": "Aktivite kaydı alınamadı",
+
+		// Silinen harcamalar
+		"error.expense.already_deleted":      "Harcama zaten silinmiş",
+		"error.expense.not_deleted":          "Harcama silinmemiş",
+		"error.expense.restore_expired":      "Harcamanın geri alma süresi dolmuş",
+		"error.expense.restore_failed":       "Harcama geri alınamadı",
+		"error.expense.fetch_deleted_failed": "Silinmiş harcamalar alınamadı",
+
+		// Hesap silme
+		"message.account_deletion_scheduled": "Hesabınız silinmek üzere işaretlendi. Bekleme süresi bitene kadar giriş yapıp geri alabilirsiniz",
+		"message.account_restored":           "Hesap silme talebi geri alındı",
+		"error.account.no_pending_deletion":  "Bekleyen bir hesap silme talebi yok",
+		"error.account.restore_failed":       "Hesap geri alınamadı",
+		"error.account.status_failed":        "Hesap silme durumu alınamadı",
+		"account.deleted_user_name":          "Silinmiş Kullanıcı",
+
+		// Veri dışa aktarma
+		"message.data_export_requested":        "Veri dışa aktarma talebiniz alındı, arşiv hazır olunca bildirim alacaksınız",
+		"error.export.fetch_failed":            "Dışa aktarma talepleri alınamadı",
+		"error.export.create_failed":           "Dışa aktarma talebi oluşturulamadı",
+		"error.export.link_invalid":            "İndirme bağlantısı geçersiz ya da süresi dolmuş",
+		"notification.data_export_ready.title": "Verileriniz hazır",
+		"notification.data_export_ready.body":  "Kişisel veri arşiviniz {hours} saat boyunca indirilebilir",
+
+		// Grup raporu
+		"error.group.invalid_currency": "Para birimi üç harfli ISO 4217 kodu olmalı (örn. TRY, EUR)",
+		"error.export.invalid_format":  "Geçersiz format, csv, xlsx veya pdf olmalı",
+		"error.export.group_failed":    "Grup raporu oluşturulamadı",
+		"report.title":                 "Grup",
+		"report.currency":              "Para birimi",
+		"report.generated_at":          "Oluşturulma",
+		"report.expenses":              "Harcamalar",
+		"report.members":               "Üye Toplamları",
+		"report.settlements":           "Önerilen Ödemeler",
+		"report.no_settlements":        "Herkesin hesabı kapalı",
+		"report.status.paid":           "Ödendi",
+		"report.status.unpaid":         "Ödenmedi",
+		"report.col.expense_id":        "Harcama No",
+		"report.col.date":              "Tarih",
+		"report.col.title":             "Başlık",
+		"report.col.note":              "Not",
+		"report.col.payer":             "Ödeyen",
+		"report.col.amount":            "Tutar",
+		"report.col.participant":       "Katılımcı",
+		"report.col.share":             "Pay",
+		"report.col.status":            "Durum",
+		"report.col.member":            "Üye",
+		"report.col.paid":              "Ödediği",
+		"report.col.net":               "Net Bakiye",
+		"report.col.from":              "Ödeyecek",
+		"report.col.to":                "Alacak",
+
+		// İçe aktarma
+		"error.import.file_required":         "CSV dosyası gerekli (file alanı)",
+		"error.import.invalid_source":        "Kaynak splitwise veya generic olmalı",
+		"error.import.invalid_mapping":       "Sütun eşlemesi geçersiz",
+		"error.import.failed":                "İçe aktarma başarısız",
+		"import.issue.not_splitwise":         "Splitwise dışa aktarma başlığı bekleniyordu (Date, Description, Category, Cost, Currency, kişiler)",
+		"import.issue.column_missing":        "Sütun bulunamadı: {column}",
+		"import.issue.invalid_date":          "Geçersiz tarih: {value}",
+		"import.issue.invalid_amount":        "Geçersiz tutar: {value}",
+		"import.issue.title_required":        "Başlık boş olamaz",
+		"import.issue.payer_required":        "Ödeyen boş olamaz",
+		"import.issue.participants_required": "En az bir katılımcı gerekli",
+		"import.issue.share_mismatch":        "Paylar toplamı ({total}) tutarla ({amount}) uyuşmuyor",
+		"import.issue.multiple_payers":       "Birden fazla ödeyenli harcamalar desteklenmiyor",
+		"import.issue.no_payer":              "\"{title}\" kimseye borç oluşturmuyor, atlandı",
+		"import.issue.payment_skipped":       "\"{title}\" bir ödeme kaydı, atlandı",
+		"import.issue.currency_mismatch":     "Para birimi {currency}, grup para birimi {expected}",
+		"import.issue.ambiguous_person":      "\"{name}\" birden fazla üyeyle eşleşiyor, people eşlemesi ile belirtin",
+		"import.issue.unknown_mapping":       "\"{name}\" için eşlenen kullanıcı grupta değil",
+		"import.issue.unknown_person":        "\"{name}\" grupta bulunamadı",
+		"import.issue.too_many_rows":         "Dosya en fazla {max} satır içerebilir",
+		"import.issue.parse_failed":          "CSV okunamadı: {error}",
+
+		// Geçici üyeler
+		"error.placeholder.fetch_failed":    "Geçici üyeler alınamadı",
+		"error.placeholder.invalid_phone":   "Geçersiz telefon numarası",
+		"error.placeholder.create_failed":   "Geçici üye oluşturulamadı",
+		"error.placeholder.not_found":       "Geçici üye bulunamadı",
+		"error.placeholder.claim_forbidden": "Bu geçici üyeyi sahiplenme yetkiniz yok",
+		"error.placeholder.claim_failed":    "Geçici üye sahiplenilemedi",
+		"message.placeholder_claimed":       "Geçici üyenin harcamaları hesabınıza aktarıldı",
+
+		// Arkadaşlar ve grup dışı harcamalar
+		"error.request.invalid_id":           "Geçersiz istek ID'si",
+		"error.expense.not_found":            "Harcama bulunamadı",
+		"error.expense.shares_mismatch":      "Payların toplamı harcama tutarına eşit olmalı",
+		"error.friend.self":                  "Kendinize arkadaşlık isteği gönderemezsiniz",
+		"error.friend.already_friends":       "Bu kullanıcı zaten arkadaşınız",
+		"error.friend.request_pending":       "Bu kullanıcıyla bekleyen bir arkadaşlık isteği zaten var",
+		"error.friend.request_failed":        "Arkadaşlık isteği gönderilemedi",
+		"error.friend.request_not_found":     "Arkadaşlık isteği bulunamadı",
+		"error.friend.respond_failed":        "Arkadaşlık isteği yanıtlanamadı",
+		"error.friend.fetch_failed":          "Arkadaş bilgileri alınamadı",
+		"error.friend.not_friends":           "Bu kullanıcıyla arkadaş değilsiniz",
+		"error.friend.remove_failed":         "Arkadaş silinemedi",
+		"message.friend_request_sent":        "Arkadaşlık isteği gönderildi",
+		"message.friend_request_accepted":    "Arkadaşlık isteği kabul edildi",
+		"message.friend_request_rejected":    "Arkadaşlık isteği reddedildi",
+		"notification.friend_request.title":  "Yeni Arkadaşlık İsteği",
+		"notification.friend_request.body":   "{name} size arkadaşlık isteği gönderdi.",
+		"notification.friend_accepted.title": "Arkadaşlık İsteği Kabul Edildi",
+		"notification.friend_accepted.body":  "{name} arkadaşlık isteğinizi kabul etti.",
+
+		// Davet bağlantıları
+		"error.invite.not_found":       "Davet bağlantısı bulunamadı",
+		"error.invite.revoked":         "Bu davet bağlantısı iptal edilmiş",
+		"error.invite.expired":         "Bu davet bağlantısının süresi dolmuş",
+		"error.invite.exhausted":       "Bu davet bağlantısının kullanım hakkı dolmuş",
+		"error.invite.request_pending": "Bu grup için bekleyen bir katılım isteğiniz zaten var",
+		"error.invite.fetch_failed":    "Davet bağlantıları alınamadı",
+		"error.invite.create_failed":   "Davet bağlantısı oluşturulamadı",
+		"error.invite.revoke_failed":   "Davet bağlantısı iptal edilemedi",
+		"message.group_join_requested": "Katılım isteğiniz grup yöneticisinin onayına gönderildi",
+
+		// Katılım onayı
+		"error.group.join_settings_failed": "Katılım ayarları kaydedilemedi",
+		"notification.join_request.title":  "Yeni Katılım İsteği",
+		"notification.join_request.body":   "{name}, {group} grubuna katılmak istiyor.",
+		"notification.join_approved.title": "Katılım İsteği Onaylandı",
+		"notification.join_approved.body":  "{group} grubuna katılım isteğiniz onaylandı.",
+		"notification.join_rejected.title": "Katılım İsteği Reddedildi",
+		"notification.join_rejected.body":  "{group} grubuna katılım isteğiniz reddedildi.",
+
+		// Email davetleri
+		"error.invitation.pending": "Bu email adresine bu grup için zaten bekleyen bir davet gönderilmiş",
+		"mail.invitation.subject":  "{name} sizi Kasa'da {group} grubuna davet etti",
+		"mail.invitation.body":     "Merhaba,\n\n{name} sizi Kasa'da {group} grubuna davet etti. Kasa ile ortak harcamaları kolayca bölüşebilirsiniz.\n\nUygulamayı indirip {email} adresiyle kayıt olduğunuzda davet hesabınıza otomatik olarak eklenecek.\n\n{url}",
+
+		// Giden grup ekleme istekleri
+		"error.request.invalid_status":      "Geçersiz istek durumu",
+		"error.request.not_found":           "Grup ekleme isteği bulunamadı",
+		"error.request.not_pending":         "Bu istek artık beklemede değil",
+		"error.request.cancel_failed":       "Grup ekleme isteği iptal edilemedi",
+		"error.request.resend_rate_limited": "Bu istek için bildirim kısa süre önce gönderildi, lütfen daha sonra tekrar deneyin",
+		"error.request.resend_failed":       "Grup ekleme isteği tekrar gönderilemedi",
+		"message.request_cancelled":         "Grup ekleme isteği iptal edildi",
+		"message.request_resent":            "Grup ekleme isteği tekrar gönderildi",
+
+		// Depo hataları
+		"error.request.send_failed":            "Grup ekleme isteği gönderilemedi",
+		"error.request.forbidden":              "Bu istek üzerinde işlem yapma yetkiniz yok",
+		"error.request.already_member":         "Bu kullanıcı zaten grup üyesi",
+		"error.request.already_pending":        "Bu kullanıcıya zaten bekleyen bir istek gönderilmiş",
+		"error.expense.delete_forbidden":       "Harcamayı yalnızca grup sahibi veya harcamayı yapan kişi silebilir",
+		"error.expense.restore_forbidden":      "Harcamayı yalnızca grup sahibi veya harcamayı yapan kişi geri alabilir",
+		"error.expense.share_required":         "Katılımcı tutarı boş olamaz",
+		"error.expense.payer_not_participant":  "Ödeyen kişi katılımcılar arasında olmalı",
+		"error.expense.participant_not_member": "Tüm katılımcılar grup üyesi olmalı",
+	},
+	"en": {
+		"error.invalid_json":        "Invalid JSON format",
+		"error.server":              "Server error",
+		"error.database":            "Database error",
+		"error.unauthorized":        "Unauthorized",
+		"error.unauthorized_no_uid": "Unauthorized: user ID could not be read",
+
+		// Request validation, see validation.go
+		"error.validation":       "Some fields are invalid",
+		"error.unknown_field":    "Unknown field: {field}",
+		"error.body_too_large":   "Request body must be at most {limit} bytes",
+		"error.body_read_failed": "Request body could not be read",
+		"validation.required":    "This field is required",
+		"validation.min_length":  "Must be at least {param} characters",
+		"validation.max_length":  "Must be at most {param} characters",
+		"validation.min_items":   "Must contain at least {param} items",
+		"validation.max_items":   "Must contain at most {param} items",
+		"validation.min":         "Must be {param} or greater",
+		"validation.max":         "Must be {param} or less",
+		"validation.gt":          "Must be greater than {param}",
+		"validation.email":       "Must be a valid email address",
+		"validation.oneof":       "Must be one of: {param}",
+		"validation.unique":      "The same {param} cannot be used more than once",
+		"validation.type":        "Value has the wrong type",
+
+		// Idempotency-Key, see idempotency.go
+		"error.idempotency.invalid_key": "Idempotency-Key must be at most 255 characters without spaces",
+		"error.idempotency.key_reused":  "This Idempotency-Key was already used with a different request",
+		"error.idempotency.in_progress": "A request with the same Idempotency-Key is still being processed",
+
+		"error.auth.token_missing":     "Unauthorized: token missing",
+		"error.auth.invalid_jwt":       "Invalid JWT token",
+		"error.auth.invalid_jwt_uid":   "Invalid JWT token: UID missing",
+		"error.auth.uid_mismatch":      "Unauthorized: UID mismatch",
+		"error.auth.failed":            "Authentication failed",
+		"error.auth.invalid_creds":     "Invalid email or password",
+		"error.auth.firebase":          "Firebase verification failed",
+		"error.auth.email_mismatch":    "Email mismatch",
+		"error.auth.jwt_create_failed": "Could not create JWT",
+
+		"error.user.firebase_create":    "Could not create the user in Firebase",
+		"error.user.create_failed":      "Could not create the user",
+		"error.user.register_failed":    "User registration failed",
+		"error.user.fetch_failed":       "Could not fetch user details",
+		"error.user.not_found":          "User not found",
+		"error.user.deleted":            "User has been deleted",
+		"error.user.no_update_fields":   "No fields to update were given",
+		"error.user.update_failed":      "Updating the user failed",
+		"error.user.unsupported_locale": "Unsupported language: {locale}",
+		"error.account.delete_failed":   "Could not delete the account",
+
+		"error.group.create_failed":        "Could not create the group",
+		"error.group.fetch_failed":         "Could not fetch group details",
+		"error.group.token_required":       "group_token is required",
+		"error.group.join_failed":          "Could not join the group",
+		"error.request.fields_required":    "group_id and added_member are required",
+		"error.request.fetch_failed":       "Could not fetch group add requests",
+		"error.request.accept_failed":      "Could not accept the group add request",
+		"error.request.reject_failed":      "Could not reject the group add request",
+		"error.expense.participant_needed": "At least one participant is required",
+		"error.expense.create_failed":      "Could not create the expense",
+		"error.expense.pay_failed":         "Expense payment failed",
+		"error.expense.invalid_id":         "Invalid expense ID",
+		"error.expense.delete_failed":      "Could not delete the expense",
+
+		"error.upload.parse_failed":  "Could not parse multipart form",
+		"error.upload.photo_missing": "Photo file is required",
+		"error.upload.save_failed":   "Unable to save the file",
+		"error.fcm.save_failed":      "Could not save the FCM token",
+
+		"error.notification.fetch_failed":  "Could not fetch notifications",
+		"error.notification.update_failed": "Could not update notifications",
+
+		"message.user_created":     "User created successfully",
+		"message.login_success":    "Login successful",
+		"message.request_accepted": "Group add request accepted",
+		"message.expense_paid":     "Expense paid successfully",
+		"message.fcm_saved":        "FCM token saved successfully",
+		"message.group_joined":     "Joined the group successfully",
+
+		"notification.add_request.title":      "New group request",
+		"notification.add_request.body":       "{name} wants to add you to {group}.",
+		"notification.request_accepted.title": "Group request accepted",
+		"notification.request_accepted.body":  "{name} accepted your request.",
+		"notification.expense_created.title":  "New Expense Added",
+		"notification.expense_created.body":   "An expense titled '{title}' was created. Amount: {amount} {currency}",
+		"notification.expense_paid.title":     "Expense Paid",
+		"notification.expense_paid.body":      "{name} paid an expense.",
+
+		"error.method_not_allowed":             "Method not allowed",
+		"error.group.invalid_id":               "Invalid group ID",
+		"error.group.not_found":                "Group not found",
+		"error.group.not_member":               "You are not a member of this group",
+		"error.group.admin_only":               "Only the group admin can do this",
+		"error.reminder.settings_fetch_failed": "Could not fetch reminder settings",
+		"error.reminder.settings_save_failed":  "Could not save reminder settings",
+		"error.reminder.fields_required":       "group_id and debtor_id are required",
+		"error.reminder.no_debt":               "This user has no outstanding debt to you",
+		"error.reminder.rate_limited":          "This user was already reminded in the last 24 hours",
+		"error.reminder.send_failed":           "Could not send the reminder",
+		"message.nudge_sent":                   "Reminder sent",
+		"notification.payment_reminder.title":  "Payment reminder",
+		"notification.payment_reminder.body":   "You owe {name} {amount} {currency} in {group}.",
+
+		"error.activity.fetch_failed": "Could not fetch the activity log",
+
+		"error.expense.already_deleted":      "The expense is already deleted",
+		"error.expense.not_deleted":          "The expense is not deleted",
+		"error.expense.restore_expired":      "The restore window for this expense has passed",
+		"error.expense.restore_failed":       "Could not restore the expense",
+		"error.expense.fetch_deleted_failed": "Could not fetch deleted expenses",
+
+		"message.account_deletion_scheduled": "Your account is scheduled for deletion. You can sign in and restore it until the grace period ends",
+		"message.account_restored":           "Account deletion cancelled",
+		"error.account.no_pending_deletion":  "There is no pending account deletion",
+		"error.account.restore_failed":       "Could not restore the account",
+		"error.account.status_failed":        "Could not fetch the account deletion status",
+		"account.deleted_user_name":          "Deleted User",
+
+		"message.data_export_requested":        "Your data export has been requested. You will be notified when the archive is ready",
+		"error.export.fetch_failed":            "Could not fetch data exports",
+		"error.export.create_failed":           "Could not request the data export",
+		"error.export.link_invalid":            "The download link is invalid or has expired",
+		"notification.data_export_ready.title": "Your data is ready",
+		"notification.data_export_ready.body":  "Your personal data archive can be downloaded for {hours} hours",
+
+		"error.group.invalid_currency": "Currency must be a three-letter ISO 4217 code (e.g. TRY, EUR)",
+		"error.export.invalid_format":  "Invalid format, must be csv, xlsx or pdf",
+		"error.export.group_failed":    "Could not generate the group report",
+		"report.title":                 "Group",
+		"report.currency":              "Currency",
+		"report.generated_at":          "Generated at",
+		"report.expenses":              "Expenses",
+		"report.members":               "Member Totals",
+		"report.settlements":           "Suggested Settlements",
+		"report.no_settlements":        "Everyone is settled up",
+		"report.status.paid":           "Paid",
+		"report.status.unpaid":         "Unpaid",
+		"report.col.expense_id":        "Expense ID",
+		"report.col.date":              "Date",
+		"report.col.title":             "Title",
+		"report.col.note":              "Note",
+		"report.col.payer":             "Paid by",
+		"report.col.amount":            "Amount",
+		"report.col.participant":       "Participant",
+		"report.col.share":             "Share",
+		"report.col.status":            "Status",
+		"report.col.member":            "Member",
+		"report.col.paid":              "Paid",
+		"report.col.net":               "Net Balance",
+		"report.col.from":              "From",
+		"report.col.to":                "To",
+
+		"error.import.file_required":         "A CSV file is required (file field)",
+		"error.import.invalid_source":        "Source must be splitwise or generic",
+		"error.import.invalid_mapping":       "Invalid column mapping",
+		"error.import.failed":                "Import failed",
+		"import.issue.not_splitwise":         "Expected a Splitwise export header (Date, Description, Category, Cost, Currency, people)",
+		"import.issue.column_missing":        "Column not found: {column}",
+		"import.issue.invalid_date":          "Invalid date: {value}",
+		"import.issue.invalid_amount":        "Invalid amount: {value}",
+		"import.issue.title_required":        "Title cannot be empty",
+		"import.issue.payer_required":        "Payer cannot be empty",
+		"import.issue.participants_required": "At least one participant is required",
+		"import.issue.share_mismatch":        "Shares total ({total}) does not match the amount ({amount})",
+		"import.issue.multiple_payers":       "Expenses with more than one payer are not supported",
+		"import.issue.no_payer":              "\"{title}\" creates no debt and was skipped",
+		"import.issue.payment_skipped":       "\"{title}\" is a payment record and was skipped",
+		"import.issue.currency_mismatch":     "Currency is {currency}, group currency is {expected}",
+		"import.issue.ambiguous_person":      "\"{name}\" matches more than one member, specify it in the people mapping",
+		"import.issue.unknown_mapping":       "The user mapped for \"{name}\" is not in the group",
+		"import.issue.unknown_person":        "\"{name}\" was not found in the group",
+		"import.issue.too_many_rows":         "The file can contain at most {max} rows",
+		"import.issue.parse_failed":          "Could not read the CSV: {error}",
+
+		"error.placeholder.fetch_failed":    "Could not fetch placeholder members",
+		"error.placeholder.invalid_phone":   "Invalid phone number",
+		"error.placeholder.create_failed":   "Could not create the placeholder member",
+		"error.placeholder.not_found":       "Placeholder member not found",
+		"error.placeholder.claim_forbidden": "You are not allowed to claim this placeholder member",
+		"error.placeholder.claim_failed":    "Could not claim the placeholder member",
+		"message.placeholder_claimed":       "The placeholder's expenses have been moved to your account",
+
+		"error.request.invalid_id":           "Invalid request ID",
+		"error.expense.not_found":            "Expense not found",
+		"error.expense.shares_mismatch":      "The shares must add up to the expense total",
+		"error.friend.self":                  "You cannot send a friend request to yourself",
+		"error.friend.already_friends":       "This user is already your friend",
+		"error.friend.request_pending":       "There is already a pending friend request with this user",
+		"error.friend.request_failed":        "Could not send the friend request",
+		"error.friend.request_not_found":     "Friend request not found",
+		"error.friend.respond_failed":        "Could not respond to the friend request",
+		"error.friend.fetch_failed":          "Could not fetch friend information",
+		"error.friend.not_friends":           "You are not friends with this user",
+		"error.friend.remove_failed":         "Could not remove the friend",
+		"message.friend_request_sent":        "Friend request sent",
+		"message.friend_request_accepted":    "Friend request accepted",
+		"message.friend_request_rejected":    "Friend request rejected",
+		"notification.friend_request.title":  "New Friend Request",
+		"notification.friend_request.body":   "{name} sent you a friend request.",
+		"notification.friend_accepted.title": "Friend Request Accepted",
+		"notification.friend_accepted.body":  "{name} accepted your friend request.",
+
+		"error.invite.not_found":       "Invite link not found",
+		"error.invite.revoked":         "This invite link has been revoked",
+		"error.invite.expired":         "This invite link has expired",
+		"error.invite.exhausted":       "This invite link has reached its usage limit",
+		"error.invite.request_pending": "You already have a pending join request for this group",
+		"error.invite.fetch_failed":    "Could not fetch invite links",
+		"error.invite.create_failed":   "Could not create the invite link",
+		"error.invite.revoke_failed":   "Could not revoke the invite link",
+		"message.group_join_requested": "Your join request was sent to the group admin for approval",
+
+		"error.group.join_settings_failed": "Could not save the join settings",
+		"notification.join_request.title":  "New Join Request",
+		"notification.join_request.body":   "{name} wants to join {group}.",
+		"notification.join_approved.title": "Join Request Approved",
+		"notification.join_approved.body":  "Your request to join {group} was approved.",
+		"notification.join_rejected.title": "Join Request Rejected",
+		"notification.join_rejected.body":  "Your request to join {group} was rejected.",
+
+		"error.invitation.pending": "An invitation to this group is already pending for this email address",
+		"mail.invitation.subject":  "{name} invited you to {group} on Kasa",
+		"mail.invitation.body":     "Hello,\n\n{name} invited you to the group {group} on Kasa. Kasa makes it easy to split shared expenses.\n\nDownload the app and sign up with {email}; the invitation will be added to your account automatically.\n\n{url}",
+
+		"error.request.invalid_status":      "Invalid request status",
+		"error.request.not_found":           "Group add request not found",
+		"error.request.not_pending":         "This request is no longer pending",
+		"error.request.cancel_failed":       "Could not cancel the group add request",
+		"error.request.resend_rate_limited": "A notification for this request was sent recently, please try again later",
+		"error.request.resend_failed":       "Could not resend the group add request",
+		"message.request_cancelled":         "Group add request cancelled",
+		"message.request_resent":            "Group add request resent",
+
+		// Repository errors
+		"error.request.send_failed":            "Could not send the group add request",
+		"error.request.forbidden":              "You are not allowed to act on this request",
+		"error.request.already_member":         "This user is already a group member",
+		"error.request.already_pending":        "A pending request has already been sent to this user",
+		"error.expense.delete_forbidden":       "Only the group owner or the payer can delete this expense",
+		"error.expense.restore_forbidden":      "Only the group owner or the payer can restore this expense",
+		"error.expense.share_required":         "Participant amount cannot be empty",
+		"error.expense.payer_not_participant":  "The payer must be one of the participants",
+		"error.expense.participant_not_member": "All participants must be group members",
+	},
+}
+
+var placeholderPattern = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// T anahtarı verilen dilde çözer, bulunamazsa varsayılan dile düşer
+func T(locale, key string, params map[string]string) string {
+	msg, ok := messages[normalizeLocale(locale)][key]
+	if !ok {
+		msg, ok = messages[defaultLocale][key]
+		if !ok {
+			return key
+		}
+	}
+	if len(params) == 0 {
+		return msg
+	}
+
+	pairs := make([]string, 0, len(params)*2)
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// normalizeLocale "en-US" gibi değerleri desteklenen dillerden birine indirger
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	for _, l := range supportedLocales {
+		if l == locale {
+			return l
+		}
+	}
+	return defaultLocale
+}
+
+func isSupportedLocale(locale string) bool {
+	for _, l := range supportedLocales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// localeFromAcceptLanguage Accept-Language başlığındaki en yüksek öncelikli desteklenen dili seçer
+func localeFromAcceptLanguage(header string) string {
+	best, bestQ := defaultLocale, -1.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.IndexAny(tag, "-_"); i >= 0 {
+			tag = tag[:i]
+		}
+		if !isSupportedLocale(tag) {
+			continue
+		}
+
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(strings.TrimPrefix(f, "q="), 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
+
+// requestLocale önce kullanıcının kayıtlı dilini, yoksa Accept-Language başlığını kullanır
+func requestLocale(r *http.Request) string {
+	if locale, ok := r.Context().Value("locale").(string); ok && locale != "" {
+		return normalizeLocale(locale)
+	}
+	return localeFromAcceptLanguage(r.Header.Get("Accept-Language"))
+}
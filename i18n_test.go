@@ -0,0 +1,104 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestMessageCatalog her anahtarın her dilde ve aynı yer tutucularla tanımlı olduğunu, kodda da
+// kullanıldığını doğrular
+func TestMessageCatalog(t *testing.T) {
+	keys := map[string]bool{}
+	for _, catalog := range messages {
+		for key := range catalog {
+			keys[key] = true
+		}
+	}
+
+	for _, locale := range supportedLocales {
+		catalog, ok := messages[locale]
+		if !ok {
+			t.Errorf("%s: katalog yok", locale)
+			continue
+		}
+		for key := range keys {
+			msg, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: %s eksik", locale, key)
+				continue
+			}
+			if ref, ok := messages[defaultLocale][key]; ok && !samePlaceholders(ref, msg) {
+				t.Errorf("%s: %s yer tutucuları uyuşmuyor", locale, key)
+			}
+		}
+	}
+
+	literals, prefixes := sourceStringLiterals(t)
+	for key := range keys {
+		if !messageKeyUsed(key, literals, prefixes) {
+			t.Errorf("%s kodda kullanılmıyor", key)
+		}
+	}
+}
+
+// sourceStringLiterals paketin test dışı dosyalarındaki (katalog hariç) string sabitlerini ve
+// "validation."+kural gibi birleştirmelerde önek olarak kullanılan sabitleri döner
+func sourceStringLiterals(t *testing.T) (literals, prefixes map[string]bool) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	literals, prefixes = map[string]bool{}, map[string]bool{}
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if name == "i18n.go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.BasicLit:
+				if v, err := strconv.Unquote(n.Value); err == nil && n.Kind == token.STRING {
+					literals[v] = true
+				}
+			case *ast.BinaryExpr:
+				if lit, ok := n.X.(*ast.BasicLit); ok && n.Op == token.ADD && lit.Kind == token.STRING {
+					if v, err := strconv.Unquote(lit.Value); err == nil {
+						prefixes[v] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	return literals, prefixes
+}
+
+func messageKeyUsed(key string, literals, prefixes map[string]bool) bool {
+	if literals[key] {
+		return true
+	}
+	for prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func samePlaceholders(a, b string) bool {
+	pa := placeholderPattern.FindAllString(a, -1)
+	pb := placeholderPattern.FindAllString(b, -1)
+	sort.Strings(pa)
+	sort.Strings(pb)
+	return strings.Join(pa, ",") == strings.Join(pb, ",")
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// İstemci POST, PATCH ve DELETE isteklerine Idempotency-Key başlığı ekleyebilir. İlk isteğin yanıtı
+// kullanıcı ve anahtar için saklanır; bağlantı koptuğu için aynı istek tekrar gönderilirse handler
+// çalışmadan kayıtlı yanıt Idempotent-Replayed başlığıyla döner. Aynı anahtar farklı bir istekle
+// (yol ya da gövde) kullanılırsa 422, ilk istek hâlâ işleniyorsa 409 döner. Sunucu hataları (5xx)
+// ve 429 saklanmaz, bu isteklerin tekrarı yeniden işlenir.
+
+const (
+	// idempotencyLockTimeout içinde yanıtı kaydedilmeyen istek yarıda kalmış sayılır
+	idempotencyLockTimeout = 2 * time.Minute
+	// maxIdempotentRequestBytes anahtarlı isteklerde özeti çıkarılabilecek gövde sınırıdır (fotoğraf yükleme dahil)
+	maxIdempotentRequestBytes = 16 << 20
+	// maxIdempotentResponseBytes'tan büyük yanıtlar saklanmaz, anahtar bırakılır
+	maxIdempotentResponseBytes = 4 << 20
+)
+
+var idempotencyKeyPattern = regexp.MustCompile(`^[\x21-\x7E]{1,255}$`)
+
+// IdempotentResponse bir anahtar için saklanan yanıttır; StatusCode 0 ise istek hâlâ işleniyordur
+type IdempotentResponse struct {
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// idempotencyKeyTTL yanıtların saklanma süresidir, IDEMPOTENCY_KEY_TTL_HOURS ile değiştirilir
+func idempotencyKeyTTL() time.Duration {
+	return envDuration("IDEMPOTENCY_KEY_TTL_HOURS", time.Hour, 24*time.Hour)
+}
+
+// IdempotencyMiddleware AuthMiddleware'den sonra çalışmalıdır, anahtarlar kullanıcı başınadır
+func IdempotencyMiddleware(next http.Handler, store IdempotencyStore, ttl time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch && r.Method != http.MethodDelete) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !idempotencyKeyPattern.MatchString(key) {
+			httpError(w, r, "error.idempotency.invalid_key", http.StatusBadRequest, nil)
+			return
+		}
+		userID, ok := r.Context().Value("userUID").(string)
+		if !ok || userID == "" {
+			httpError(w, r, "error.unauthorized_no_uid", http.StatusUnauthorized, nil)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				httpError(w, r, "error.body_too_large", http.StatusRequestEntityTooLarge, map[string]string{"limit": strconv.FormatInt(tooLarge.Limit, 10)})
+				return
+			}
+			httpError(w, r, "error.body_read_failed", http.StatusBadRequest, nil)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestFingerprint(r, body)
+
+		now := time.Now().UTC()
+		existing, err := store.reserveIdempotencyKey(r.Context(), userID, key, hash, now, now.Add(ttl))
+		if err != nil {
+			writeError(w, r, err, "error.server")
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.RequestHash != hash:
+				httpError(w, r, "error.idempotency.key_reused", http.StatusUnprocessableEntity, nil)
+			case existing.StatusCode == 0:
+				w.Header().Set("Retry-After", "1")
+				httpError(w, r, "error.idempotency.in_progress", http.StatusConflict, nil)
+			default:
+				if existing.ContentType != "" {
+					w.Header().Set("Content-Type", existing.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(existing.StatusCode)
+				w.Write(existing.Body)
+			}
+			return
+		}
+
+		// İstemcinin bağlantısı kopsa da yanıt kaydedilmeli, tekrar deneme zaten bu durum için
+		ctx := context.WithoutCancel(r.Context())
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+			if err := store.releaseIdempotencyKey(ctx, userID, key); err != nil {
+				log.Printf("[%s] Idempotency anahtarı bırakılamadı: %v", requestIDFrom(r), err)
+			}
+		}()
+
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= 500 || status == http.StatusTooManyRequests || rec.overflow {
+			return
+		}
+		err = store.saveIdempotentResponse(ctx, userID, key, &IdempotentResponse{
+			RequestHash: hash,
+			StatusCode:  status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("[%s] Idempotent yanıt kaydedilemedi: %v", requestIDFrom(r), err)
+			return
+		}
+		saved = true
+	})
+}
+
+// requestFingerprint aynı anahtarla gelen isteğin ilkiyle aynı olup olmadığını anlamak içindir
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.RequestURI())
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyRecorder yanıtı istemciye yazarken saklamak için kopyalar
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if !rec.overflow {
+		if rec.body.Len()+len(p) > maxIdempotentResponseBytes {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(p)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// Unwrap http.ResponseController'ın alttaki yazıcıya ulaşması içindir
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// reserveIdempotencyKey anahtarı ilk istek için ayırır; anahtar zaten varsa kayıtlı yanıtı döner
+func (repo *KasaRepository) reserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, now, expiresAt time.Time) (*IdempotentResponse, error) {
+	// SQLite zamanı metin olarak karşılaştırır, tüm zamanlar UTC yazılır
+	now, expiresAt = now.UTC(), expiresAt.UTC()
+	_, err := repo.DB.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = ? AND idempotency_key = ?
+		  AND (expires_at <= ? OR (status_code IS NULL AND created_at <= ?))
+	`, userID, key, now, now.Add(-idempotencyLockTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("eski idempotency kaydı silinemedi: %w", err)
+	}
+
+	res, err := repo.DB.ExecContext(ctx, repo.dialect.insertIgnore()+` INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, userID, key, requestHash, now, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("idempotency anahtarı ayrılamadı: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 1 {
+		return nil, nil
+	}
+
+	var existing IdempotentResponse
+	var status sql.NullInt64
+	var contentType sql.NullString
+	err = repo.DB.QueryRowContext(ctx, `
+		SELECT request_hash, status_code, content_type, response_body
+		FROM idempotency_keys
+		WHERE user_id = ? AND idempotency_key = ?
+	`, userID, key).Scan(&existing.RequestHash, &status, &contentType, &existing.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		// İlk istek bu arada bırakıldı; istemci tekrar denediğinde anahtar boş olur
+		return &IdempotentResponse{RequestHash: requestHash}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("idempotency kaydı okunamadı: %w", err)
+	}
+	existing.StatusCode = int(status.Int64)
+	existing.ContentType = contentType.String
+	return &existing, nil
+}
+
+func (repo *KasaRepository) saveIdempotentResponse(ctx context.Context, userID, key string, resp *IdempotentResponse) error {
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = ?, content_type = ?, response_body = ?
+		WHERE user_id = ? AND idempotency_key = ? AND request_hash = ?
+	`, resp.StatusCode, resp.ContentType, resp.Body, userID, key, resp.RequestHash)
+	return err
+}
+
+func (repo *KasaRepository) releaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	_, err := repo.DB.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = ? AND idempotency_key = ? AND status_code IS NULL
+	`, userID, key)
+	return err
+}
+
+// purgeExpiredIdempotencyKeys süresi dolan kayıtları siler, silinen kayıt sayısını döner
+func (repo *KasaRepository) purgeExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	res, err := repo.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("idempotency kayıtları silinemedi: %w", err)
+	}
+	return res.RowsAffected()
+}
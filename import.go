@@ -0,0 +1,614 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	importSourceSplitwise = "splitwise"
+	importSourceGeneric   = "generic"
+
+	maxImportRows = 2000
+)
+
+// ImportMapping genel CSV'de hangi sütunun neye karşılık geldiğini tanımlar.
+// Katılımcı hücresi "Ali; Veli:12.50" biçimindedir; tutarı verilmeyenler kalan tutarı eşit paylaşır.
+type ImportMapping struct {
+	Date         string `json:"date"`
+	Title        string `json:"title"`
+	Amount       string `json:"amount"`
+	Payer        string `json:"payer"`
+	Participants string `json:"participants"`
+	Note         string `json:"note"`
+	Currency     string `json:"currency"`
+
+	DateFormat   string `json:"date_format"`           // Go zaman biçimi, varsayılan 2006-01-02
+	Separator    string `json:"participant_separator"` // varsayılan ;
+	Delimiter    string `json:"delimiter"`             // varsayılan ,
+	DecimalComma bool   `json:"decimal_comma"`         // 1.234,56 biçimindeki tutarlar
+}
+
+type ImportIssue struct {
+	Row     int    `json:"row"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	params map[string]string
+}
+
+func newImportIssue(row int, code string, params map[string]string) ImportIssue {
+	return ImportIssue{Row: row, Code: code, params: params}
+}
+
+// importPerson dosyadaki bir isim ve eşleştiği grup üyesi. UserID boşsa geçici üye oluşturulacaktır.
+type importPerson struct {
+	Name   string
+	UserID string
+}
+
+type importShare struct {
+	Person importPerson
+	Amount float64
+}
+
+type importedExpense struct {
+	Row      int
+	Date     int64
+	Title    string
+	Note     string
+	Currency string
+	Amount   float64
+	Payer    importPerson
+	Shares   []importShare
+}
+
+type ImportPersonReport struct {
+	Name        string `json:"name"`
+	UserID      string `json:"user_id,omitempty"`
+	MatchedBy   string `json:"matched_by"` // member_name, member_email, mapping, placeholder
+	Placeholder bool   `json:"placeholder"`
+}
+
+type ImportExpensePreview struct {
+	Row    int                `json:"row"`
+	Date   int64              `json:"date"`
+	Title  string             `json:"title"`
+	Amount float64            `json:"amount"`
+	Payer  string             `json:"payer"`
+	Shares map[string]float64 `json:"shares"`
+}
+
+type ImportReport struct {
+	Source              string                 `json:"source"`
+	DryRun              bool                   `json:"dry_run"`
+	Currency            string                 `json:"currency"`
+	RowsRead            int                    `json:"rows_read"`
+	ValidExpenses       int                    `json:"valid_expenses"`
+	TotalAmount         float64                `json:"total_amount"`
+	Errors              []ImportIssue          `json:"errors"`
+	Warnings            []ImportIssue          `json:"warnings"`
+	People              []ImportPersonReport   `json:"people"`
+	Expenses            []ImportExpensePreview `json:"expenses"`
+	Committed           bool                   `json:"committed"`
+	CreatedExpenseIDs   []int64                `json:"created_expense_ids,omitempty"`
+	CreatedPlaceholders map[string]string      `json:"created_placeholders,omitempty"`
+}
+
+// localize sorunları isteğin dilinde doldurur
+func (report *ImportReport) localize(locale string) {
+	for i := range report.Errors {
+		report.Errors[i].Message = T(locale, report.Errors[i].Code, report.Errors[i].params)
+	}
+	for i := range report.Warnings {
+		report.Warnings[i].Message = T(locale, report.Warnings[i].Code, report.Warnings[i].params)
+	}
+}
+
+// rawImportRow kaynak biçimden bağımsız, isimleri henüz çözülmemiş bir harcama
+type rawImportRow struct {
+	row      int
+	date     int64
+	title    string
+	note     string
+	currency string
+	amount   int64 // kuruş
+	payer    string
+	shares   []rawImportShare
+}
+
+type rawImportShare struct {
+	name  string
+	cents int64
+}
+
+type importParseResult struct {
+	rows     []rawImportRow
+	rowsRead int
+	errors   []ImportIssue
+	warnings []ImportIssue
+}
+
+var errTooManyImportRows = errors.New("çok fazla satır")
+
+func newImportCSVReader(r io.Reader, delimiter string) *csv.Reader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+	if d := []rune(delimiter); len(d) == 1 {
+		cr.Comma = d[0]
+	}
+	return cr
+}
+
+func isBlankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseImportAmount tutarı kuruşa çevirir
+func parseImportAmount(value string, decimalComma bool) (int64, bool) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	if decimalComma {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return int64(math.Round(f * 100)), true
+}
+
+// readImportRecords başlığı ve veri satırlarını okur; satır numaraları dosyadaki 1 tabanlı satırlardır
+func readImportRecords(cr *csv.Reader) ([]string, [][]string, []int, error) {
+	var header []string
+	var records [][]string
+	var lines []int
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		if header == nil {
+			for i := range record {
+				record[i] = strings.TrimSpace(strings.TrimPrefix(record[i], "\ufeff"))
+			}
+			header = record
+			continue
+		}
+		if len(records) >= maxImportRows {
+			return nil, nil, nil, errTooManyImportRows
+		}
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	return header, records, lines, nil
+}
+
+func parseImportError(err error) ImportIssue {
+	if err == errTooManyImportRows {
+		return newImportIssue(0, "import.issue.too_many_rows", map[string]string{"max": strconv.Itoa(maxImportRows)})
+	}
+	return newImportIssue(0, "import.issue.parse_failed", map[string]string{"error": err.Error()})
+}
+
+func field(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// parseSplitwiseCSV Splitwise'ın "Date, Description, Category, Cost, Currency, <kişiler...>" dışa aktarmasını okur.
+// Kişi sütunları o harcamadaki net bakiyedir: ödeyen için tutar eksi kendi payı, diğerleri için eksi payları.
+func parseSplitwiseCSV(r io.Reader) importParseResult {
+	var result importParseResult
+
+	header, records, lines, err := readImportRecords(newImportCSVReader(r, ","))
+	if err != nil {
+		result.errors = append(result.errors, parseImportError(err))
+		return result
+	}
+	expected := []string{"date", "description", "category", "cost", "currency"}
+	if len(header) <= len(expected) {
+		result.errors = append(result.errors, newImportIssue(1, "import.issue.not_splitwise", nil))
+		return result
+	}
+	for i, name := range expected {
+		if !strings.EqualFold(header[i], name) {
+			result.errors = append(result.errors, newImportIssue(1, "import.issue.not_splitwise", nil))
+			return result
+		}
+	}
+	people := header[len(expected):]
+
+	for i, record := range records {
+		row := lines[i]
+		title := field(record, 1)
+
+		// Son satırdaki toplam bakiye bir harcama değildir
+		if strings.EqualFold(title, "Total balance") && field(record, 0) == "" {
+			continue
+		}
+		result.rowsRead++
+
+		if strings.EqualFold(field(record, 2), "Payment") {
+			result.warnings = append(result.warnings, newImportIssue(row, "import.issue.payment_skipped", map[string]string{"title": title}))
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", field(record, 0))
+		if err != nil {
+			result.errors = append(result.errors, newImportIssue(row, "import.issue.invalid_date", map[string]string{"value": field(record, 0)}))
+			continue
+		}
+		cost, ok := parseImportAmount(field(record, 3), false)
+		if !ok || cost <= 0 {
+			result.errors = append(result.errors, newImportIssue(row, "import.issue.invalid_amount", map[string]string{"value": field(record, 3)}))
+			continue
+		}
+
+		var payers []rawImportShare
+		var shares []rawImportShare
+		var owed int64
+		valid := true
+		for j, name := range people {
+			value := field(record, len(expected)+j)
+			if value == "" {
+				continue
+			}
+			cents, ok := parseImportAmount(value, false)
+			if !ok {
+				result.errors = append(result.errors, newImportIssue(row, "import.issue.invalid_amount", map[string]string{"value": value}))
+				valid = false
+				break
+			}
+			switch {
+			case cents > 0:
+				payers = append(payers, rawImportShare{name, cents})
+			case cents < 0:
+				shares = append(shares, rawImportShare{name, -cents})
+				owed -= cents
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		switch {
+		case len(payers) == 0:
+			// Kişi kendi harcamasını girmiş ya da kimse borçlanmamış; bakiyeye etkisi yok
+			result.warnings = append(result.warnings, newImportIssue(row, "import.issue.no_payer", map[string]string{"title": title}))
+			continue
+		case len(payers) > 1:
+			result.errors = append(result.errors, newImportIssue(row, "import.issue.multiple_payers", nil))
+			continue
+		}
+
+		payer := payers[0]
+		if payer.cents != owed {
+			result.errors = append(result.errors, newImportIssue(row, "import.issue.share_mismatch", map[string]string{
+				"total": formatCents(owed), "amount": formatCents(payer.cents),
+			}))
+			continue
+		}
+
+		// Ödeyenin kendi payı = tutar - diğerlerinin payları
+		payerShare := cost - owed
+		if payerShare < 0 {
+			result.errors = append(result.errors, newImportIssue(row, "import.issue.share_mismatch", map[string]string{
+				"total": formatCents(owed), "amount": formatCents(cost),
+			}))
+			continue
+		}
+		if payerShare > 0 {
+			shares = append([]rawImportShare{{payer.name, payerShare}}, shares...)
+		}
+
+		result.rows = append(result.rows, rawImportRow{
+			row:      row,
+			date:     date.Unix(),
+			title:    title,
+			note:     field(record, 2),
+			currency: strings.ToUpper(field(record, 4)),
+			amount:   cost,
+			payer:    payer.name,
+			shares:   shares,
+		})
+	}
+	return result
+}
+
+// parseGenericCSV eşleme tanımına göre sütunları okur
+func parseGenericCSV(r io.Reader, mapping ImportMapping) importParseResult {
+	var result importParseResult
+
+	header, records, lines, err := readImportRecords(newImportCSVReader(r, mapping.Delimiter))
+	if err != nil {
+		result.errors = append(result.errors, parseImportError(err))
+		return result
+	}
+
+	index := func(column string) int {
+		for i, h := range header {
+			if strings.EqualFold(h, strings.TrimSpace(column)) {
+				return i
+			}
+		}
+		return -1
+	}
+	columns := map[string]int{}
+	for _, c := range []struct{ key, name string }{
+		{"date", mapping.Date}, {"title", mapping.Title}, {"amount", mapping.Amount},
+		{"payer", mapping.Payer}, {"participants", mapping.Participants},
+	} {
+		columns[c.key] = index(c.name)
+		if columns[c.key] < 0 {
+			result.errors = append(result.errors, newImportIssue(1, "import.issue.column_missing", map[string]string{"column": c.name}))
+		}
+	}
+	if len(result.errors) > 0 {
+		return result
+	}
+	noteCol, currencyCol := -1, -1
+	if mapping.Note != "" {
+		noteCol = index(mapping.Note)
+	}
+	if mapping.Currency != "" {
+		currencyCol = index(mapping.Currency)
+	}
+
+	dateFormat := mapping.DateFormat
+	if dateFormat == "" {
+		dateFormat = "2006-01-02"
+	}
+	separator := mapping.Separator
+	if separator == "" {
+		separator = ";"
+	}
+
+	for i, record := range records {
+		row := lines[i]
+		result.rowsRead++
+
+		date, err := time.Parse(dateFormat, field(record, columns["date"]))
+		if err != nil {
+			result.errors = append(result.errors, newImportIssue(row, "import.issue.invalid_date", map[string]string{"value": field(record, columns["date"])}))
+			continue
+		}
+		title := field(record, columns["title"])
+		if title == "" {
+			result.errors = append(result.errors, newImportIssue(row, "import.issue.title_required", nil))
+			continue
+		}
+		amount, ok := parseImportAmount(field(record, columns["amount"]), mapping.DecimalComma)
+		if !ok || amount <= 0 {
+			result.errors = append(result.errors, newImportIssue(row, "import.issue.invalid_amount", map[string]string{"value": field(record, columns["amount"])}))
+			continue
+		}
+		payer := field(record, columns["payer"])
+		if payer == "" {
+			result.errors = append(result.errors, newImportIssue(row, "import.issue.payer_required", nil))
+			continue
+		}
+
+		shares, issue := splitImportParticipants(row, field(record, columns["participants"]), separator, amount, mapping.DecimalComma)
+		if issue != nil {
+			result.errors = append(result.errors, *issue)
+			continue
+		}
+
+		result.rows = append(result.rows, rawImportRow{
+			row:      row,
+			date:     date.Unix(),
+			title:    title,
+			note:     field(record, noteCol),
+			currency: strings.ToUpper(field(record, currencyCol)),
+			amount:   amount,
+			payer:    payer,
+			shares:   shares,
+		})
+	}
+	return result
+}
+
+// splitImportParticipants "Ali; Veli:12.50" hücresini paylara çevirir. Tutarı yazılmayanlar
+// kalanı eşit paylaşır; bölünemeyen kuruşlar ilk katılımcılara dağıtılır.
+func splitImportParticipants(row int, cell, separator string, amount int64, decimalComma bool) ([]rawImportShare, *ImportIssue) {
+	var shares []rawImportShare
+	var equal []int
+	var fixed int64
+	for _, part := range strings.Split(cell, separator) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, hasAmount := strings.Cut(part, ":")
+		name = strings.TrimSpace(name)
+		if !hasAmount {
+			equal = append(equal, len(shares))
+			shares = append(shares, rawImportShare{name: name})
+			continue
+		}
+		cents, ok := parseImportAmount(value, decimalComma)
+		if !ok || cents < 0 {
+			issue := newImportIssue(row, "import.issue.invalid_amount", map[string]string{"value": value})
+			return nil, &issue
+		}
+		fixed += cents
+		shares = append(shares, rawImportShare{name: name, cents: cents})
+	}
+	if len(shares) == 0 {
+		issue := newImportIssue(row, "import.issue.participants_required", nil)
+		return nil, &issue
+	}
+
+	remaining := amount - fixed
+	if remaining < 0 || (len(equal) == 0 && remaining != 0) {
+		issue := newImportIssue(row, "import.issue.share_mismatch", map[string]string{
+			"total": formatCents(fixed), "amount": formatCents(amount),
+		})
+		return nil, &issue
+	}
+	if len(equal) > 0 {
+		each := remaining / int64(len(equal))
+		extra := remaining % int64(len(equal))
+		for k, idx := range equal {
+			shares[idx].cents = each
+			if int64(k) < extra {
+				shares[idx].cents++
+			}
+		}
+	}
+	return shares, nil
+}
+
+func formatCents(c int64) string {
+	return strconv.FormatFloat(fromCents(c), 'f', 2, 64)
+}
+
+// importNameKey isimleri büyük/küçük harf ve boşluk farkı gözetmeden karşılaştırmak için
+func importNameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// buildExpenseImport dosyadaki isimleri grup üyeleriyle eşleştirir ve raporu hazırlar.
+// people açık eşlemedir (dosyadaki isim -> kullanıcı ID); eşleşmeyen isimler için geçici üye önerilir.
+func buildExpenseImport(source, currency string, parsed importParseResult, members []GroupMemberRef, people map[string]string, allowPlaceholders bool) (*ImportReport, []importedExpense, []string) {
+	report := &ImportReport{
+		Source:   source,
+		Currency: currency,
+		RowsRead: parsed.rowsRead,
+		Errors:   append([]ImportIssue{}, parsed.errors...),
+		Warnings: append([]ImportIssue{}, parsed.warnings...),
+		People:   []ImportPersonReport{},
+		Expenses: []ImportExpensePreview{},
+	}
+
+	memberIDs := map[string]bool{}
+	byName := map[string][]GroupMemberRef{}
+	byEmail := map[string]GroupMemberRef{}
+	for _, m := range members {
+		memberIDs[m.ID] = true
+		byName[importNameKey(m.FullName)] = append(byName[importNameKey(m.FullName)], m)
+		byEmail[strings.ToLower(m.Email)] = m
+	}
+	mapped := map[string]string{}
+	for name, id := range people {
+		mapped[importNameKey(name)] = id
+	}
+
+	resolved := map[string]*importPerson{}
+	failed := map[string]bool{}
+	var placeholders []string
+	resolve := func(row int, name string) (importPerson, bool) {
+		key := importNameKey(name)
+		if p, ok := resolved[key]; ok {
+			return *p, true
+		}
+		if failed[key] {
+			return importPerson{}, false
+		}
+
+		fail := func(code string) (importPerson, bool) {
+			failed[key] = true
+			report.Errors = append(report.Errors, newImportIssue(row, code, map[string]string{"name": name}))
+			return importPerson{}, false
+		}
+		person := importPerson{Name: name}
+		entry := ImportPersonReport{Name: name}
+
+		if id, ok := mapped[key]; ok {
+			if !memberIDs[id] {
+				return fail("import.issue.unknown_mapping")
+			}
+			person.UserID, entry.UserID, entry.MatchedBy = id, id, "mapping"
+		} else if m, ok := byEmail[strings.ToLower(strings.TrimSpace(name))]; ok {
+			person.UserID, entry.UserID, entry.MatchedBy = m.ID, m.ID, "member_email"
+		} else if candidates := byName[key]; len(candidates) == 1 {
+			person.UserID, entry.UserID, entry.MatchedBy = candidates[0].ID, candidates[0].ID, "member_name"
+		} else if len(candidates) > 1 {
+			return fail("import.issue.ambiguous_person")
+		} else if !allowPlaceholders {
+			return fail("import.issue.unknown_person")
+		} else {
+			entry.MatchedBy, entry.Placeholder = "placeholder", true
+			placeholders = append(placeholders, name)
+		}
+
+		resolved[key] = &person
+		report.People = append(report.People, entry)
+		return person, true
+	}
+
+	var expenses []importedExpense
+	var total int64
+	for _, raw := range parsed.rows {
+		if raw.currency != "" && raw.currency != currency {
+			report.Errors = append(report.Errors, newImportIssue(raw.row, "import.issue.currency_mismatch", map[string]string{
+				"currency": raw.currency, "expected": currency,
+			}))
+			continue
+		}
+
+		payer, ok := resolve(raw.row, raw.payer)
+		valid := ok
+		preview := ImportExpensePreview{
+			Row: raw.row, Date: raw.date, Title: raw.title, Amount: fromCents(raw.amount), Payer: raw.payer,
+			Shares: map[string]float64{},
+		}
+		expense := importedExpense{
+			Row: raw.row, Date: raw.date, Title: raw.title, Note: raw.note, Currency: raw.currency,
+			Amount: fromCents(raw.amount), Payer: payer,
+		}
+		for _, s := range raw.shares {
+			person, ok := resolve(raw.row, s.name)
+			if !ok {
+				valid = false
+				continue
+			}
+			expense.Shares = append(expense.Shares, importShare{Person: person, Amount: fromCents(s.cents)})
+			preview.Shares[s.name] += fromCents(s.cents)
+		}
+		if !valid {
+			continue
+		}
+
+		expenses = append(expenses, expense)
+		report.Expenses = append(report.Expenses, preview)
+		total += raw.amount
+	}
+
+	report.ValidExpenses = len(expenses)
+	report.TotalAmount = fromCents(total)
+	return report, expenses, placeholders
+}
+
+func describeImportSource(source string) (string, error) {
+	switch strings.ToLower(source) {
+	case "", importSourceSplitwise:
+		return importSourceSplitwise, nil
+	case importSourceGeneric:
+		return importSourceGeneric, nil
+	}
+	return "", fmt.Errorf("bilinmeyen kaynak: %s", source)
+}
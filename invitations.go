@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// sendInvitationEmail hesabı olmayan kişiye davet email'i gönderir. Alıcının dili
+// bilinmediği için davet edenin dili kullanılır.
+func sendInvitationEmail(invitation *EmailInvitation, locale string) {
+	if AppMailer == nil {
+		log.Println("Email gönderici tanımlı değil, davet email'i gönderilmedi")
+		return
+	}
+
+	params := map[string]string{
+		"name":  invitation.InviterName,
+		"group": invitation.GroupName,
+		"email": invitation.Email,
+		"url":   publicURL("/"),
+	}
+	msg := MailMessage{
+		To:      invitation.Email,
+		Subject: T(locale, "mail.invitation.subject", params),
+		Body:    T(locale, "mail.invitation.body", params),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := AppMailer.Send(ctx, msg); err != nil {
+		log.Printf("Davet email'i gönderilemedi (invitation_id=%d): %v", invitation.InvitationID, err)
+	}
+}
+
+// convertInvitationsForNewUser kayıt olan kullanıcıya email ile gönderilmiş davetleri
+// grup ekleme isteğine çevirir ve her istek için bildirim gönderir. Hatalar kaydı engellemez.
+func convertInvitationsForNewUser(ctx context.Context, repo Repository, userID, email string) {
+	converted, err := repo.convertEmailInvitations(ctx, userID, email)
+	if err != nil {
+		log.Printf("Email davetleri isteğe çevrilemedi (user_id=%s): %v", userID, err)
+		return
+	}
+
+	for _, inv := range converted {
+		params := map[string]string{
+			"name":  inv.InviterName,
+			"group": inv.GroupName,
+		}
+		data := map[string]string{
+			"type": "new_request",
+		}
+		if err := SendNotification(ctx, repo, userID, "notification.add_request.title", "notification.add_request.body", params, data); err != nil {
+			log.Printf("Bildirim gönderilemedi: %v", err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// runPeriodically fn'i verilen aralıkla context iptal edilene kadar çalıştırır.
+// İlk çalıştırma hemen yapılır, hatalar loglanır ve iş durmaz.
+func runPeriodically(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	log.Printf("⏱️ %s işi başlatıldı (aralık: %s)", name, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(ctx); err != nil {
+			log.Printf("❌ %s işi hata verdi: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("%s işi durduruldu", name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// envDuration ortam değişkenindeki tam sayıyı verilen birimle süreye çevirir
+func envDuration(key string, unit time.Duration, fallback time.Duration) time.Duration {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return fallback
+	}
+	return time.Duration(v) * unit
+}
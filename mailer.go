@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MailMessage düz metin olarak gönderilen bir email'dir
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer email gönderimini soyutlar; üretimde SMTP, geliştirmede dosya ya da bellek kullanılır
+type Mailer interface {
+	Send(ctx context.Context, msg MailMessage) error
+}
+
+var AppMailer Mailer
+
+// newMailerFromEnv MAILER değişkenine göre (smtp, file, memory) göndericiyi seçer.
+// MAILER boşsa SMTP kullanılır. Email göndermeyen file ve memory göndericileri sadece
+// APP_ENV=development iken seçilebilir; aksi halde davetler sessizce kaybolurdu.
+// Geliştirmede MAILER ve SMTP_HOST boşsa ./mails klasörüne yazan dosya gönderici kullanılır.
+func newMailerFromEnv() (Mailer, error) {
+	kind := strings.ToLower(os.Getenv("MAILER"))
+	if kind == "" {
+		kind = "smtp"
+		if os.Getenv("SMTP_HOST") == "" && isDevelopment() {
+			kind = "file"
+		}
+	}
+	if (kind == "file" || kind == "memory") && !isDevelopment() {
+		return nil, fmt.Errorf("MAILER=%s sadece APP_ENV=development iken kullanılabilir", kind)
+	}
+
+	switch kind {
+	case "smtp":
+		m := &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}
+		if m.Host == "" || m.From == "" {
+			return nil, fmt.Errorf("SMTP_HOST ve MAIL_FROM tanımlı olmalı (geliştirmede APP_ENV=development ile dosya gönderici kullanılabilir)")
+		}
+		if m.Port == "" {
+			m.Port = "587"
+		}
+		return m, nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "./mails"
+		}
+		log.Printf("⚠️ Email'ler gönderilmiyor, %s klasörüne yazılıyor (MAILER=file)", dir)
+		return &FileMailer{Dir: dir, From: mailFromOrDefault()}, nil
+	case "memory":
+		return &MemoryMailer{}, nil
+	}
+	return nil, fmt.Errorf("bilinmeyen MAILER: %s", kind)
+}
+
+func mailFromOrDefault() string {
+	if from := os.Getenv("MAIL_FROM"); from != "" {
+		return from
+	}
+	return "Kasa <no-reply@kasa.local>"
+}
+
+// buildMailMessage başlıkları UTF-8 olarak kodlanmış RFC 5322 mesajı üretir
+func buildMailMessage(from string, msg MailMessage) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// smtpTimeout bağlantı, TLS, kimlik doğrulama ve veri aktarımı dahil tek gönderimin üst sınırıdır
+const smtpTimeout = 30 * time.Second
+
+func (m *SMTPMailer) Send(ctx context.Context, msg MailMessage) error {
+	dialer := net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.Host, m.Port))
+	if err != nil {
+		return fmt.Errorf("SMTP sunucusuna bağlanılamadı: %w", err)
+	}
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+	// İstek iptal edilirse bekleyen okuma/yazma hemen sonlansın
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP oturumu açılamadı: %w", err)
+	}
+	defer c.Close()
+
+	if err := m.deliver(c, msg); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("email gönderilemedi: %w", err)
+	}
+	return nil
+}
+
+// deliver smtp.SendMail'in adımlarını açık bağlantı üzerinde uygular
+func (m *SMTPMailer) deliver(c *smtp.Client, msg MailMessage) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.Host}); err != nil {
+			return err
+		}
+	}
+	if m.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("SMTP sunucusu kimlik doğrulamayı desteklemiyor")
+		}
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return err
+		}
+	}
+
+	from := m.From
+	if addr, err := mailAddress(m.From); err == nil {
+		from = addr
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMailMessage(m.From, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// mailAddress "Ad <adres>" biçimindeki göndericiden SMTP zarfı için sadece adresi alır
+func mailAddress(from string) (string, error) {
+	start, end := strings.LastIndex(from, "<"), strings.LastIndex(from, ">")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("adres bulunamadı")
+	}
+	return from[start+1 : end], nil
+}
+
+// FileMailer email'leri göndermek yerine klasöre .eml dosyası olarak yazar
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._@-]`)
+
+func (m *FileMailer) Send(ctx context.Context, msg MailMessage) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("email klasörü oluşturulamadı: %w", err)
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, buildMailMessage(m.From, msg), 0o644); err != nil {
+		return fmt.Errorf("email dosyası yazılamadı: %w", err)
+	}
+	log.Printf("📧 Email dosyaya yazıldı: %s", path)
+	return nil
+}
+
+// MemoryMailer gönderilen email'leri bellekte tutar
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []MailMessage
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg MailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *MemoryMailer) Messages() []MailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MailMessage(nil), m.messages...)
+}
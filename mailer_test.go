@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestSMTPMailerHonoursContext selamlamayı hiç göndermeyen sunucuda Send'in ctx süresiyle bittiğini doğrular
+func TestSMTPMailerHonoursContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	defer func() {
+		select {
+		case conn := <-accepted:
+			conn.Close()
+		default:
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	m := &SMTPMailer{Host: host, Port: port, From: "Kasa <no-reply@kasa.local>"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := m.Send(ctx, MailMessage{To: "a@example.com", Subject: "s", Body: "b"}); err == nil {
+		t.Fatal("yanıt vermeyen sunucuda hata bekleniyordu")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Send %v sürdü, ctx süresine uyulmadı", elapsed)
+	}
+}
+
+func TestNewMailerFromEnv(t *testing.T) {
+	cases := []struct {
+		env     map[string]string
+		wantErr bool
+		want    string
+	}{
+		{env: map[string]string{}, wantErr: true},
+		{env: map[string]string{"MAILER": "file"}, wantErr: true},
+		{env: map[string]string{"APP_ENV": "development"}, want: "*main.FileMailer"},
+		{env: map[string]string{"SMTP_HOST": "smtp.example.com", "MAIL_FROM": "a@example.com"}, want: "*main.SMTPMailer"},
+		{env: map[string]string{"APP_ENV": "development", "MAILER": "memory"}, want: "*main.MemoryMailer"},
+	}
+	for _, c := range cases {
+		for _, key := range []string{"APP_ENV", "MAILER", "SMTP_HOST", "MAIL_FROM", "MAIL_DIR"} {
+			t.Setenv(key, c.env[key])
+		}
+		m, err := newMailerFromEnv()
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%v: hata bekleniyordu, %T döndü", c.env, m)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", c.env, err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", m); got != c.want {
+			t.Errorf("%v: %s döndü, beklenen %s", c.env, got, c.want)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"firebase.google.com/go/v4/auth"
 	"firebase.google.com/go/v4/messaging"
@@ -23,45 +25,17 @@ func main() {
 		log.Fatal("❌ .env dosyası yüklenemedi:", err)
 	}
 
-	// Ortam değişkenlerini oku
-	user := os.Getenv("DB_USER")
-	pass := os.Getenv("DB_PASS")
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	dbname := os.Getenv("DB_NAME")
-
-	// DSN oluştur
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, pass, host, port, dbname)
-
-	// Veritabanına bağlan
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		log.Fatal("❌ Veritabanına bağlanılamadı:", err)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrateCommand(os.Args[2:]))
 	}
-	defer db.Close()
 
-	// Bağlantı testi
-	if err := db.Ping(); err != nil {
-		log.Fatal("❌ Veritabanı bağlantısı başarısız:", err)
-	}
-
-	// SQL scriptini oku
-	schema, err := os.ReadFile("./database/schema.sql")
+	// Veritabanına bağlan; yerelde MySQL olmadan DB_DRIVER=sqlite kullanılabilir
+	repo, err := openRepository(context.Background())
 	if err != nil {
-		log.Fatal("❌ schema.sql dosyası okunamadı:", err)
-	}
-
-	// SQL'i çalıştır (tek tek komutları ayırarak güvenli hale getiriyoruz)
-	queries := string(schema)
-	for _, stmt := range splitSQL(queries) {
-		if stmt == "" {
-			continue
-		}
-		if _, err := db.Exec(stmt); err != nil {
-			log.Fatalf("❌ SQL çalıştırma hatası:\n%v\nHata: %v", stmt, err)
-		}
+		log.Fatal("❌ ", err)
 	}
-	fmt.Println("✅ SQL script başarıyla çalıştırıldı.")
+	defer repo.DB.Close()
+	fmt.Println("✅ Veritabanı şeması güncel.")
 	clients, err := connectToFirebase(context.Background())
 	if err != nil {
 		log.Fatal("❌ Firebase bağlantısı başarısız:", err)
@@ -69,101 +43,92 @@ func main() {
 	FirebaseAuth = clients.AuthClient
 	FirebaseMessagingClient = clients.MessagingClient
 
-	// Sunucu başlatma
-	repo := &KasaRepository{DB: db}
+	AppMailer, err = newMailerFromEnv()
+	if err != nil {
+		log.Fatal("❌ Email gönderici başlatılamadı:", err)
+	}
 
-	// HTTP endpointleri
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		fmt.Fprintln(w, `
-        <!DOCTYPE html>
-        <html>
-        <head>
-            <title>Deneme Sayfası</title>
-        </head>
-        <body>
-            <h1>Hoşgeldin!</h1>
-            <p>Bu bir basit HTML sayfasıdır.</p>
-        </body>
-        </html>
-    `)
+	// Arka plan işleri
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go runPeriodically(jobCtx, "Ödeme hatırlatmaları", envDuration("REMINDER_JOB_INTERVAL_MINUTES", time.Minute, time.Hour), func(ctx context.Context) error {
+		return runPaymentReminders(ctx, repo)
 	})
-
-	http.HandleFunc("/v", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintln(w, "Merhaba, test serverı çalışıyor!")
+	go runPeriodically(jobCtx, "Silinen harcamaları temizleme", envDuration("EXPENSE_PURGE_INTERVAL_MINUTES", time.Minute, time.Hour), func(ctx context.Context) error {
+		purged, err := repo.purgeDeletedExpenses(ctx, 500)
+		if purged > 0 {
+			log.Printf("Kalıcı olarak silinen harcama: %d", purged)
+		}
+		return err
+	})
+	go runPeriodically(jobCtx, "Hesap silme", envDuration("ACCOUNT_DELETION_INTERVAL_MINUTES", time.Minute, time.Hour), func(ctx context.Context) error {
+		return processAccountDeletions(ctx, repo)
+	})
+	go runPeriodically(jobCtx, "Veri dışa aktarma", envDuration("DATA_EXPORT_INTERVAL_SECONDS", time.Second, time.Minute), func(ctx context.Context) error {
+		return processDataExports(ctx, repo)
+	})
+	go runPeriodically(jobCtx, "Grup ekleme isteklerinin süresi", envDuration("ADD_REQUEST_EXPIRY_INTERVAL_MINUTES", time.Minute, time.Hour), func(ctx context.Context) error {
+		return expireAddRequests(ctx, repo)
+	})
+	go runPeriodically(jobCtx, "Idempotency kayıtlarını temizleme", envDuration("IDEMPOTENCY_PURGE_INTERVAL_MINUTES", time.Minute, time.Hour), func(ctx context.Context) error {
+		purged, err := repo.purgeExpiredIdempotencyKeys(ctx, time.Now())
+		if purged > 0 {
+			log.Printf("Süresi dolan idempotency kaydı: %d", purged)
+		}
+		return err
 	})
-	http.HandleFunc("/register", RegisterUserHandler(repo))
-
-	http.HandleFunc("/login", LoginUserHandler(repo))
-
-	http.HandleFunc("/login-google", LoginWGoogleHandler(repo))
-
-	http.Handle("/get-me", AuthMiddleware(getMeHandler(repo), repo))
-
-	http.Handle("/update-user", AuthMiddleware(updateUserHandler(repo), repo))
-
-	http.Handle("/create-group", AuthMiddleware(CreateGroupHandler(repo), repo))
-
-	http.Handle("/groups", AuthMiddleware(GetGroups(repo), repo))
-
-	http.Handle("/send-add-group-request", AuthMiddleware(SendAddRequest(repo), repo))
-
-	http.Handle("/get-my-add-requests", AuthMiddleware(handleGetAddRequests(repo), repo))
-
-	http.Handle("/accept-add-request", AuthMiddleware(handleAcceptAddRequest(repo), repo))
-
-	http.Handle("/reject-add-request", AuthMiddleware(handleRejectAddRequest(repo), repo))
-
-	http.Handle("/add-group-expense", AuthMiddleware(handleCreateGroupExpense(repo), repo))
-
-	http.Handle("/pay-group-expense", AuthMiddleware(handlePayGroupExpense(repo), repo))
-
-	http.Handle("/save-fcm-token", AuthMiddleware(handleSaveFCMToken(repo), repo))
-
-	http.Handle("/add-group-token", AuthMiddleware(addGroupWithTokenHandler(repo), repo))
-
-	http.Handle("/delete-expense", AuthMiddleware(handleDeleteExpense(repo), repo))
-
-	http.Handle("/delete-account", AuthMiddleware(handleDeleteAccount(repo), repo))
 
-	fs := http.FileServer(http.Dir("./uploads"))
-	http.Handle("/uploads/", http.StripPrefix("/uploads/", fs))
-	http.Handle("/upload-photo", AuthMiddleware(uploadPhotoHandler(repo), repo))
+	// HTTP endpointleri, bkz. routes.go
+	router := newRouter(repo)
 
 	fmt.Println("🚀 Sunucu 80 portunda başlatıldı...")
-	log.Fatal(http.ListenAndServe("0.0.0.0:8080", nil))
+	log.Fatal(http.ListenAndServe("0.0.0.0:8080", router))
 }
 
-// Basit SQL ayırıcı (noktalı virgülle ayırır)
-func splitSQL(sql string) []string {
-	var result []string
-	queries := ""
-	for _, line := range splitLines(sql) {
-		if line == "" || line[0] == '-' { // yorum satırlarını atla
-			continue
+// openRepository DB_DRIVER'a göre veritabanını açar. MySQL'de bekleyen migration'lar uygulanır;
+// DB_DRIVER=sqlite ise (sadece APP_ENV=development) DB_PATH'teki SQLite dosyası kullanılır.
+func openRepository(ctx context.Context) (*KasaRepository, error) {
+	if useSQLite() {
+		if !isDevelopment() {
+			return nil, fmt.Errorf("DB_DRIVER=sqlite sadece APP_ENV=development iken kullanılabilir")
 		}
-		queries += line + "\n"
-		if line[len(line)-1] == ';' {
-			result = append(result, queries)
-			queries = ""
+		repo, err := openSQLiteRepository(sqlitePath())
+		if err != nil {
+			return nil, err
 		}
+		log.Printf("⚠️ SQLite veritabanı kullanılıyor: %s (DB_DRIVER=sqlite)", sqlitePath())
+		return repo, nil
 	}
-	return result
-}
 
-func splitLines(sql string) []string {
-	var lines []string
-	curr := ""
-	for _, ch := range sql {
-		if ch == '\n' {
-			lines = append(lines, curr)
-			curr = ""
-		} else {
-			curr += string(ch)
-		}
+	db, err := sql.Open("mysql", mysqlDSN())
+	if err != nil {
+		return nil, fmt.Errorf("veritabanına bağlanılamadı: %w", err)
 	}
-	if curr != "" {
-		lines = append(lines, curr)
+	// Bağlantı testi
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("veritabanı bağlantısı başarısız: %w", err)
 	}
-	return lines
+	// Bekleyen şema migration'larını uygula
+	if err := migrateOnStartup(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migration hatası: %w", err)
+	}
+	return &KasaRepository{DB: db}, nil
+}
+
+// mysqlDSN ortam değişkenlerinden bağlantı adresini oluşturur
+func mysqlDSN() string {
+	user := os.Getenv("DB_USER")
+	pass := os.Getenv("DB_PASS")
+	host := os.Getenv("DB_HOST")
+	port := os.Getenv("DB_PORT")
+	dbname := os.Getenv("DB_NAME")
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, pass, host, port, dbname)
+}
+
+// isDevelopment APP_ENV=development ise true döner; dosyaya yazan mailer ve diskten okunan
+// migration'lar gibi sadece geliştirmede kullanılacak ayarlar buna bağlıdır
+func isDevelopment() bool {
+	return strings.EqualFold(os.Getenv("APP_ENV"), "development")
 }
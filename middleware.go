@@ -2,62 +2,88 @@ package main
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"regexp"
 	"strings"
 )
 
 // decodeJWTWithoutValidation fonksiyonunu kendi içinde implemente etmelisin
 // Örneği aşağıda açıklayabilirim istersen.
 
-func AuthMiddleware(next http.Handler, repo *KasaRepository) http.Handler {
+func AuthMiddleware(next http.Handler, repo UserStore) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "Yetkisiz erişim: token eksik", http.StatusUnauthorized)
+			httpError(w, r, "error.auth.token_missing", http.StatusUnauthorized, nil)
 			return
 		}
 
 		jwtToken := strings.TrimPrefix(authHeader, "Bearer ")
 		claims, err := decodeJWTWithoutValidation(jwtToken)
 		if err != nil {
-			http.Error(w, "Geçersiz JWT token", http.StatusUnauthorized)
+			httpError(w, r, "error.auth.invalid_jwt", http.StatusUnauthorized, nil)
 			return
 		}
 
 		uid, ok := claims["uid"].(string)
 		if !ok || uid == "" {
-			http.Error(w, "Geçersiz JWT token: UID eksik", http.StatusUnauthorized)
+			httpError(w, r, "error.auth.invalid_jwt_uid", http.StatusUnauthorized, nil)
 			return
 		}
 
 		email, _ := claims["email"].(string)
 
-		rows, err := repo.GetUserByEmail(email)
+		userID, err := repo.GetUserIDByEmail(email)
 		if err != nil {
-			http.Error(w, "Sunucu hatası", http.StatusInternalServerError)
+			httpError(w, r, "error.server", http.StatusInternalServerError, nil)
 			return
 		}
-		defer rows.Close()
-
-		var userID string
-		if rows.Next() {
-			if err := rows.Scan(&userID); err != nil {
-				http.Error(w, "Sunucu hatası", http.StatusInternalServerError)
-				return
-			}
-			if userID != uid {
-				http.Error(w, "Yetkisiz erişim: UID uyuşmuyor", http.StatusUnauthorized)
-				return
-			}
-		} else {
-			http.Error(w, "Kullanıcı bulunamadı", http.StatusNotFound)
+		if userID == "" {
+			httpError(w, r, "error.user.not_found", http.StatusNotFound, nil)
+			return
+		}
+		if userID != uid {
+			httpError(w, r, "error.auth.uid_mismatch", http.StatusUnauthorized, nil)
 			return
 		}
 
-		// Context'e UID ve email ekle
+		// Mesajlar kullanıcının kayıtlı dilinde dönsün
+		locale, err := repo.GetUserLocale(r.Context(), uid)
+		if err != nil {
+			log.Println("Kullanıcı dili alınamadı:", err)
+		}
+
+		// Context'e UID, email ve dil ekle
 		ctx := context.WithValue(r.Context(), "userUID", uid)
 		ctx = context.WithValue(ctx, "email", email)
+		ctx = context.WithValue(ctx, "locale", locale)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware her isteğe bir ID verir ve X-Request-ID başlığıyla döner; hata yanıtlarındaki
+// request_id ile loglardaki kayıt bulunur. Proxy ya da istemci geçerli bir ID gönderdiyse o kullanılır.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !requestIDPattern.MatchString(id) {
+			var err error
+			if id, err = generateToken(16); err != nil {
+				log.Println("İstek ID'si oluşturulamadı:", err)
+				id = ""
+			}
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "requestID", id)))
+	})
+}
+
+// requestIDFrom RequestIDMiddleware'in verdiği ID'yi döner
+func requestIDFrom(r *http.Request) string {
+	id, _ := r.Context().Value("requestID").(string)
+	return id
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Şema değişiklikleri database/migrations altında NNNN_ad.up.sql / NNNN_ad.down.sql
+// çiftleri olarak tutulur ve binary'ye gömülür. Uygulanan sürümler schema_migrations tablosuna yazılır.
+// MySQL'de DDL transaction içinde geri alınamadığı için her migration önce 'dirty'
+// olarak işaretlenir; yarıda kalan bir migration elle düzeltilene kadar yenileri çalışmaz.
+
+const migrationLockName = "kasa_schema_migrations"
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var migrationNameCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+var errDirtyMigration = errors.New("yarıda kalmış migration var")
+
+//go:embed database/migrations/*.sql
+var embeddedMigrations embed.FS
+
+type Migration struct {
+	Version  int64
+	Name     string
+	UpPath   string // migration dosya sistemi içindeki yol
+	DownPath string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	Dirty     bool
+	AppliedAt *time.Time
+}
+
+// migrationsDir "migrate create" komutunun dosyaları yazdığı klasördür
+func migrationsDir() string {
+	if dir := os.Getenv("MIGRATIONS_DIR"); dir != "" {
+		return dir
+	}
+	return "./database/migrations"
+}
+
+// migrationsFS binary'ye gömülü migration'ları döner. Geliştirmede (APP_ENV=development)
+// MIGRATIONS_DIR verilirse yeni derleme gerekmeden o klasör okunur.
+func migrationsFS() (fs.FS, error) {
+	if dir := os.Getenv("MIGRATIONS_DIR"); dir != "" {
+		if !isDevelopment() {
+			return nil, fmt.Errorf("MIGRATIONS_DIR sadece APP_ENV=development iken kullanılabilir")
+		}
+		return os.DirFS(dir), nil
+	}
+	return fs.Sub(embeddedMigrations, "database/migrations")
+}
+
+// loadMigrations migration dosyalarını sürüme göre sıralı döner
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migration klasörü okunamadı: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("geçersiz migration sürümü: %s", entry.Name())
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("%d sürümü için birden fazla migration var: %s, %s", version, mig.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			mig.UpPath = entry.Name()
+		} else {
+			mig.DownPath = entry.Name()
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.UpPath == "" {
+			return nil, fmt.Errorf("%04d_%s için up dosyası yok", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// isEmptySQL sadece boşluk ve yorum satırlarından oluşan dosyaları ayırt eder;
+// MySQL boş sorguyu hata olarak döndürür
+func isEmptySQL(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// createMigration sıradaki sürüm numarasıyla boş up/down dosyaları oluşturur
+func createMigration(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = migrationNameCleaner.ReplaceAllString(name, "_")
+	name = strings.Trim(name, "_")
+	if name == "" {
+		return "", "", fmt.Errorf("migration adı boş olamaz")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("migration klasörü oluşturulamadı: %w", err)
+	}
+	migrations, err := loadMigrations(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	next := int64(1)
+	if len(migrations) > 0 {
+		next = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", next, name))
+	up, down := base+".up.sql", base+".down.sql"
+	if err := os.WriteFile(up, []byte("-- "+name+"\n"), 0o644); err != nil {
+		return "", "", fmt.Errorf("migration dosyası yazılamadı: %w", err)
+	}
+	if err := os.WriteFile(down, []byte("-- "+name+" geri alma\n"), 0o644); err != nil {
+		return "", "", fmt.Errorf("migration dosyası yazılamadı: %w", err)
+	}
+	return up, down, nil
+}
+
+// Migrator migration'ları tek bir bağlantı üzerinden, isimli MySQL kilidi altında çalıştırır.
+// Aynı anda açılan birden fazla sunucu kilidi sırayla alır; ikincisi işi bitmiş bulur.
+// DB bağlantısı multiStatements=true ile açılmış olmalı.
+type Migrator struct {
+	DB          *sql.DB
+	FS          fs.FS
+	LockTimeout time.Duration
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("veritabanı bağlantısı alınamadı: %w", err)
+	}
+	defer conn.Close()
+
+	timeout := m.LockTimeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, int(timeout.Seconds())).Scan(&locked); err != nil {
+		return fmt.Errorf("migration kilidi alınamadı: %w", err)
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return fmt.Errorf("migration kilidi %s içinde alınamadı", timeout)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName)
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			dirty BOOLEAN NOT NULL DEFAULT FALSE,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("schema_migrations tablosu oluşturulamadı: %w", err)
+	}
+
+	return fn(conn)
+}
+
+type appliedMigration struct {
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+func loadAppliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, dirty, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("uygulanan migration'lar okunamadı: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.Dirty, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+func checkNotDirty(applied map[int64]appliedMigration) error {
+	for version, a := range applied {
+		if a.Dirty {
+			return fmt.Errorf("%w: %d sürümü. Şemayı elle düzeltip schema_migrations kaydını güncelleyin", errDirtyMigration, version)
+		}
+	}
+	return nil
+}
+
+func runMigrationScript(ctx context.Context, conn *sql.Conn, fsys fs.FS, path string) error {
+	script, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return fmt.Errorf("migration dosyası okunamadı: %w", err)
+	}
+	if isEmptySQL(string(script)) {
+		return nil
+	}
+	if _, err := conn.ExecContext(ctx, string(script)); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Up bekleyen migration'ları sırayla uygular. limit 0 ise hepsi uygulanır.
+func (m *Migrator) Up(ctx context.Context, limit int) ([]Migration, error) {
+	migrations, err := loadMigrations(m.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := loadAppliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkNotDirty(applied); err != nil {
+			return err
+		}
+
+		for _, mig := range migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if limit > 0 && len(done) >= limit {
+				break
+			}
+
+			if _, err := conn.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, TRUE)`, mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("migration kaydı eklenemedi: %w", err)
+			}
+			if err := runMigrationScript(ctx, conn, m.FS, mig.UpPath); err != nil {
+				return err
+			}
+			if _, err := conn.ExecContext(ctx, `UPDATE schema_migrations SET dirty = FALSE, applied_at = NOW() WHERE version = ?`, mig.Version); err != nil {
+				return fmt.Errorf("migration kaydı güncellenemedi: %w", err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down son uygulanan steps adet migration'ı tersten geri alır
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	migrations, err := loadMigrations(m.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := loadAppliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkNotDirty(applied); err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.DownPath == "" {
+				return fmt.Errorf("%04d_%s için down dosyası yok", mig.Version, mig.Name)
+			}
+
+			if _, err := conn.ExecContext(ctx, `UPDATE schema_migrations SET dirty = TRUE WHERE version = ?`, mig.Version); err != nil {
+				return fmt.Errorf("migration kaydı güncellenemedi: %w", err)
+			}
+			if err := runMigrationScript(ctx, conn, m.FS, mig.DownPath); err != nil {
+				return err
+			}
+			if _, err := conn.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, mig.Version); err != nil {
+				return fmt.Errorf("migration kaydı silinemedi: %w", err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status klasördeki her migration'ın uygulanıp uygulanmadığını döner
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(m.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := loadAppliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range migrations {
+			s := MigrationStatus{Migration: mig}
+			if a, ok := applied[mig.Version]; ok {
+				appliedAt := a.AppliedAt
+				s.Applied, s.Dirty, s.AppliedAt = true, a.Dirty, &appliedAt
+				delete(applied, mig.Version)
+			}
+			statuses = append(statuses, s)
+		}
+		// Klasörde olmayan ama veritabanında kayıtlı sürümler de gösterilir
+		for version, a := range applied {
+			appliedAt := a.AppliedAt
+			statuses = append(statuses, MigrationStatus{
+				Migration: Migration{Version: version, Name: "(dosya yok)"},
+				Applied:   true,
+				Dirty:     a.Dirty,
+				AppliedAt: &appliedAt,
+			})
+		}
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+		return nil
+	})
+	return statuses, err
+}
+
+// openMigrationDB migration dosyalarındaki çoklu komutlar için ayrı bir bağlantı açar
+func openMigrationDB() (*sql.DB, error) {
+	db, err := sql.Open("mysql", mysqlDSN()+"&multiStatements=true")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func newMigrator(db *sql.DB) (*Migrator, error) {
+	fsys, err := migrationsFS()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		DB:          db,
+		FS:          fsys,
+		LockTimeout: envDuration("MIGRATION_LOCK_TIMEOUT_SECONDS", time.Second, time.Minute),
+	}, nil
+}
+
+// migrateOnStartup sunucu açılırken bekleyen migration'ları uygular.
+// MIGRATE_ON_START=false ise sadece bekleyen migration olmadığı kontrol edilir.
+func migrateOnStartup(ctx context.Context) error {
+	db, err := openMigrationDB()
+	if err != nil {
+		return fmt.Errorf("migration bağlantısı açılamadı: %w", err)
+	}
+	defer db.Close()
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(os.Getenv("MIGRATE_ON_START"), "false") {
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if !s.Applied || s.Dirty {
+				return fmt.Errorf("bekleyen migration var (%04d_%s); 'migrate up' çalıştırın", s.Version, s.Name)
+			}
+		}
+		return nil
+	}
+
+	done, err := migrator.Up(ctx, 0)
+	for _, mig := range done {
+		fmt.Printf("✅ Migration uygulandı: %04d_%s\n", mig.Version, mig.Name)
+	}
+	return err
+}
+
+const migrateUsage = `Kullanım:
+  kasa migrate up [N]       bekleyen migration'ları (ya da ilk N tanesini) uygular
+  kasa migrate down [N]     son N migration'ı geri alır (varsayılan 1)
+  kasa migrate status       migration durumlarını listeler
+  kasa migrate create AD    sıradaki numarayla boş up/down dosyaları oluşturur`
+
+// runMigrateCommand "migrate" alt komutunu çalıştırır ve çıkış kodunu döner
+func runMigrateCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if args[0] == "create" {
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		up, down, err := createMigration(migrationsDir(), strings.Join(args[1:], "_"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		fmt.Println("✅ Oluşturuldu:", up)
+		fmt.Println("✅ Oluşturuldu:", down)
+		return 0
+	}
+
+	n := 0
+	if args[0] == "down" {
+		n = 1
+	}
+	if len(args) > 1 {
+		parsed, err := strconv.Atoi(args[1])
+		if err != nil || parsed <= 0 {
+			fmt.Fprintln(os.Stderr, "❌ Geçersiz adım sayısı:", args[1])
+			return 2
+		}
+		n = parsed
+	}
+
+	if useSQLite() {
+		fmt.Fprintln(os.Stderr, "❌ Migration'lar sadece MySQL içindir; SQLite şeması açılışta uygulanır")
+		return 1
+	}
+	db, err := openMigrationDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "❌ Veritabanına bağlanılamadı:", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	migrator, err := newMigrator(db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "❌", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx, n)
+		for _, mig := range done {
+			fmt.Printf("✅ Uygulandı: %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("Bekleyen migration yok")
+		}
+	case "down":
+		done, err := migrator.Down(ctx, n)
+		for _, mig := range done {
+			fmt.Printf("↩️  Geri alındı: %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("Geri alınacak migration yok")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SÜRÜM\tAD\tDURUM\tUYGULANMA")
+		for _, s := range statuses {
+			state, appliedAt := "bekliyor", "-"
+			if s.Applied {
+				state = "uygulandı"
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Dirty {
+				state = "yarıda kaldı"
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		tw.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
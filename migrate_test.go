@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestEmbeddedMigrations gömülü migration'ların klasördekilerle aynı olduğunu ve
+// sürümlerin boşluksuz, down dosyalarıyla birlikte geldiğini doğrular
+func TestEmbeddedMigrations(t *testing.T) {
+	t.Setenv("MIGRATIONS_DIR", "")
+	fsys, err := migrationsFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedded, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onDisk, err := loadMigrations(os.DirFS("database/migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embedded) != len(onDisk) {
+		t.Fatalf("gömülü %d migration var, klasörde %d", len(embedded), len(onDisk))
+	}
+	for i, mig := range embedded {
+		if mig != onDisk[i] {
+			t.Errorf("%d. migration farklı: %+v, %+v", i, mig, onDisk[i])
+		}
+		if mig.Version != int64(i+1) {
+			t.Errorf("%04d_%s: beklenen sürüm %d", mig.Version, mig.Name, i+1)
+		}
+		if mig.DownPath == "" {
+			t.Errorf("%04d_%s için down dosyası yok", mig.Version, mig.Name)
+		}
+	}
+}
+
+func TestMigrationsDirNeedsDevelopment(t *testing.T) {
+	t.Setenv("MIGRATIONS_DIR", "database/migrations")
+	t.Setenv("APP_ENV", "")
+	if _, err := migrationsFS(); err == nil {
+		t.Fatal("APP_ENV=development olmadan MIGRATIONS_DIR kabul edilmemeli")
+	}
+	t.Setenv("APP_ENV", "development")
+	if _, err := migrationsFS(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,8 +1,95 @@
 package main
 
 type UserRegisterRequest struct {
+	FullName string `json:"fullname" validate:"required,max=50"`
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"required,min=6,max=128"`
+	Iban     string `json:"iban" validate:"max=34"`
+	Locale   string `json:"locale"` // opsiyonel, boşsa Accept-Language kullanılır
+}
+
+type UserLoginRequest struct {
+	Email    string `json:"email" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,max=128"`
+}
+
+// Grup yanıtlarının ortak modelleri. Depo (KasaRepository) bu yapıları doldurur; grup dönen
+// tüm handler'lar aynı JSON'u üretir. Alan adları istemcilerin kullandığı
+// sözleşmedir, testdata/golden altındaki örnekler ile TestGoldenResponses değişiklikleri yakalar.
+
+// Group isteyen kullanıcının gözünden bir gruptur; borç ve alacaklar o kullanıcıya aittir.
+// Boş listeler veritabanındaki gibi null döner.
+type Group struct {
+	ID              int64        `json:"id"`
+	GroupToken      *string      `json:"group_token"`
+	Name            string       `json:"name"`
+	Currency        string       `json:"currency"`
+	CreatedAt       int64        `json:"created_at"`
+	IsAdmin         bool         `json:"is_admin"`
+	Creator         GroupCreator `json:"creator"`
+	Members         []Member     `json:"members"`
+	PendingRequests []AddRequest `json:"pending_requests"`
+	Expenses        []Expense    `json:"expenses"`
+	Debts           []Debt       `json:"debts"`
+	Credits         []Credit     `json:"credits"`
+}
+
+type GroupCreator struct {
+	ID       string `json:"id"`
 	FullName string `json:"fullname"`
 	Email    string `json:"email"`
-	Password string `json:"password"`
-	Iban     string `json:"iban"`
 }
+
+// Member grup üyesidir; total_share silinmemiş harcamalardaki paylarının toplamıdır
+type Member struct {
+	ID         string  `json:"id"`
+	FullName   string  `json:"fullname"`
+	Email      string  `json:"email"`
+	TotalShare float64 `json:"total_share"`
+}
+
+// AddRequest gruptaki bekleyen ekleme ya da katılım isteğidir
+type AddRequest struct {
+	RequestID        int64  `json:"request_id"`
+	UserID           string `json:"user_id"`
+	FullName         string `json:"fullname"`
+	Email            string `json:"email"`
+	RequestedAt      int64  `json:"requested_at"`
+	RequestStatus    string `json:"request_status"`
+	RequestDirection string `json:"request_direction"`
+	GroupName        string `json:"group_name"`
+	GroupID          int64  `json:"group_id"`
+}
+
+type Expense struct {
+	ExpenseID       int64         `json:"expense_id"`
+	GroupID         int64         `json:"group_id"`
+	Amount          float64       `json:"amount"`
+	DescriptionNote *string       `json:"description_note"`
+	PaymentDate     int64         `json:"payment_date"`
+	PaymentTitle    string        `json:"payment_title"`
+	BillImageURL    *string       `json:"bill_image_url"`
+	PayerID         string        `json:"payer_id"`
+	PayerName       string        `json:"payer_name"`
+	Participants    []Participant `json:"participants"`
+}
+
+type Participant struct {
+	UserID        string  `json:"user_id"`
+	UserName      string  `json:"user_name"`
+	AmountShare   float64 `json:"amount_share"`
+	PaymentStatus string  `json:"payment_status"`
+}
+
+// Debt kullanıcının bir harcamadaki, ödeyene (user_id) olan borcudur
+type Debt struct {
+	UserID   string  `json:"user_id"`
+	Username string  `json:"username"`
+	IBAN     *string `json:"iban"`
+	Amount   float64 `json:"amount"`
+	Status   string  `json:"status"`
+	Expenses []int64 `json:"expenses"`
+}
+
+// Credit Debt ile aynı alanları taşır; user_id bu kez kullanıcıya borçlu olan kişidir
+type Credit Debt
@@ -4,11 +4,33 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 
 	"firebase.google.com/go/v4/messaging"
 )
 
-func SendNotification(ctx context.Context, repo *KasaRepository, userID, title, body string, data map[string]string) error {
+// SendNotification başlık ve gövdeyi alıcının kayıtlı dilinde oluşturup gönderir.
+// titleKey ve bodyKey mesaj kataloğundaki anahtarlardır, params yer tutucuları doldurur.
+// Bildirim push gönderilemese bile kullanıcının gelen kutusuna kaydedilir.
+func SendNotification(ctx context.Context, repo Repository, userID, titleKey, bodyKey string, params map[string]string, data map[string]string) error {
+	// Geçici üyelerin hesabı ve cihazı yok
+	if isPlaceholderID(userID) {
+		return nil
+	}
+
+	locale, err := repo.GetUserLocale(ctx, userID)
+	if err != nil {
+		log.Printf("Kullanıcı (%s) dili alınamadı, varsayılan dil kullanılıyor: %v", userID, err)
+	}
+	title := T(locale, titleKey, params)
+	body := T(locale, bodyKey, params)
+
+	// Gelen kutusuna kaydet
+	notificationID, err := repo.SaveNotification(ctx, userID, title, body, data)
+	if err != nil {
+		return err
+	}
+
 	if FirebaseMessagingClient == nil {
 		return fmt.Errorf("FirebaseMessagingClient initialize edilmemiş")
 	}
@@ -24,7 +46,18 @@ func SendNotification(ctx context.Context, repo *KasaRepository, userID, title,
 		return nil
 	}
 
-	badge := 1
+	// Rozet sayısı okunmamış bildirim sayısıdır
+	badge, err := repo.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		log.Printf("Okunmamış bildirim sayısı alınamadı: %v", err)
+		badge = 1
+	}
+
+	// Uygulama bildirime dokunulduğunda gelen kutusundaki kaydı okundu işaretleyebilsin
+	pushData := map[string]string{"notification_id": strconv.FormatInt(notificationID, 10)}
+	for k, v := range data {
+		pushData[k] = v
+	}
 
 	message := &messaging.Message{
 		Token: userToken,
@@ -32,7 +65,7 @@ func SendNotification(ctx context.Context, repo *KasaRepository, userID, title,
 			Title: title,
 			Body:  body,
 		},
-		Data: data, // 🔥 Bildirimle birlikte yönlendirme verileri buraya
+		Data: pushData, // 🔥 Bildirimle birlikte yönlendirme verileri buraya
 		Android: &messaging.AndroidConfig{
 			Priority: "high",
 		},
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// openAPISpec tüm uçların istek ve yanıt sözleşmesidir; /openapi.json adresinden sunulur.
+// Yeni bir uç eklendiğinde ya da yanıt modeli değiştiğinde bu dosya da güncellenmelidir,
+// conformance testi kayıtlı yolları ve handler yanıtlarını bu dokümana karşı dener.
+//
+//go:embed api/openapi.json
+var openAPISpec []byte
+
+func handleOpenAPISpec() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Write(openAPISpec)
+	}
+}
+
+// openAPIDocument spesifikasyonun doğrulama için gereken kısmıdır
+type openAPIDocument struct {
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas   map[string]*openAPISchema   `json:"schemas"`
+		Responses map[string]*openAPIResponse `json:"responses"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Deprecated  bool                        `json:"deprecated"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIResponse struct {
+	Ref     string `json:"$ref"`
+	Content map[string]struct {
+		Schema *openAPISchema `json:"schema"`
+	} `json:"content"`
+}
+
+// openAPISchema dokümanda kullanılan JSON Schema alt kümesidir. Sözleşme katıdır: properties
+// tanımlı bir nesnede şemada olmayan alan hata sayılır, böylece handler'a eklenen ama dokümana
+// yazılmayan alanlar da yakalanır.
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref"`
+	Type                 string                    `json:"type"`
+	Nullable             bool                      `json:"nullable"`
+	Enum                 []interface{}             `json:"enum"`
+	Properties           map[string]*openAPISchema `json:"properties"`
+	Required             []string                  `json:"required"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties"`
+	Items                *openAPISchema            `json:"items"`
+	AllOf                []*openAPISchema          `json:"allOf"`
+	Minimum              *float64                  `json:"minimum"`
+	Maximum              *float64                  `json:"maximum"`
+}
+
+func loadOpenAPIDocument() (*openAPIDocument, error) {
+	var doc openAPIDocument
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		return nil, fmt.Errorf("OpenAPI dokümanı okunamadı: %w", err)
+	}
+	return &doc, nil
+}
+
+// resolve $ref ve allOf'u tek bir şemaya indirir; allOf'taki nesnelerin alanları birleştirilir
+func (doc *openAPIDocument) resolve(s *openAPISchema) (*openAPISchema, error) {
+	for s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		target, ok := doc.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("tanımsız şema %s", s.Ref)
+		}
+		s = target
+	}
+	if len(s.AllOf) == 0 {
+		return s, nil
+	}
+
+	merged := &openAPISchema{Type: "object", Nullable: s.Nullable, Properties: map[string]*openAPISchema{}}
+	for _, part := range s.AllOf {
+		resolved, err := doc.resolve(part)
+		if err != nil {
+			return nil, err
+		}
+		for name, prop := range resolved.Properties {
+			merged.Properties[name] = prop
+		}
+		merged.Required = append(merged.Required, resolved.Required...)
+	}
+	return merged, nil
+}
+
+// validate JSON'dan (UseNumber ile) çözülmüş değeri şemaya karşı dener
+func (doc *openAPIDocument) validate(path string, s *openAPISchema, value interface{}) error {
+	s, err := doc.resolve(s)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s: null olamaz", path)
+	}
+
+	switch s.Type {
+	case "":
+		// Tipi belirtilmemiş alan (ör. aktivite kaydındaki before/after) her değeri alır
+		return nil
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: nesne beklenirdi, gelen %T", path, value)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s.%s: zorunlu alan eksik", path, name)
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil {
+				if s.Properties == nil {
+					continue
+				}
+				return fmt.Errorf("%s.%s: sözleşmede olmayan alan", path, name)
+			}
+			if err := doc.validate(path+"."+name, prop, obj[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: dizi beklenirdi, gelen %T", path, value)
+		}
+		for i, item := range items {
+			if err := doc.validate(fmt.Sprintf("%s[%d]", path, i), s.Items, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: metin beklenirdi, gelen %T", path, value)
+		}
+		return checkEnum(path, s.Enum, str)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: boolean beklenirdi, gelen %T", path, value)
+		}
+		return nil
+	case "integer", "number":
+		num, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: sayı beklenirdi, gelen %T", path, value)
+		}
+		f, err := num.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: geçersiz sayı %s", path, num)
+		}
+		if s.Type == "integer" {
+			if _, err := strconv.ParseInt(num.String(), 10, 64); err != nil {
+				return fmt.Errorf("%s: tam sayı beklenirdi, gelen %s", path, num)
+			}
+		}
+		if (s.Minimum != nil && f < *s.Minimum) || (s.Maximum != nil && f > *s.Maximum) {
+			return fmt.Errorf("%s: %s izin verilen aralıkta değil", path, num)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: desteklenmeyen şema tipi %q", path, s.Type)
+}
+
+func checkEnum(path string, enum []interface{}, value string) error {
+	if len(enum) == 0 {
+		return nil
+	}
+	for _, allowed := range enum {
+		if allowed == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %q izin verilen değerlerden biri değil %v", path, value, enum)
+}
+
+// validateResponse handler yanıtını dokümandaki işlemin o durum koduna ait şemasıyla karşılaştırır.
+// pattern yolun router'a kaydedildiği biçimidir (/api/v1/groups/{id} gibi).
+func (doc *openAPIDocument) validateResponse(method, pattern string, status int, contentType string, body []byte) error {
+	where := method + " " + pattern
+	op := doc.Paths[pattern][strings.ToLower(method)]
+	if op == nil {
+		return fmt.Errorf("%s: dokümanda yok", where)
+	}
+	resp := op.Responses[strconv.Itoa(status)]
+	if resp == nil {
+		return fmt.Errorf("%s: %d yanıtı dokümanda yok", where, status)
+	}
+	for resp.Ref != "" {
+		// Ortak yanıtlar (ör. hata gövdesi) components.responses altında tanımlıdır
+		name := strings.TrimPrefix(resp.Ref, "#/components/responses/")
+		target, ok := doc.Components.Responses[name]
+		if !ok {
+			return fmt.Errorf("%s: tanımsız yanıt %s", where, resp.Ref)
+		}
+		resp = target
+	}
+	if len(resp.Content) == 0 {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return fmt.Errorf("%s: %d yanıtının gövdesi olmamalı", where, status)
+		}
+		return nil
+	}
+
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	content, ok := resp.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("%s: %d yanıtı için %q içerik tipi dokümanda yok", where, status, mediaType)
+	}
+	if mediaType != "application/json" || content.Schema == nil {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("%s: yanıt JSON değil: %w", where, err)
+	}
+	if err := doc.validate("yanıt", content.Schema, value); err != nil {
+		return fmt.Errorf("%s %d: %w", where, status, err)
+	}
+	return nil
+}
+
+// checkRoutes router'a kayıtlı her metot ve yolun dokümanda, dokümandaki her işlemin de
+// router'da olduğunu kontrol eder. Mount ile eklenen statik yollar kapsam dışıdır.
+func (doc *openAPIDocument) checkRoutes(rt *Router) error {
+	rt.allowed.mu.RLock()
+	defer rt.allowed.mu.RUnlock()
+
+	var problems []string
+	for path, methods := range rt.allowed.methods {
+		for _, method := range methods {
+			if doc.Paths[path][strings.ToLower(method)] == nil {
+				problems = append(problems, fmt.Sprintf("%s %s dokümanda yok", method, path))
+			}
+		}
+	}
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			registered := false
+			for _, m := range rt.allowed.methods[path] {
+				registered = registered || strings.EqualFold(m, method)
+			}
+			if !registered {
+				problems = append(problems, fmt.Sprintf("%s %s router'da yok", strings.ToUpper(method), path))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("OpenAPI ve router uyuşmuyor:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOpenAPIContract dokümandaki her işlemi (eski yollar dahil) router üzerinden, kimlik doğrulama
+// ve idempotency middleware'leriyle birlikte çağırır ve yanıtı dokümana karşı dener. Çağrılmayan
+// bir işlem kalırsa test başarısız olur; yeni bir uç dokümana eklendiğinde buraya da çağrısı eklenmelidir.
+func TestOpenAPIContract(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		repo, err := openSQLiteRepository(":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { repo.DB.Close() })
+		checkOpenAPIContract(t, repo)
+	})
+	t.Run("mysql", func(t *testing.T) {
+		dsn := os.Getenv("KASA_TEST_MYSQL_DSN")
+		if dsn == "" {
+			t.Skip("KASA_TEST_MYSQL_DSN verilmedi")
+		}
+		checkOpenAPIContract(t, openMySQLTestRepository(t, dsn))
+	})
+}
+
+// contractClient istekleri router'a verir, yanıtları dokümana karşı dener ve çağrılan işlemleri sayar
+type contractClient struct {
+	t      *testing.T
+	router http.Handler
+	doc    *openAPIDocument
+	called map[string]bool
+}
+
+// do pattern'deki işlemi path'e istek atarak çağırır; userID boşsa kimlik gönderilmez. body
+// string ise olduğu gibi, değilse JSON olarak gönderilir. Durum want değilse test durur.
+func (c *contractClient) do(userID, method, pattern, path string, body interface{}, want int) *httptest.ResponseRecorder {
+	c.t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	r := httptest.NewRequest(method, path, reader)
+	if userID != "" {
+		token, err := generateJWT(map[string]string{"uid": userID, "email": userID + "@example.com"})
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, r)
+	c.called[method+" "+pattern] = true
+
+	if w.Code != want {
+		c.t.Fatalf("%s %s: durum %d, beklenen %d: %s", method, path, w.Code, want, strings.TrimSpace(w.Body.String()))
+	}
+	if err := c.doc.validateResponse(method, pattern, w.Code, w.Header().Get("Content-Type"), w.Body.Bytes()); err != nil {
+		c.t.Fatal(err)
+	}
+	return w
+}
+
+// both aynı işlemi /api/v1 yolundan ve ilk sürümdeki aynı adlı yolundan çağırır
+func (c *contractClient) both(userID, method, pattern, path string, body interface{}, want int) {
+	c.t.Helper()
+	c.do(userID, method, apiV1+pattern, apiV1+path, body, want)
+	c.do(userID, method, pattern, path, body, want)
+}
+
+// decode yanıt gövdesini dest'e çözer
+func (c *contractClient) decode(w *httptest.ResponseRecorder, dest interface{}) {
+	c.t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), dest); err != nil {
+		c.t.Fatalf("yanıt çözülemedi: %v: %s", err, w.Body.String())
+	}
+}
+
+// uncalled dokümanda olup çağrılmayan işlemleri döner
+func (c *contractClient) uncalled() []string {
+	var missing []string
+	for path, ops := range c.doc.Paths {
+		for method := range ops {
+			if key := strings.ToUpper(method) + " " + path; !c.called[key] {
+				missing = append(missing, key)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func checkOpenAPIContract(t *testing.T, repo Repository) {
+	doc, err := loadOpenAPIDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newRouter(repo)
+	if err := doc.checkRoutes(router); err != nil {
+		t.Fatal(err)
+	}
+	c := &contractClient{t: t, router: router, doc: doc, called: map[string]bool{}}
+
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	user := func(name string) string {
+		id := "contract-" + name + "-" + suffix
+		if err := repo.CreateUser(id, "Kullanıcı "+name, id+"@example.com", "hash", "TR00", "tr"); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	owner, member, friend, joiner, invitee, leaver := user("owner"), user("member"), user("friend"), user("joiner"), user("invitee"), user("leaver")
+	outsider := user("outsider")
+
+	groupID, err := repo.CreateGroup(owner, "Ev", "contract-token-"+suffix, "TRY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := fmt.Sprint(groupID)
+	groupPath := "/groups/" + group
+	if _, _, err := repo.sendAddGroupRequest(group, member+"@example.com", owner); err != nil {
+		t.Fatal(err)
+	}
+	requests, err := repo.getMyAddRequests(member)
+	if err != nil || len(requests) != 1 {
+		t.Fatalf("üyeye gelen istekler: %v %v", requests, err)
+	}
+	if _, err := repo.acceptAddRequest(requests[0].RequestID, member); err != nil {
+		t.Fatal(err)
+	}
+	share := 25.0
+	expenseBody := CreateExpenseRequest{
+		TotalAmount: 50, PaymentTitle: "Market",
+		Users: []ExpenseUser{{UserID: owner, Amount: &share}, {UserID: member, Amount: &share}},
+	}
+
+	// Meta ve kimlik; kimlik uçları Firebase'e gitmeden gövde doğrulamasında döner
+	c.do("", "GET", "/openapi.json", "/openapi.json", nil, http.StatusOK)
+	c.do("", "POST", apiV1+"/auth/register", apiV1+"/auth/register", map[string]string{}, http.StatusBadRequest)
+	c.do("", "POST", "/register", "/register", map[string]string{}, http.StatusBadRequest)
+	c.do("", "POST", apiV1+"/auth/login", apiV1+"/auth/login", map[string]string{}, http.StatusBadRequest)
+	c.do("", "POST", "/login", "/login", map[string]string{}, http.StatusBadRequest)
+	c.do("", "POST", apiV1+"/auth/google", apiV1+"/auth/google", map[string]string{}, http.StatusBadRequest)
+	c.do("", "POST", "/login-google", "/login-google", map[string]string{}, http.StatusBadRequest)
+
+	// Hesap
+	c.do(owner, "GET", apiV1+"/me", apiV1+"/me", nil, http.StatusOK)
+	c.do(owner, "GET", "/get-me", "/get-me", nil, http.StatusOK)
+	c.do(owner, "PATCH", apiV1+"/me", apiV1+"/me", map[string]string{"iban": "TR03"}, http.StatusOK)
+	c.do(owner, "PATCH", "/update-user", "/update-user", map[string]string{"iban": "TR04"}, http.StatusOK)
+	c.do(owner, "PUT", apiV1+"/me/fcm-token", apiV1+"/me/fcm-token", map[string]string{"token": "token-1"}, http.StatusOK)
+	c.do(owner, "POST", "/save-fcm-token", "/save-fcm-token", map[string]string{"token": "token-2"}, http.StatusOK)
+	c.do(owner, "GET", apiV1+"/me/deletion", apiV1+"/me/deletion", nil, http.StatusOK)
+	c.do(owner, "POST", apiV1+"/me/data-exports", apiV1+"/me/data-exports", nil, http.StatusAccepted)
+	c.do(owner, "GET", apiV1+"/me/data-exports", apiV1+"/me/data-exports", nil, http.StatusOK)
+	c.do("", "GET", apiV1+"/data-exports/download", apiV1+"/data-exports/download?token=yok", nil, http.StatusNotFound)
+	c.do(owner, "POST", apiV1+"/uploads/photos", apiV1+"/uploads/photos", nil, http.StatusBadRequest)
+	c.do(owner, "POST", "/upload-photo", "/upload-photo", nil, http.StatusBadRequest)
+	c.do(leaver, "DELETE", apiV1+"/me", apiV1+"/me", nil, http.StatusAccepted)
+	c.do(leaver, "POST", apiV1+"/me/restore", apiV1+"/me/restore", nil, http.StatusOK)
+	c.do(leaver, "DELETE", "/delete-account", "/delete-account", nil, http.StatusAccepted)
+	c.do(leaver, "POST", apiV1+"/me/restore", apiV1+"/me/restore", nil, http.StatusOK)
+
+	// Gruplar
+	c.both(member, "GET", "/groups", "/groups", nil, http.StatusOK)
+	c.do(friend, "POST", apiV1+"/groups", apiV1+"/groups", CreateGroupRequest{GroupName: "Sözleşme"}, http.StatusCreated)
+	c.do(friend, "POST", "/create-group", "/create-group", CreateGroupRequest{GroupName: "Sözleşme 2"}, http.StatusCreated)
+	c.do(friend, "POST", apiV1+"/groups", apiV1+"/groups", CreateGroupRequest{GroupName: " "}, http.StatusBadRequest)
+	c.do(friend, "POST", apiV1+"/groups", apiV1+"/groups", map[string]string{"group_name": "Sözleşme", "owner": friend}, http.StatusBadRequest)
+	c.do(owner, "GET", apiV1+"/groups/{id}/activity", apiV1+groupPath+"/activity", nil, http.StatusOK)
+	c.do(owner, "GET", apiV1+"/groups/{id}/export", apiV1+groupPath+"/export", nil, http.StatusOK)
+	c.do(owner, "POST", apiV1+"/groups/{id}/import", apiV1+groupPath+"/import", nil, http.StatusBadRequest)
+	c.do(member, "GET", apiV1+"/groups/{id}/join-settings", apiV1+groupPath+"/join-settings", nil, http.StatusOK)
+	c.do(owner, "PUT", apiV1+"/groups/{id}/join-settings", apiV1+groupPath+"/join-settings", GroupJoinSettings{}, http.StatusOK)
+	c.do(member, "GET", apiV1+"/groups/{id}/reminder-settings", apiV1+groupPath+"/reminder-settings", nil, http.StatusOK)
+	reminder := ReminderSettings{Enabled: true, FirstReminderDays: 3, RepeatEveryDays: 7}
+	c.do(owner, "PUT", apiV1+"/groups/{id}/reminder-settings", apiV1+groupPath+"/reminder-settings", reminder, http.StatusOK)
+
+	// Harcamalar ve ödemeler
+	var created ExpenseWithParticipantsAndBalances
+	c.decode(c.do(owner, "POST", apiV1+"/groups/{id}/expenses", apiV1+groupPath+"/expenses", expenseBody, http.StatusCreated), &created)
+	expensePath := fmt.Sprintf("%s%s/expenses/%d", apiV1, groupPath, created.Expense.ExpenseID)
+	c.do(outsider, "DELETE", apiV1+"/groups/{id}/expenses/{expenseId}", expensePath, nil, http.StatusForbidden)
+	c.do(owner, "DELETE", apiV1+"/groups/{id}/expenses/{expenseId}", apiV1+groupPath+"/expenses/999999999", nil, http.StatusNotFound)
+	c.do(owner, "DELETE", apiV1+"/groups/{id}/expenses/{expenseId}", expensePath, nil, http.StatusOK)
+	c.do(owner, "POST", apiV1+"/groups/{id}/expenses/{expenseId}/restore", expensePath+"/restore", nil, http.StatusOK)
+	c.do(owner, "POST", apiV1+"/groups/{id}/expenses/{expenseId}/restore", expensePath+"/restore", nil, http.StatusConflict)
+	legacyExpense := expenseBody
+	legacyExpense.GroupID = int(groupID)
+	c.decode(c.do(owner, "POST", "/add-group-expense", "/add-group-expense", legacyExpense, http.StatusCreated), &created)
+	expenseID := map[string]int64{"expense_id": created.Expense.ExpenseID}
+	c.do(owner, "DELETE", "/delete-expense", "/delete-expense", expenseID, http.StatusOK)
+	c.do(owner, "POST", apiV1+"/groups/{id}/expenses/{expenseId}/restore", fmt.Sprintf("%s%s/expenses/%d/restore", apiV1, groupPath, created.Expense.ExpenseID), nil, http.StatusOK)
+	c.do(member, "GET", apiV1+"/groups/{id}/deleted-expenses", apiV1+groupPath+"/deleted-expenses", nil, http.StatusOK)
+	c.do(owner, "POST", apiV1+"/groups/{id}/nudges", apiV1+groupPath+"/nudges", map[string]string{"debtor_id": member}, http.StatusOK)
+	c.do(owner, "POST", apiV1+"/groups/{id}/nudges", apiV1+groupPath+"/nudges", map[string]string{"debtor_id": member}, http.StatusTooManyRequests)
+	c.do(member, "POST", apiV1+"/groups/{id}/payments", apiV1+groupPath+"/payments", map[string]string{"sended_user_id": owner}, http.StatusOK)
+	c.do(member, "POST", "/pay-group-expense", "/pay-group-expense", map[string]interface{}{"sended_user_id": owner, "group_id": groupID}, http.StatusOK)
+
+	// Davet bağlantıları
+	var link InviteLink
+	c.decode(c.do(owner, "POST", apiV1+"/groups/{id}/invite-links", apiV1+groupPath+"/invite-links", CreateInviteLinkRequest{}, http.StatusCreated), &link)
+	c.do(owner, "GET", apiV1+"/groups/{id}/invite-links", apiV1+groupPath+"/invite-links", nil, http.StatusOK)
+	c.decode(c.do(owner, "POST", apiV1+"/groups/{id}/invite-links/{linkId}/rotate", fmt.Sprintf("%s%s/invite-links/%d/rotate", apiV1, groupPath, link.LinkID), nil, http.StatusCreated), &link)
+	c.do(joiner, "POST", apiV1+"/invites/{token}/join", apiV1+"/invites/"+link.Token+"/join", nil, http.StatusCreated)
+	c.do(friend, "POST", "/add-group-token", "/add-group-token", map[string]string{"group_token": "contract-token-" + suffix}, http.StatusCreated)
+	c.do(owner, "DELETE", apiV1+"/groups/{id}/invite-links/{linkId}", fmt.Sprintf("%s%s/invite-links/%d", apiV1, groupPath, link.LinkID), nil, http.StatusNoContent)
+
+	// Grup ekleme istekleri
+	invite := func(pattern, path string, body interface{}) int64 {
+		c.do(owner, "POST", pattern, path, body, http.StatusOK)
+		requests, err := repo.getMyAddRequests(invitee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, req := range requests {
+			if req.RequestStatus == "pending" {
+				return req.RequestID
+			}
+		}
+		t.Fatalf("davet edilene bekleyen istek gelmedi: %v", requests)
+		return 0
+	}
+	requestID := invite(apiV1+"/groups/{id}/add-requests", apiV1+groupPath+"/add-requests", map[string]string{"added_member": invitee + "@example.com"})
+	c.do(owner, "GET", apiV1+"/groups/{id}/add-requests", apiV1+groupPath+"/add-requests", nil, http.StatusOK)
+	c.do(invitee, "GET", apiV1+"/add-requests", apiV1+"/add-requests", nil, http.StatusOK)
+	c.do(invitee, "GET", "/get-my-add-requests", "/get-my-add-requests", nil, http.StatusOK)
+	requestPath := fmt.Sprintf("%s/add-requests/%d", groupPath, requestID)
+	c.do(owner, "POST", apiV1+"/groups/{id}/add-requests/{requestId}/resend", apiV1+requestPath+"/resend", nil, http.StatusTooManyRequests)
+	c.do(owner, "DELETE", apiV1+"/groups/{id}/add-requests/{requestId}", apiV1+requestPath, nil, http.StatusOK)
+	requestID = invite("/send-add-group-request", "/send-add-group-request", map[string]string{"group_id": group, "added_member": invitee + "@example.com"})
+	c.do(owner, "DELETE", apiV1+"/groups/{id}/add-requests/{requestId}", fmt.Sprintf("%s%s/add-requests/%d", apiV1, groupPath, requestID), nil, http.StatusOK)
+	requestID = invite(apiV1+"/groups/{id}/add-requests", apiV1+groupPath+"/add-requests", map[string]string{"added_member": invitee + "@example.com"})
+	c.do(invitee, "POST", apiV1+"/add-requests/{requestId}/reject", fmt.Sprintf("%s/add-requests/%d/reject", apiV1, requestID), nil, http.StatusOK)
+	requestID = invite(apiV1+"/groups/{id}/add-requests", apiV1+groupPath+"/add-requests", map[string]string{"added_member": invitee + "@example.com"})
+	c.do(invitee, "POST", "/reject-add-request", "/reject-add-request", map[string]int64{"request_id": requestID}, http.StatusOK)
+	requestID = invite(apiV1+"/groups/{id}/add-requests", apiV1+groupPath+"/add-requests", map[string]string{"added_member": invitee + "@example.com"})
+	c.do(invitee, "POST", "/accept-add-request", "/accept-add-request", map[string]int64{"request_id": requestID}, http.StatusOK)
+	c.do(invitee, "POST", apiV1+"/add-requests/{requestId}/accept", fmt.Sprintf("%s/add-requests/%d/accept", apiV1, requestID), nil, http.StatusNotFound)
+
+	// Geçici üyeler
+	var placeholder PlaceholderMember
+	c.decode(c.do(owner, "POST", apiV1+"/groups/{id}/placeholders", apiV1+groupPath+"/placeholders",
+		CreatePlaceholderRequest{FullName: "Ali", Email: leaver + "@example.com"}, http.StatusCreated), &placeholder)
+	c.do(owner, "POST", apiV1+"/groups/{id}/placeholders", apiV1+groupPath+"/placeholders", CreatePlaceholderRequest{FullName: "Veli"}, http.StatusCreated)
+	c.do(owner, "GET", apiV1+"/groups/{id}/placeholders", apiV1+groupPath+"/placeholders", nil, http.StatusOK)
+	c.do(leaver, "GET", apiV1+"/placeholders/claimable", apiV1+"/placeholders/claimable", nil, http.StatusOK)
+	c.do(leaver, "POST", apiV1+"/placeholders/{id}/claim", apiV1+"/placeholders/"+placeholder.ID+"/claim", nil, http.StatusOK)
+	c.do(leaver, "POST", apiV1+"/placeholders/{id}/claim", apiV1+"/placeholders/"+placeholder.ID+"/claim", nil, http.StatusNotFound)
+
+	// Bildirimler
+	c.do(member, "GET", apiV1+"/notifications", apiV1+"/notifications", nil, http.StatusOK)
+	c.do(member, "GET", apiV1+"/notifications/unread-count", apiV1+"/notifications/unread-count", nil, http.StatusOK)
+	c.do(member, "POST", apiV1+"/notifications/mark-read", apiV1+"/notifications/mark-read", map[string][]int64{"ids": {1}}, http.StatusOK)
+	c.do(member, "POST", apiV1+"/notifications/mark-all-read", apiV1+"/notifications/mark-all-read", nil, http.StatusOK)
+
+	// Arkadaşlar
+	var sent FriendRequestResponseBody
+	sendFriendRequest := func(pattern, from, to string) int64 {
+		c.decode(c.do(from, "POST", pattern, pattern, FriendRequestBody{Email: to + "@example.com"}, http.StatusCreated), &sent)
+		return sent.RequestID
+	}
+	id := sendFriendRequest(apiV1+"/friend-requests", owner, friend)
+	c.do(friend, "GET", apiV1+"/friend-requests", apiV1+"/friend-requests", nil, http.StatusOK)
+	c.do(friend, "POST", apiV1+"/friend-requests/{requestId}/accept", fmt.Sprintf("%s/friend-requests/%d/accept", apiV1, id), nil, http.StatusOK)
+	id = sendFriendRequest(apiV1+"/friend-requests", joiner, leaver)
+	c.do(leaver, "POST", apiV1+"/friend-requests/{requestId}/accept", fmt.Sprintf("%s/friend-requests/%d/accept", apiV1, id), nil, http.StatusOK)
+	id = sendFriendRequest(apiV1+"/friend-requests", owner, joiner)
+	c.do(joiner, "POST", apiV1+"/friend-requests/{requestId}/reject", fmt.Sprintf("%s/friend-requests/%d/reject", apiV1, id), nil, http.StatusOK)
+	id = sendFriendRequest(apiV1+"/friend-requests", owner, leaver)
+	c.do(leaver, "POST", apiV1+"/friend-requests/{requestId}/reject", fmt.Sprintf("%s/friend-requests/%d/reject", apiV1, id), nil, http.StatusOK)
+	c.do(owner, "GET", apiV1+"/friends", apiV1+"/friends", nil, http.StatusOK)
+	c.do(owner, "GET", apiV1+"/balances", apiV1+"/balances", nil, http.StatusOK)
+
+	friendExpense := CreateExpenseRequest{
+		TotalAmount: 50, PaymentTitle: "Sinema",
+		Users: []ExpenseUser{{UserID: owner, Amount: &share}, {UserID: friend, Amount: &share}},
+	}
+	var friendCreated ExpenseWithParticipants
+	c.decode(c.do(owner, "POST", apiV1+"/friend-expenses", apiV1+"/friend-expenses", friendExpense, http.StatusCreated), &friendCreated)
+	c.do(owner, "DELETE", apiV1+"/friend-expenses/{id}", fmt.Sprintf("%s/friend-expenses/%d", apiV1, friendCreated.ExpenseID), nil, http.StatusNoContent)
+	c.decode(c.do(owner, "POST", apiV1+"/friend-expenses", apiV1+"/friend-expenses", friendExpense, http.StatusCreated), &friendCreated)
+	c.do(owner, "GET", apiV1+"/friends/{id}/expenses", apiV1+"/friends/"+friend+"/expenses", nil, http.StatusOK)
+	c.do(owner, "DELETE", apiV1+"/friend-expenses/{id}", fmt.Sprintf("%s/friend-expenses/%d", apiV1, friendCreated.ExpenseID), nil, http.StatusNoContent)
+	c.do(owner, "POST", apiV1+"/friends/{id}/settle", apiV1+"/friends/"+friend+"/settle", nil, http.StatusOK)
+	c.do(owner, "DELETE", apiV1+"/friends/{id}", apiV1+"/friends/"+friend, nil, http.StatusNoContent)
+	c.do(joiner, "DELETE", apiV1+"/friends/{id}", apiV1+"/friends/"+leaver, nil, http.StatusNoContent)
+
+	if missing := c.uncalled(); len(missing) > 0 {
+		t.Errorf("dokümandaki %d işlem çağrılmadı:\n  %s", len(missing), strings.Join(missing, "\n  "))
+	}
+}
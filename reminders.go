@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+const (
+	reminderTypeScheduled = "scheduled"
+	reminderTypeNudge     = "nudge"
+
+	// Alacaklı aynı borçluyu en fazla günde bir kez dürtebilir
+	nudgeCooldown = 24 * time.Hour
+)
+
+var (
+	errNoOutstandingDebt = newDomainError(ErrValidation, "error.reminder.no_debt", "bu kullanıcının size ödenmemiş borcu yok")
+	errNudgeRateLimited  = newDomainError(ErrRateLimited, "error.reminder.rate_limited", "bu kullanıcıya son 24 saat içinde zaten hatırlatma gönderildi")
+)
+
+type debtPair struct {
+	groupID    int64
+	creditorID string
+	debtorID   string
+}
+
+// netOutstandingDebts karşılıklı borçları birbirinden düşer, sadece net pozitif borçları bırakır.
+// PayGroupExpense iki yönü birlikte ödendi işaretlediği için hatırlatma da net tutar üzerinden yapılır.
+func netOutstandingDebts(debts []OutstandingDebt) []OutstandingDebt {
+	byPair := make(map[debtPair]OutstandingDebt, len(debts))
+	for _, d := range debts {
+		byPair[debtPair{d.GroupID, d.CreditorID, d.DebtorID}] = d
+	}
+
+	var result []OutstandingDebt
+	for _, d := range debts {
+		if reverse, ok := byPair[debtPair{d.GroupID, d.DebtorID, d.CreditorID}]; ok {
+			d.Amount -= reverse.Amount
+		}
+		d.Amount = math.Round(d.Amount*100) / 100
+		if d.Amount > 0 {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// sendPaymentReminder cooldown içinde aynı türde hatırlatma gönderilmediyse borçluya bildirim
+// gönderir; gönderildiyse true döner
+func sendPaymentReminder(ctx context.Context, repo Repository, debt OutstandingDebt, reminderType string, cooldown time.Duration) (bool, error) {
+	reserved, err := repo.reservePaymentReminder(ctx, debt, reminderType, cooldown)
+	if err != nil || !reserved {
+		return false, err
+	}
+
+	params := map[string]string{
+		"name":     debt.CreditorName,
+		"group":    debt.GroupName,
+		"amount":   fmt.Sprintf("%.2f", debt.Amount),
+		"currency": debt.Currency,
+	}
+	data := map[string]string{
+		"type":     "payment_reminder",
+		"group_id": fmt.Sprintf("%d", debt.GroupID),
+	}
+
+	// Hatırlatma kayıtlı; push gönderilemese de bildirim gelen kutusuna düşer
+	if err := SendNotification(ctx, repo, debt.DebtorID, "notification.payment_reminder.title", "notification.payment_reminder.body", params, data); err != nil {
+		log.Printf("Hatırlatma bildirimi gönderilemedi (debtor=%s): %v", debt.DebtorID, err)
+	}
+	return true, nil
+}
+
+// runPaymentReminders hatırlatmaları açık gruplarda süresi gelen borçlulara bildirim gönderir
+func runPaymentReminders(ctx context.Context, repo Repository) error {
+	settings, err := repo.getEnabledReminderSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+
+	debts, err := repo.getOutstandingDebts(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	sent := 0
+	for _, debt := range netOutstandingDebts(debts) {
+		s, ok := settings[debt.GroupID]
+		if !ok {
+			continue
+		}
+
+		// İlk hatırlatma, en eski ödenmemiş harcamadan N gün sonra
+		firstDue := time.Unix(debt.OldestUnpaid, 0).Add(time.Duration(s.FirstReminderDays) * 24 * time.Hour)
+		if now.Before(firstDue) {
+			continue
+		}
+
+		// Sonrakiler her repeat_every_days günde bir
+		ok, err := sendPaymentReminder(ctx, repo, debt, reminderTypeScheduled, time.Duration(s.RepeatEveryDays)*24*time.Hour)
+		if err != nil {
+			return err
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	if sent > 0 {
+		log.Printf("Ödeme hatırlatması gönderildi: %d", sent)
+	}
+	return nil
+}
+
+// nudgeDebtor alacaklının borçluya elle hatırlatma göndermesini sağlar
+func nudgeDebtor(ctx context.Context, repo Repository, groupID int64, creditorID, debtorID string) (*OutstandingDebt, error) {
+	debts, err := repo.getOutstandingDebts(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var debt *OutstandingDebt
+	for _, d := range netOutstandingDebts(debts) {
+		if d.CreditorID == creditorID && d.DebtorID == debtorID {
+			d := d
+			debt = &d
+			break
+		}
+	}
+	if debt == nil {
+		return nil, errNoOutstandingDebt
+	}
+
+	sent, err := sendPaymentReminder(ctx, repo, *debt, reminderTypeNudge, nudgeCooldown)
+	if err != nil {
+		return nil, err
+	}
+	if !sent {
+		return nil, errNudgeRateLimited
+	}
+	return debt, nil
+}
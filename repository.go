@@ -6,20 +6,34 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type KasaRepository struct {
 	DB *sql.DB
+	// dialect veritabanına özgü SQL parçalarını seçer; sıfır değeri MySQL'dir
+	dialect sqlDialect
 }
 
-func (repo *KasaRepository) CreateUser(id, username, email, hashedPassword string, iban string) error {
-	log.Println("Kullanıcı oluşturuluyor:", id, username, email, hashedPassword, iban)
-	_, err := repo.DB.Exec("INSERT INTO users (id, fullname, email, password_hash, iban) VALUES (?, ?, ?, ?, ?)", id, username, email, hashedPassword, iban)
+func (repo *KasaRepository) CreateUser(id, username, email, hashedPassword string, iban string, locale string) error {
+	log.Println("Kullanıcı oluşturuluyor:", id, locale)
+	_, err := repo.DB.Exec("INSERT INTO users (id, fullname, email, password_hash, iban, locale) VALUES (?, ?, ?, ?, ?, ?)", id, username, email, hashedPassword, iban, locale)
 	return err
 }
 
-func (repo *KasaRepository) CreateGroup(creatorID, groupName string, groupToken string) (int64, error) {
-	result, err := repo.DB.Exec("INSERT INTO groups (group_name, creator_id, group_token) VALUES (?, ?, ?)", groupName, creatorID, groupToken)
+func (repo *KasaRepository) CreateGroup(creatorID, groupName string, groupToken string, currency string) (int64, error) {
+	ctx := context.Background()
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("Transaction başlatılamadı:", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO groups (group_name, creator_id, group_token, currency) VALUES (?, ?, ?, ?)", groupName, creatorID, groupToken, currency)
 	if err != nil {
 		log.Println("Grup oluşturma hatası:", err)
 		return 0, err
@@ -30,155 +44,81 @@ func (repo *KasaRepository) CreateGroup(creatorID, groupName string, groupToken
 		return 0, err
 	}
 
-	_, err = repo.DB.Exec("INSERT INTO group_members (group_id, user_id) VALUES (?, ?)", groupID, creatorID)
+	_, err = tx.ExecContext(ctx, "INSERT INTO group_members (group_id, user_id) VALUES (?, ?)", groupID, creatorID)
 	if err != nil {
 		log.Println("Grup üyesi ekleme hatası:", err)
 		return 0, err
 	}
 
+	// group_token grubun varsayılan davet bağlantısıdır; diğer bağlantılar gibi iptal edilip yenilenebilir
+	_, err = tx.ExecContext(ctx, "INSERT INTO group_invite_links (group_id, token, created_by) VALUES (?, ?, ?)", groupID, groupToken, creatorID)
+	if err != nil {
+		log.Println("Davet bağlantısı oluşturulamadı:", err)
+		return 0, err
+	}
+
+	if err := touchUsers(ctx, tx, creatorID); err != nil {
+		return 0, err
+	}
+
+	err = logGroupActivity(ctx, tx, groupID, creatorID, activityGroupCreated, "group", fmt.Sprint(groupID), nil, map[string]interface{}{
+		"group_name": groupName,
+		"creator_id": creatorID,
+		"currency":   currency,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("Transaction commit edilemedi:", err)
+		return 0, err
+	}
 	return groupID, nil
 }
 
-func (repo *KasaRepository) GetUserByEmail(email string) (*sql.Rows, error) {
-	return repo.DB.Query("SELECT id FROM users WHERE email = ?", email)
-}
-
-func (repo *KasaRepository) getMyGroups(userID string) (*sql.Rows, error) {
-	return repo.DB.Query(`
-		SELECT 
-			g.id AS group_id,
-			g.group_token AS group_token,
-			g.group_name,
-			UNIX_TIMESTAMP(g.created_at) AS created_ts,
-			u.id AS creator_id,
-			u.fullname AS creator_name,
-			u.email AS creator_email,
-
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'id', gm_user.id,
-						'fullname', gm_user.fullname,
-						'email', gm_user.email,
-						'total_share', COALESCE((
-							SELECT SUM(p.amount_share)
-							FROM group_expenses e
-							JOIN group_expense_participants p ON p.expense_id = e.expense_id
-							WHERE e.group_id = g.id AND p.user_id = gm_user.id
-						), 0)
-					)
-				)
-				FROM group_members gm
-				JOIN users gm_user ON gm.user_id = gm_user.id
-				WHERE gm.group_id = g.id
-			) AS members,
-
-			(
-				SELECT JSON_ARRAYAGG(JSON_OBJECT(
-					'request_id', r.request_id,
-					'user_id', r.user_id,
-					'fullname', ru.fullname,
-					'email', ru.email,
-					'requested_at', UNIX_TIMESTAMP(r.requested_at),
-					'request_status', r.request_status,
-					'group_name', gr.group_name,
-					'group_id', gr.id
-				))
-				FROM group_add_requests r
-				JOIN users ru ON r.user_id = ru.id
-				JOIN groups gr ON r.group_id = gr.id 
-				WHERE r.group_id = g.id AND r.request_status = 'pending'
-			) AS pending_requests,
-
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'expense_id', e.expense_id,
-						'group_id', e.group_id,
-						'amount', e.amount,
-						'description_note', e.description_note,
-						'payment_date', UNIX_TIMESTAMP(e.payment_date),
-						'payment_title', e.payment_title,
-						'bill_image_url', e.bill_image_url,
-						'payer_id', e.payer_id,
-						'payer_name', pu.fullname,
-						'participants', (
-							SELECT JSON_ARRAYAGG(
-								JSON_OBJECT(
-									'user_id', p.user_id,
-									'user_name', u.fullname,
-									'amount_share', p.amount_share,
-									'payment_status', p.payment_status
-								)
-							)
-							FROM group_expense_participants p
-							JOIN users u ON p.user_id = u.id
-							WHERE p.expense_id = e.expense_id
-						)
-					)
-				)
-				FROM group_expenses e
-				LEFT JOIN users pu ON pu.id = e.payer_id
-				WHERE e.group_id = g.id
-				ORDER BY e.payment_date ASC
-			) AS expenses,
-
-			-- ✅ Borçlu olduğun kişiler
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'user_id', e.payer_id,
-						'username', payer.fullname,
-						'iban', payer.iban,
-						'amount', p.amount_share,
-						'status', p.payment_status,
-						'expenses', JSON_ARRAY(p.expense_id)
-					)
-				)
-				FROM group_expense_participants p
-				JOIN group_expenses e ON p.expense_id = e.expense_id
-				JOIN users payer ON payer.id = e.payer_id
-				WHERE p.user_id = ? AND e.payer_id != p.user_id AND e.group_id = g.id
-			) AS debts,
-
-			-- ✅ Sana borçlu olan kişiler
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'user_id', p.user_id,
-						'username', u.fullname,
-						'iban', u.iban,
-						'amount', p.amount_share,
-						'status', p.payment_status,
-						'expenses', JSON_ARRAY(p.expense_id)
-					)
-				)
-				FROM group_expenses e
-				JOIN group_expense_participants p ON e.expense_id = p.expense_id
-				JOIN users u ON u.id = p.user_id
-				WHERE e.payer_id = ? AND p.user_id != e.payer_id AND e.group_id = g.id
-			) AS credits
+func (repo *KasaRepository) GetUserIDByEmail(email string) (string, error) {
+	var userID string
+	err := repo.DB.QueryRow("SELECT id FROM users WHERE email = ?", email).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return userID, err
+}
 
-		FROM groups g
-		JOIN users u ON g.creator_id = u.id
-		JOIN group_members gm ON g.id = gm.group_id
-		WHERE gm.user_id = ?
-		GROUP BY g.id
-		ORDER BY g.created_at DESC
-	`, userID, userID, userID) // 3 kez userID: debts, credits ve WHERE gm.user_id
+// Kullanıcının kayıtlı dilini getir, kayıt yoksa varsayılan dil döner
+func (repo *KasaRepository) GetUserLocale(ctx context.Context, userID string) (string, error) {
+	var locale string
+	err := repo.DB.QueryRowContext(ctx, "SELECT locale FROM users WHERE id = ?", userID).Scan(&locale)
+	if err == sql.ErrNoRows {
+		return defaultLocale, nil
+	}
+	if err != nil {
+		return defaultLocale, fmt.Errorf("kullanıcı dili alınamadı: %w", err)
+	}
+	return normalizeLocale(locale), nil
+}
+
+func (repo *KasaRepository) getMyGroups(userID string) ([]*Group, error) {
+	return groupLoader{dialect: repo.dialect}.load(context.Background(), repo.DB, memberGroupsScope(userID), userID)
 }
 
-func (repo *KasaRepository) sendAddGroupRequest(groupID, addedMemberEmail, currentUserID string) (*sql.Row, error) {
+func (repo *KasaRepository) sendAddGroupRequest(groupID, addedMemberEmail, currentUserID string) (*Group, *EmailInvitation, error) {
 	// Email'e karşılık gelen kullanıcı ID'sini al
 	var addedMemberID string
-	err := repo.DB.QueryRow("SELECT id FROM users WHERE email = ?", addedMemberEmail).Scan(&addedMemberID)
+	err := repo.DB.QueryRow("SELECT id FROM users WHERE email = ? AND is_placeholder = FALSE", addedMemberEmail).Scan(&addedMemberID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			log.Printf("Kullanıcı bulunamadı: %s\n", addedMemberEmail)
-			return nil, fmt.Errorf("kullanıcı bulunamadı: %s", addedMemberEmail)
+			// Hesabı olmayan kişiye email ile davet açılır; kayıt olduğunda normal isteğe dönüşür
+			invitation, err := repo.createEmailInvitation(context.Background(), groupID, addedMemberEmail, currentUserID)
+			if err != nil {
+				return nil, nil, err
+			}
+			group, err := repo.getGroupDetails(groupID, currentUserID)
+			return group, invitation, err
 		}
 		log.Println("Kullanıcı kontrolü sırasında hata:", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Kullanıcı zaten grup üyesi mi?
@@ -188,10 +128,10 @@ func (repo *KasaRepository) sendAddGroupRequest(groupID, addedMemberEmail, curre
 		WHERE group_id = ? AND user_id = ?
 	`, groupID, addedMemberID).Scan(&memberCount)
 	if err != nil {
-		return nil, fmt.Errorf("üye kontrolü sırasında hata: %v", err)
+		return nil, nil, fmt.Errorf("üye kontrolü sırasında hata: %v", err)
 	}
 	if memberCount > 0 {
-		return nil, fmt.Errorf("bu kullanıcı zaten grup üyesi")
+		return nil, nil, errAlreadyMember
 	}
 
 	// Aynı istek zaten varsa tekrar ekleme
@@ -201,196 +141,156 @@ func (repo *KasaRepository) sendAddGroupRequest(groupID, addedMemberEmail, curre
 		WHERE group_id = ? AND user_id = ? AND request_status = 'pending'
 	`, groupID, addedMemberID).Scan(&requestCount)
 	if err != nil {
-		return nil, fmt.Errorf("mevcut istek kontrolü sırasında hata: %v", err)
+		return nil, nil, fmt.Errorf("mevcut istek kontrolü sırasında hata: %v", err)
 	}
 	if requestCount > 0 {
-		return nil, fmt.Errorf("bu kullanıcıya zaten bekleyen bir istek gönderilmiş")
+		return nil, nil, errAddRequestExists
 	}
 
 	// Grup ekleme isteğini gönder
-	_, err = repo.DB.Exec(
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Println("Transaction başlatılamadı:", err)
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
 		"INSERT INTO group_add_requests (group_id, user_id) VALUES (?, ?)",
 		groupID, addedMemberID,
 	)
 	if err != nil {
 		log.Println("Grup ekleme isteği gönderilemedi:", err)
-		return nil, err
+		return nil, nil, err
+	}
+	requestID, err := res.LastInsertId()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Güncel grup bilgilerini çek
-	row := repo.DB.QueryRow(`
-		SELECT 
-			g.id AS group_id,
-			g.group_token AS group_token,
-			g.group_name,
-			UNIX_TIMESTAMP(g.created_at) AS created_ts,
-			u.id AS creator_id,
-			u.fullname AS creator_name,
-			u.email AS creator_email,
-
-			-- üyeler
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'id', gm_user.id,
-						'fullname', gm_user.fullname,
-						'email', gm_user.email,
-						'total_share', COALESCE((
-							SELECT SUM(p.amount_share)
-							FROM group_expenses e
-							JOIN group_expense_participants p ON p.expense_id = e.expense_id
-							WHERE e.group_id = g.id AND p.user_id = gm_user.id
-						), 0)
-					)
-				)
-				FROM group_members gm
-				JOIN users gm_user ON gm.user_id = gm_user.id
-				WHERE gm.group_id = g.id
-			) AS members,
-
-			-- istekler
-			(
-				SELECT JSON_ARRAYAGG(JSON_OBJECT(
-					'request_id', r.request_id,
-					'user_id', r.user_id,
-					'fullname', ru.fullname,
-					'email', ru.email,
-					'requested_at', UNIX_TIMESTAMP(r.requested_at),
-					'request_status', r.request_status,
-					'group_name', gr.group_name,
-					'group_id', gr.id
-				))
-				FROM group_add_requests r
-				JOIN users ru ON r.user_id = ru.id
-				JOIN groups gr ON r.group_id = gr.id 
-				WHERE r.group_id = g.id AND r.request_status = 'pending'
-			) AS pending_requests,
-
-			-- harcamalar
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'expense_id', e.expense_id,
-						'group_id', e.group_id,
-						'amount', e.amount,
-						'description_note', e.description_note,
-						'payment_date', UNIX_TIMESTAMP(e.payment_date),
-						'payment_title', e.payment_title,
-						'bill_image_url', e.bill_image_url,
-						'payer_id', e.payer_id,
-						'payer_name', pu.fullname,
-						'participants', (
-							SELECT JSON_ARRAYAGG(
-								JSON_OBJECT(
-									'user_id', p.user_id,
-									'user_name', u.fullname,
-									'amount_share', p.amount_share,
-									'payment_status', p.payment_status
-								)
-							)
-							FROM group_expense_participants p
-							JOIN users u ON p.user_id = u.id
-							WHERE p.expense_id = e.expense_id
-						)
-					)
-				)
-				FROM group_expenses e
-				LEFT JOIN users pu ON pu.id = e.payer_id
-				WHERE e.group_id = g.id
-				ORDER BY e.payment_date ASC
-			) AS expenses,
-
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'user_id', e.payer_id,
-						'username', payer.fullname,
-						'iban', payer.iban,
-						'amount', p.amount_share,
-						'status', p.payment_status,
-						'expenses', JSON_ARRAY(p.expense_id)
-					)
-				)
-				FROM group_expense_participants p
-				JOIN group_expenses e ON p.expense_id = e.expense_id
-				JOIN users payer ON payer.id = e.payer_id
-				WHERE p.user_id = ? AND e.payer_id != p.user_id AND e.group_id = g.id
-			) AS debts,
-
-			-- ✅ Sana borçlu olan kişiler
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'user_id', p.user_id,
-						'username', u.fullname,
-						'iban', u.iban,
-						'amount', p.amount_share,
-						'status', p.payment_status,
-						'expenses', JSON_ARRAY(p.expense_id)
-					)
-				)
-				FROM group_expenses e
-				JOIN group_expense_participants p ON e.expense_id = p.expense_id
-				JOIN users u ON u.id = p.user_id
-				WHERE e.payer_id = ? AND p.user_id != e.payer_id AND e.group_id = g.id
-			) AS credits
+	groupIDInt, err := strconv.ParseInt(groupID, 10, 64)
+	if err != nil {
+		return nil, nil, errInvalidGroupID
+	}
+	if err := touchGroupMembers(context.Background(), tx, groupIDInt); err != nil {
+		return nil, nil, err
+	}
+	err = logGroupActivity(context.Background(), tx, groupIDInt, currentUserID, activityRequestSent, "add_request", fmt.Sprint(requestID), nil, map[string]interface{}{
+		"request_id": requestID,
+		"user_id":    addedMemberID,
+		"email":      addedMemberEmail,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-		FROM groups g
-		JOIN users u ON g.creator_id = u.id
-		WHERE g.id = ?
-	`, currentUserID, currentUserID, groupID)
+	if err := tx.Commit(); err != nil {
+		log.Println("Transaction commit edilemedi:", err)
+		return nil, nil, err
+	}
+
+	// Güncel grup bilgilerini çek
+	group, err := repo.getGroupDetails(groupID, currentUserID)
+	return group, nil, err
+}
 
-	return row, nil
+// getGroupDetails grubu üyeleri, bekleyen istekleri, harcamaları ve kullanıcının borç/alacaklarıyla döner
+func (repo *KasaRepository) getGroupDetails(groupID, currentUserID string) (*Group, error) {
+	return groupLoader{dialect: repo.dialect}.loadGroupDetails(context.Background(), repo.DB, groupID, currentUserID)
 }
 
-func (repo *KasaRepository) getMyAddRequests(userID string) (*sql.Rows, error) {
+func (repo *KasaRepository) getMyAddRequests(userID string) ([]MyAddRequest, error) {
 	rows, err := repo.DB.Query(`
-		SELECT gar.request_id, gar.group_id, g.group_name, UNIX_TIMESTAMP(gar.requested_at), gar.request_status
+		SELECT gar.request_id, gar.group_id, g.group_name, `+repo.dialect.unix("gar.requested_at")+`, gar.request_status
 		FROM group_add_requests gar
 		JOIN groups g ON gar.group_id = g.id
-		WHERE gar.user_id = ?
-		ORDER BY gar.requested_at DESC
+		WHERE gar.user_id = ? AND gar.request_direction = 'invite'
+		ORDER BY gar.requested_at DESC, gar.request_id DESC
 	`, userID)
 
 	if err != nil {
 		log.Println("Grup ekleme istekleri alınamadı:", err)
 		return nil, err
 	}
-	return rows, nil
+	defer rows.Close()
+
+	requests := make([]MyAddRequest, 0)
+	for rows.Next() {
+		var req MyAddRequest
+		if err := rows.Scan(&req.RequestID, &req.GroupID, &req.GroupName, &req.RequestedAt, &req.RequestStatus); err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
 }
 
-func (repo *KasaRepository) acceptAddRequest(requestID int64, userID string) error {
+// AddRequestDecision yanıtlanan isteğin hangi gruba ve kullanıcıya ait olduğunu taşır;
+// katılım isteklerinde kararı kullanıcıya bildirmek için kullanılır.
+type AddRequestDecision struct {
+	GroupID   int64
+	GroupName string
+	UserID    string
+	Direction string
+}
+
+func (repo *KasaRepository) acceptAddRequest(requestID int64, userID string) (*AddRequestDecision, error) {
 	tx, err := repo.DB.Begin()
 	if err != nil {
 		log.Println("Transaction başlatılamadı:", err)
-		return err
+		return nil, err
 	}
 
 	var groupID int64
-	var reqUserID string
+	var reqUserID, direction, creatorID, groupName string
+	var linkID sql.NullInt64
 
 	// 1. Gerekli bilgileri al (sadece 'pending' durumundaki istekler işlenir)
 	err = tx.QueryRow(`
-		SELECT group_id, user_id 
-		FROM group_add_requests 
-		WHERE request_id = ? AND request_status = 'pending'
-	`, requestID).Scan(&groupID, &reqUserID)
+		SELECT r.group_id, r.user_id, r.request_direction, r.invite_link_id, g.creator_id, g.group_name
+		FROM group_add_requests r
+		JOIN groups g ON g.id = r.group_id
+		WHERE r.request_id = ? AND r.request_status = 'pending'
+		`+repo.dialect.forUpdate()+`
+	`, requestID).Scan(&groupID, &reqUserID, &direction, &linkID, &creatorID, &groupName)
 
 	if err == sql.ErrNoRows {
 		tx.Rollback()
 		log.Printf("Geçersiz ya da işlenmiş istek: request_id=%d\n", requestID)
-		return fmt.Errorf("bu istek zaten işlenmiş veya mevcut değil")
+		return nil, errAddRequestNotFound
 	} else if err != nil {
 		tx.Rollback()
 		log.Println("Grup ID veya kullanıcı ID alınamadı:", err)
-		return err
+		return nil, err
 	}
 
-	// 2. userID doğruluğunu kontrol et
-	if reqUserID != userID {
+	// 2. userID doğruluğunu kontrol et: davetleri davet edilen, katılım isteklerini yönetici yanıtlar
+	if !canRespondAddRequest(direction, userID, reqUserID, creatorID) {
 		tx.Rollback()
 		log.Printf("Yetkisiz işlem: parametre userID '%s' != veritabanı userID '%s'\n", userID, reqUserID)
-		return fmt.Errorf("yetkisiz işlem: kullanıcı uyuşmazlığı")
+		return nil, errAddRequestForbidden
+	}
+
+	// Kullanıcı başka bir yoldan (ör. davet bağlantısı) katılmışsa istek kapatılır, üyelik tekrar eklenmez
+	var memberCount int
+	err = tx.QueryRow("SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_id = ?", groupID, reqUserID).Scan(&memberCount)
+	if err != nil {
+		tx.Rollback()
+		log.Println("Üye kontrolü sırasında hata:", err)
+		return nil, err
+	}
+	if memberCount > 0 {
+		_, err = tx.Exec("UPDATE group_add_requests SET request_status = 'cancelled', cancelled_by = ? WHERE request_id = ?", userID, requestID)
+		if err == nil {
+			err = tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+		if err != nil {
+			log.Println("Geçersiz istek kapatılamadı:", err)
+			return nil, err
+		}
+		return nil, errAlreadyMember
 	}
 
 	// 3. İsteği 'accepted' olarak güncelle
@@ -402,53 +302,111 @@ func (repo *KasaRepository) acceptAddRequest(requestID int64, userID string) err
 	if err != nil {
 		tx.Rollback()
 		log.Println("Grup ekleme isteği güncellenemedi:", err)
-		return err
+		return nil, err
 	}
 
 	// 4. Kullanıcıyı gruba ekle
 	_, err = tx.Exec(`
 		INSERT INTO group_members (group_id, user_id) 
 		VALUES (?, ?)
-	`, groupID, userID)
+	`, groupID, reqUserID)
 	if err != nil {
 		tx.Rollback()
 		log.Println("Kullanıcı gruba eklenemedi:", err)
-		return err
+		return nil, err
+	}
+
+	ctx := context.Background()
+	via := "add_request"
+	if direction == requestDirectionJoin {
+		via = "join_request"
+	}
+
+	// Davet bağlantısıyla gelen katılım isteği onaylandıysa kullanım bağlantıya yazılır
+	if linkID.Valid {
+		if err := recordInviteLinkUseTx(ctx, tx, linkID.Int64, reqUserID); err != nil {
+			tx.Rollback()
+			log.Println("Davet bağlantısı kullanımı yazılamadı:", err)
+			return nil, err
+		}
+	}
+
+	// Yeni üye dahil tüm üyelerin grup listesi değişti
+	if err := touchGroupMembers(ctx, tx, groupID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Aktivite kaydı
+	err = logGroupActivity(ctx, tx, groupID, userID, activityRequestAccepted, "add_request", fmt.Sprint(requestID),
+		map[string]interface{}{"request_status": "pending"},
+		map[string]interface{}{"request_status": "accepted"})
+	if err == nil {
+		err = logGroupActivity(ctx, tx, groupID, reqUserID, activityMemberJoined, "member", reqUserID, nil,
+			map[string]interface{}{"user_id": reqUserID, "via": via, "request_id": requestID})
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Println("Aktivite kaydı yazılamadı:", err)
+		return nil, err
+	}
+
+	// Bu email için açılmış geçici üyeler varsa kullanıcıya devret
+	if _, err := claimMatchingPlaceholdersTx(ctx, tx, groupID, reqUserID); err != nil {
+		tx.Rollback()
+		log.Println("Geçici üyeler birleştirilemedi:", err)
+		return nil, err
 	}
 
 	// 5. Commit işlemi
 	err = tx.Commit()
 	if err != nil {
 		log.Println("Transaction commit edilemedi:", err)
-		return err
+		return nil, err
 	}
 
-	log.Printf("✅ İstek kabul edildi: request_id=%d, user_id=%s\n", requestID, userID)
-	return nil
+	log.Printf("✅ İstek kabul edildi: request_id=%d, user_id=%s\n", requestID, reqUserID)
+	return &AddRequestDecision{GroupID: groupID, GroupName: groupName, UserID: reqUserID, Direction: direction}, nil
 }
 
-func (repo *KasaRepository) rejectAddRequest(requestID int64, userID string) error {
+func (repo *KasaRepository) rejectAddRequest(requestID int64, userID string) (*AddRequestDecision, error) {
 	tx, err := repo.DB.Begin()
 	if err != nil {
 		log.Println("Transaction başlatılamadı:", err)
-		return err
+		return nil, err
 	}
 
-	var reqUserID string
+	var reqUserID, reqStatus, direction, creatorID, groupName string
+	var groupID int64
 
 	// 1. İstek sahibi kim kontrol et
-	err = tx.QueryRow("SELECT user_id FROM group_add_requests WHERE request_id = ?", requestID).Scan(&reqUserID)
-	if err != nil {
+	err = tx.QueryRow(`
+		SELECT r.user_id, r.group_id, r.request_status, r.request_direction, g.creator_id, g.group_name
+		FROM group_add_requests r
+		JOIN groups g ON g.id = r.group_id
+		WHERE r.request_id = ?
+		`+repo.dialect.forUpdate()+`
+	`, requestID).Scan(&reqUserID, &groupID, &reqStatus, &direction, &creatorID, &groupName)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return nil, errAddRequestNotFound
+	} else if err != nil {
 		tx.Rollback()
 		log.Println("İstek bilgisi alınamadı:", err)
-		return err
+		return nil, err
+	}
+
+	// İptal edilmiş, süresi dolmuş ya da yanıtlanmış istekler tekrar reddedilemez
+	if reqStatus != "pending" {
+		tx.Rollback()
+		return nil, errAddRequestNotFound
 	}
 
 	// 2. userID doğruluğunu kontrol et
-	if reqUserID != userID {
+	if !canRespondAddRequest(direction, userID, reqUserID, creatorID) {
 		tx.Rollback()
 		log.Printf("Yetkisiz işlem: parametre userID '%s' != veritabanı userID '%s'\n", userID, reqUserID)
-		return fmt.Errorf("yetkisiz işlem: kullanıcı uyuşmazlığı")
+		return nil, errAddRequestForbidden
 	}
 
 	// 3. İsteği 'rejected' olarak güncelle
@@ -456,82 +414,258 @@ func (repo *KasaRepository) rejectAddRequest(requestID int64, userID string) err
 	if err != nil {
 		tx.Rollback()
 		log.Println("Grup ekleme isteği reddedilemedi:", err)
-		return err
+		return nil, err
+	}
+
+	if err := touchGroupMembers(context.Background(), tx, groupID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = logGroupActivity(context.Background(), tx, groupID, userID, activityRequestRejected, "add_request", fmt.Sprint(requestID),
+		map[string]interface{}{"request_status": reqStatus},
+		map[string]interface{}{"request_status": "rejected"})
+	if err != nil {
+		tx.Rollback()
+		log.Println("Aktivite kaydı yazılamadı:", err)
+		return nil, err
 	}
 
 	// 4. Commit işlemi
 	err = tx.Commit()
 	if err != nil {
 		log.Println("Transaction commit edilemedi:", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &AddRequestDecision{GroupID: groupID, GroupName: groupName, UserID: reqUserID, Direction: direction}, nil
 }
 
 type ExpenseWithParticipants struct {
-	ExpenseID       int64           `json:"expense_id"`
-	GroupID         int64           `json:"group_id"`
-	PayerID         string          `json:"payer_id"`
-	PayerName       string          `json:"payer_name"`
-	Amount          float64         `json:"amount"`
-	DescriptionNote string          `json:"description_note"`
-	PaymentTitle    string          `json:"payment_title"`
-	PaymentDate     int64           `json:"payment_date"`
-	BillImageURL    string          `json:"bill_image_url"`
-	Participants    json.RawMessage `json:"participants"`
+	ExpenseID       int64         `json:"expense_id"`
+	GroupID         int64         `json:"group_id"`
+	PayerID         string        `json:"payer_id"`
+	PayerName       string        `json:"payer_name"`
+	Amount          float64       `json:"amount"`
+	DescriptionNote string        `json:"description_note"`
+	PaymentTitle    string        `json:"payment_title"`
+	PaymentDate     int64         `json:"payment_date"`
+	BillImageURL    string        `json:"bill_image_url"`
+	Participants    []Participant `json:"participants"`
 }
 type ExpenseWithParticipantsAndBalances struct {
 	Expense ExpenseWithParticipants `json:"expense"`
-	Debts   json.RawMessage         `json:"debts"`
-	Credits json.RawMessage         `json:"credits"`
+	Debts   []Debt                  `json:"debts"`
+	Credits []Credit                `json:"credits"`
+}
+
+// decodeJSONList JSON_ARRAYAGG sonucunu dest dilimine çözer. Toplanacak satır yoksa sonuç
+// NULL'dır; bu durumda dest'e dokunulmaz, çağıran boş dilimle başlatır ve yanıtta [] döner.
+func decodeJSONList(raw sql.NullString, dest interface{}) error {
+	if !raw.Valid || raw.String == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw.String), dest); err != nil {
+		return fmt.Errorf("JSON listesi çözülemedi: %w", err)
+	}
+	return nil
 }
 
 func (repo *KasaRepository) createGroupExpense(ctx context.Context, payerID string, req CreateExpenseRequest) (*ExpenseWithParticipantsAndBalances, error) {
-	tx, err := repo.DB.Begin()
+	tx, err := repo.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("transaction başlatılamadı: %w", err)
 	}
+	defer tx.Rollback()
+
+	if err := checkExpenseParticipants(ctx, tx, int64(req.GroupID), payerID, req.Users); err != nil {
+		return nil, err
+	}
+
+	expense, err := repo.createGroupExpenseTx(ctx, tx, payerID, payerID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Gruba ait debts ve credits çek
+	debts, credits, err := repo.loadGroupBalances(ctx, tx, payerID, int64(req.GroupID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+
+	// ✔️ Sonuç yapısı
+	return &ExpenseWithParticipantsAndBalances{
+		Expense: *expense,
+		Debts:   debts,
+		Credits: credits,
+	}, nil
+}
 
-	var txErr error
-	defer func() {
-		if txErr != nil {
-			_ = tx.Rollback()
+var (
+	errPayerNotParticipant  = newDomainError(ErrValidation, "error.expense.payer_not_participant", "ödeyen kişi katılımcılar arasında olmalı")
+	errParticipantNeeded    = newDomainError(ErrValidation, "error.expense.participant_needed", "ödeyen dışında en az bir katılımcı olmalı")
+	errParticipantNotMember = newDomainError(ErrValidation, "error.expense.participant_not_member", "katılımcılar grup üyesi olmalı")
+)
+
+// checkExpensePayer ödeyenin katılımcılar arasında olduğunu ve ödeyen dışında en az bir katılımcı
+// bulunduğunu kontrol eder; grup ve arkadaş harcamaları için ortaktır
+func checkExpensePayer(payerID string, users []ExpenseUser) error {
+	payerIncluded, hasOther := false, false
+	for _, u := range users {
+		if u.UserID == payerID {
+			payerIncluded = true
 		} else {
-			txErr = tx.Commit()
+			hasOther = true
 		}
-	}()
+	}
+	if !payerIncluded {
+		return errPayerNotParticipant
+	}
+	if !hasOther {
+		return errParticipantNeeded
+	}
+	return nil
+}
 
-	// Harcama Ekle
-	result, txErr := tx.ExecContext(ctx, `
-		INSERT INTO group_expenses (group_id, payer_id, amount, description_note, payment_title, bill_image_url, payment_date)
-		VALUES (?, ?, ?, ?, ?, ?, NOW())
-	`, req.GroupID, payerID, req.TotalAmount, req.Note, req.PaymentTitle, req.BillImageURL)
-	if txErr != nil {
-		return nil, fmt.Errorf("harcama eklenemedi: %w", txErr)
+// checkExpenseParticipants checkExpensePayer'a ek olarak tüm katılımcıların grup üyesi olduğunu
+// kontrol eder. Sorgu MySQL ve SQLite'ta aynıdır.
+func checkExpenseParticipants(ctx context.Context, tx *sql.Tx, groupID int64, payerID string, users []ExpenseUser) error {
+	if groupID <= 0 {
+		return errInvalidGroupID
+	}
+	if err := checkExpensePayer(payerID, users); err != nil {
+		return err
 	}
 
-	expenseID, txErr := result.LastInsertId()
-	if txErr != nil {
-		return nil, fmt.Errorf("expense ID alınamadı: %w", txErr)
+	rows, err := tx.QueryContext(ctx, "SELECT user_id FROM group_members WHERE group_id = ?", groupID)
+	if err != nil {
+		return fmt.Errorf("grup üyeleri alınamadı: %w", err)
+	}
+	defer rows.Close()
+	members := map[string]bool{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return err
+		}
+		members[userID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		if !members[u.UserID] {
+			return errParticipantNotMember
+		}
 	}
+	return nil
+}
+
+// expenseColumns harcamayı ExpenseWithParticipants alan sırasıyla seçer (e harcama, u ödeyen)
+func expenseColumns(d sqlDialect) string {
+	return `
+		e.expense_id, COALESCE(e.group_id, 0), e.payer_id, u.fullname,
+		e.amount, e.description_note, e.payment_title, ` + d.unix("e.payment_date") + `, e.bill_image_url`
+}
+
+// participantsJSON e harcamasının katılımcılarını JSON dizisi olarak seçen alt sorgudur;
+// katılımcı yoksa NULL döner.
+func participantsJSON(d sqlDialect) string {
+	return `(
+		SELECT ` + d.jsonArrayAgg(`JSON_OBJECT(
+			'user_id', ep.user_id,
+			'user_name', uu.fullname,
+			'amount_share', ep.amount_share,
+			'payment_status', ep.payment_status
+		)`) + `
+		FROM group_expense_participants ep
+		LEFT JOIN users uu ON uu.id = ep.user_id
+		WHERE ep.expense_id = e.expense_id
+	)`
+}
 
+// balancesJSON kullanıcının bir gruptaki borç (debts) ve alacak (credits) kolonlarıdır (silinmiş
+// harcamalar hariç). Parametreler sırasıyla borçlar için kullanıcı ID'si, alacaklar için
+// kullanıcı ID'sidir; groupExpr grup ID'sinin ifadesidir ("?" ise kullanıcıdan sonra bağlanır).
+func balancesJSON(d sqlDialect, groupExpr string) string {
+	return `(
+			SELECT ` + d.jsonArrayAgg(`JSON_OBJECT(
+				'user_id', e.payer_id,
+				'username', payer.fullname,
+				'iban', payer.iban,
+				'amount', p.amount_share,
+				'status', p.payment_status,
+				'expenses', JSON_ARRAY(p.expense_id)
+			)`) + `
+			FROM group_expense_participants p
+			JOIN group_expenses e ON p.expense_id = e.expense_id
+			JOIN users payer ON payer.id = e.payer_id
+			WHERE p.user_id = ? AND e.payer_id != p.user_id AND e.group_id = ` + groupExpr + ` AND e.deleted_at IS NULL
+		) AS debts,
+		(
+			SELECT ` + d.jsonArrayAgg(`JSON_OBJECT(
+				'user_id', p.user_id,
+				'username', u.fullname,
+				'iban', u.iban,
+				'amount', p.amount_share,
+				'status', p.payment_status,
+				'expenses', JSON_ARRAY(p.expense_id)
+			)`) + `
+			FROM group_expenses e
+			JOIN group_expense_participants p ON e.expense_id = p.expense_id
+			JOIN users u ON u.id = p.user_id
+			WHERE e.payer_id = ? AND p.user_id != e.payer_id AND e.group_id = ` + groupExpr + ` AND e.deleted_at IS NULL
+		) AS credits`
+}
+
+// createGroupExpenseTx harcamayı ve katılımcı paylarını verilen transaction içinde ekler.
+// actorID aktivite kaydına yazılır; içe aktarmada harcamayı ekleyen ile ödeyen farklı olabilir.
+func (repo *KasaRepository) createGroupExpenseTx(ctx context.Context, tx *sql.Tx, actorID, payerID string, req CreateExpenseRequest) (*ExpenseWithParticipants, error) {
 	// Tutar kontrolü
-	var sum float64
 	for _, u := range req.Users {
 		if u.Amount == nil {
-			return nil, fmt.Errorf("katılımcı tutarı boş olamaz")
+			return nil, errShareAmountMissing
 		}
-		sum += *u.Amount
+	}
+
+	// Tarih verilmediyse şimdiki zaman
+	var paymentDate interface{}
+	if req.PaymentDate > 0 {
+		paymentDate = req.PaymentDate
+	}
+
+	// Grup dışı (arkadaşlar arası) harcamalarda group_id NULL kalır
+	var groupID interface{}
+	if req.GroupID > 0 {
+		groupID = req.GroupID
+	}
+
+	// Harcama Ekle
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO group_expenses (group_id, payer_id, amount, description_note, payment_title, bill_image_url, payment_date)
+		VALUES (?, ?, ?, ?, ?, ?, COALESCE(`+repo.dialect.fromUnix()+`, CURRENT_TIMESTAMP))
+	`, groupID, payerID, req.TotalAmount, req.Note, req.PaymentTitle, req.BillImageURL, paymentDate)
+	if err != nil {
+		return nil, fmt.Errorf("harcama eklenemedi: %w", err)
+	}
+
+	expenseID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("expense ID alınamadı: %w", err)
 	}
 
 	// Katılımcılar ekle
-	stmt, txErr := tx.PrepareContext(ctx, `
+	stmt, err := tx.PrepareContext(ctx, `
 		INSERT INTO group_expense_participants (expense_id, user_id, amount_share, payment_status)
 		VALUES (?, ?, ?, ?)
 	`)
-	if txErr != nil {
-		return nil, fmt.Errorf("participant insert hazırlanamadı: %w", txErr)
+	if err != nil {
+		return nil, fmt.Errorf("participant insert hazırlanamadı: %w", err)
 	}
 	defer stmt.Close()
 
@@ -540,32 +674,18 @@ func (repo *KasaRepository) createGroupExpense(ctx context.Context, payerID stri
 		if u.UserID == payerID {
 			status = "paid"
 		}
-		_, txErr = stmt.ExecContext(ctx, expenseID, u.UserID, *u.Amount, status)
-		if txErr != nil {
-			return nil, fmt.Errorf("katılımcı eklenemedi: %w", txErr)
+		if _, err := stmt.ExecContext(ctx, expenseID, u.UserID, *u.Amount, status); err != nil {
+			return nil, fmt.Errorf("katılımcı eklenemedi: %w", err)
 		}
 	}
 
 	// Expense ve katılımcıları getir
 	var expense ExpenseWithParticipants
 	var participantsRaw sql.NullString
-	var paymentDateUnix int64
 
-	txErr = tx.QueryRowContext(ctx, `
-		SELECT
-			e.expense_id, e.group_id, e.payer_id, u.fullname AS payer_name,
-			e.amount, e.description_note, e.payment_title, UNIX_TIMESTAMP(e.payment_date), e.bill_image_url,
-			(
-				SELECT JSON_ARRAYAGG(JSON_OBJECT(
-					'user_id', ep.user_id,
-					'user_name', uu.fullname,
-					'amount_share', ep.amount_share,
-					'payment_status', ep.payment_status
-				))
-				FROM group_expense_participants ep
-				LEFT JOIN users uu ON uu.id = ep.user_id
-				WHERE ep.expense_id = e.expense_id
-			) AS participants
+	err = tx.QueryRowContext(ctx, `
+		SELECT `+expenseColumns(repo.dialect)+`,
+			`+participantsJSON(repo.dialect)+` AS participants
 		FROM group_expenses e
 		LEFT JOIN users u ON u.id = e.payer_id
 		WHERE e.expense_id = ?
@@ -577,78 +697,30 @@ func (repo *KasaRepository) createGroupExpense(ctx context.Context, payerID stri
 		&expense.Amount,
 		&expense.DescriptionNote,
 		&expense.PaymentTitle,
-		&paymentDateUnix,
+		&expense.PaymentDate,
 		&expense.BillImageURL,
 		&participantsRaw,
 	)
-	if txErr != nil {
-		return nil, fmt.Errorf("expense okunamadı: %w", txErr)
-	}
-
-	expense.PaymentDate = paymentDateUnix
-	expense.Participants = []byte("[]")
-	if participantsRaw.Valid && participantsRaw.String != "" {
-		expense.Participants = json.RawMessage(participantsRaw.String)
+	if err != nil {
+		return nil, fmt.Errorf("expense okunamadı: %w", err)
 	}
 
-	// Gruba ait debts ve credits çek
-	var debtsRaw, creditsRaw sql.NullString
-
-	txErr = tx.QueryRowContext(ctx, `
-		SELECT 
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'user_id', e.payer_id,
-						'username', payer.fullname,
-						'iban', payer.iban,
-						'amount', p.amount_share,
-						'status', p.payment_status,
-						'expenses', JSON_ARRAY(p.expense_id)
-					)
-				)
-				FROM group_expense_participants p
-				JOIN group_expenses e ON p.expense_id = e.expense_id
-				JOIN users payer ON payer.id = e.payer_id
-				WHERE p.user_id = ? AND e.payer_id != p.user_id AND e.group_id = ?
-			) AS debts,
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'user_id', p.user_id,
-						'username', u.fullname,
-						'iban', u.iban,
-						'amount', p.amount_share,
-						'status', p.payment_status,
-						'expenses', JSON_ARRAY(p.expense_id)
-					)
-				)
-				FROM group_expenses e
-				JOIN group_expense_participants p ON e.expense_id = p.expense_id
-				JOIN users u ON u.id = p.user_id
-				WHERE e.payer_id = ? AND p.user_id != e.payer_id AND e.group_id = ?
-			) AS credits
-	`, payerID, req.GroupID, payerID, req.GroupID).Scan(&debtsRaw, &creditsRaw)
-	if txErr != nil {
-		return nil, fmt.Errorf("borç/alacak bilgileri alınamadı: %w", txErr)
+	expense.Participants = []Participant{}
+	if err := decodeJSONList(participantsRaw, &expense.Participants); err != nil {
+		return nil, err
 	}
 
-	// Güvenli JSON set et
-	debts := []byte("[]")
-	if debtsRaw.Valid && debtsRaw.String != "" {
-		debts = json.RawMessage(debtsRaw.String)
-	}
-	credits := []byte("[]")
-	if creditsRaw.Valid && creditsRaw.String != "" {
-		credits = json.RawMessage(creditsRaw.String)
+	// Aktivite kaydı ve veri sürümü (sadece grup harcamaları için)
+	if expense.GroupID > 0 {
+		if err := touchGroupMembers(ctx, tx, expense.GroupID); err != nil {
+			return nil, err
+		}
+		err = logGroupActivity(ctx, tx, expense.GroupID, actorID, activityExpenseCreated, "expense", fmt.Sprint(expense.ExpenseID), nil, expense)
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	// ✔️ Sonuç yapısı
-	return &ExpenseWithParticipantsAndBalances{
-		Expense: expense,
-		Debts:   debts,
-		Credits: credits,
-	}, nil
+	return &expense, nil
 }
 
 type User struct {
@@ -657,13 +729,16 @@ type User struct {
 	FullName string `json:"fullName"`
 	IBAN     string `json:"iban"`
 	Deleted  bool   `json:"deleted"`
+	Locale   string `json:"locale"`
+
+	DeletionStatus string `json:"deletion_status"`
 }
 
 // Kullanıcıyı ID ile al
 func (repo *KasaRepository) GetUserByID(userID string) (*User, error) {
 	var user User
-	err := repo.DB.QueryRow("SELECT id, email, fullname, iban, deleted FROM users WHERE id = ?", userID).
-		Scan(&user.ID, &user.Email, &user.FullName, &user.IBAN, &user.Deleted)
+	err := repo.DB.QueryRow("SELECT id, email, fullname, COALESCE(iban, ''), deleted, locale, deletion_status FROM users WHERE id = ?", userID).
+		Scan(&user.ID, &user.Email, &user.FullName, &user.IBAN, &user.Deleted, &user.Locale, &user.DeletionStatus)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, err
@@ -676,47 +751,136 @@ func (repo *KasaRepository) GetUserByID(userID string) (*User, error) {
 // Kullanıcıyı ekle
 func (repo *KasaRepository) InsertUser(user User) error {
 	query := `
-		INSERT INTO users (id, email, fullname, iban)
-		VALUES (?, ?, ?, ?)
-		ON DUPLICATE KEY UPDATE 
-			fullname = VALUES(fullname),
-			iban = VALUES(iban)
-	`
-	_, err := repo.DB.Exec(query, user.ID, user.Email, user.FullName, user.IBAN)
+		INSERT INTO users (id, email, fullname, iban, locale)
+		VALUES (?, ?, ?, ?, ?)
+		` + repo.dialect.upsert("", "fullname", "iban")
+	ctx := context.Background()
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	changed, err := profileChangedTx(ctx, tx, user)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, user.ID, user.Email, user.FullName, user.IBAN, normalizeLocale(user.Locale))
 	if err != nil {
 		log.Printf("InsertUser (update'li) hatası: %v", err)
+		return err
 	}
-	return err
+	if changed {
+		if err := touchUserAndGroupmates(ctx, tx, user.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 func (repo *KasaRepository) UpdateUser(user *User) error {
+	ctx := context.Background()
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
         UPDATE users 
-        SET fullname = ?, iban = ? 
+        SET fullname = ?, iban = ?, locale = ? 
         WHERE id = ?
     `
-	_, err := repo.DB.Exec(query, user.FullName, user.IBAN, user.ID)
-	return err
+	if _, err := tx.ExecContext(ctx, query, user.FullName, user.IBAN, normalizeLocale(user.Locale), user.ID); err != nil {
+		return err
+	}
+	// Ad ve IBAN diğer üyelerin grup yanıtlarında da görünür
+	if err := touchUserAndGroupmates(ctx, tx, user.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type SettledShare struct {
+	ExpenseID   int64   `json:"expense_id"`
+	PayerID     string  `json:"payer_id"`
+	UserID      string  `json:"user_id"`
+	AmountShare float64 `json:"amount_share"`
 }
 
 func (repo *KasaRepository) PayGroupExpense(userID string, sendedUserID string, groupID int64) error {
-	query := `
-		UPDATE group_expense_participants gep
+	ctx := context.Background()
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("Transaction başlatılamadı:", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Ödenecek payları önce oku, aktivite kaydına "önce" olarak yazılacak
+	rows, err := tx.QueryContext(ctx, `
+		SELECT gep.expense_id, ge.payer_id, gep.user_id, gep.amount_share
+		FROM group_expense_participants gep
 		JOIN group_expenses ge ON gep.expense_id = ge.expense_id
-		SET gep.payment_status = 'paid'
-		WHERE ge.group_id = ?
+		WHERE ge.group_id = ? AND gep.payment_status = 'unpaid' AND ge.deleted_at IS NULL
 		AND (
 			(ge.payer_id = ? AND gep.user_id = ?)
 			OR
 			(ge.payer_id = ? AND gep.user_id = ?)
 		)
+	`, groupID, userID, sendedUserID, sendedUserID, userID)
+	if err != nil {
+		log.Printf("Ödenecek paylar alınamadı: %v", err)
+		return err
+	}
+	var settled []SettledShare
+	for rows.Next() {
+		var share SettledShare
+		if err := rows.Scan(&share.ExpenseID, &share.PayerID, &share.UserID, &share.AmountShare); err != nil {
+			rows.Close()
+			return err
+		}
+		settled = append(settled, share)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Harcamalar alt sorguyla seçilir; UPDATE ... JOIN SQLite'ta yoktur
+	query := `
+		UPDATE group_expense_participants
+		SET payment_status = 'paid'
+		WHERE (user_id = ? AND expense_id IN (
+				SELECT expense_id FROM group_expenses WHERE group_id = ? AND deleted_at IS NULL AND payer_id = ?
+			))
+			OR (user_id = ? AND expense_id IN (
+				SELECT expense_id FROM group_expenses WHERE group_id = ? AND deleted_at IS NULL AND payer_id = ?
+			))
 	`
-	_, err := repo.DB.Exec(query, groupID, userID, sendedUserID, sendedUserID, userID)
+	_, err = tx.ExecContext(ctx, query, sendedUserID, groupID, userID, userID, groupID, sendedUserID)
 	if err != nil {
 		log.Printf("Harcama ödeme hatası: %v", err)
 		return err
 	}
 
+	if len(settled) > 0 {
+		if err := touchGroupMembers(ctx, tx, groupID); err != nil {
+			return err
+		}
+		err = logGroupActivity(ctx, tx, groupID, userID, activityPaymentSettled, "payment", sendedUserID,
+			map[string]interface{}{"payment_status": "unpaid", "shares": settled},
+			map[string]interface{}{"payment_status": "paid", "shares": settled})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("Transaction commit edilemedi:", err)
+		return err
+	}
+
 	log.Printf("Harcama ödendi: userID=%s, sendedUserID=%s, groupID=%d", userID, sendedUserID, groupID)
 	return nil
 }
@@ -725,8 +889,7 @@ func (repo *KasaRepository) SaveFCMToken(userID string, token string) error {
 	query := `
 		INSERT INTO fcm_table (user_id, fcm_token)
 		VALUES (?, ?)
-		ON DUPLICATE KEY UPDATE fcm_token = VALUES(fcm_token)
-	`
+		` + repo.dialect.upsert("user_id", "fcm_token")
 	res, err := repo.DB.Exec(query, userID, token)
 	if err != nil {
 		log.Println("FCM token kaydetme hatası:", err)
@@ -757,63 +920,121 @@ func (r *KasaRepository) GetFCMTokenByUserID(ctx context.Context, userID string)
 	return "", nil
 }
 
-func (repo *KasaRepository) addUserToGroupWithToken(userID string, groupToken string) (int64, error) {
-	var groupID int64
+// InviteJoinResult davet bağlantısıyla katılımın sonucudur. Bağlantı ya da grup yönetici
+// onayı istiyorsa kullanıcı gruba eklenmez, onun yerine bekleyen bir katılım isteği açılır.
+type InviteJoinResult struct {
+	GroupID   int64
+	GroupName string
+	AdminID   string
+	RequestID int64
+	Pending   bool
+}
+
+func (repo *KasaRepository) addUserToGroupWithToken(userID string, groupToken string) (*InviteJoinResult, error) {
+	ctx := context.Background()
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Bağlantıyı token'dan bul (eski gruplarda group_token için kayıt yoksa açılır)
+	if err := repo.ensureLegacyInviteLink(ctx, tx, 0, groupToken); err != nil {
+		return nil, err
+	}
+	link, err := repo.lockInviteLinkByToken(ctx, tx, groupToken)
+	if err != nil {
+		return nil, err
+	}
+	result := &InviteJoinResult{GroupID: link.GroupID}
+
+	var groupApproval bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT group_name, creator_id, join_requires_approval FROM groups WHERE id = ?
+	`, link.GroupID).Scan(&result.GroupName, &result.AdminID, &groupApproval)
+	if err != nil {
+		return nil, fmt.Errorf("grup bilgisi alınamadı: %w", err)
+	}
 
-	// 1. Group ID'yi token'dan bul
-	err := repo.DB.QueryRow(`
-		SELECT id FROM groups WHERE group_token = ?
-	`, groupToken).Scan(&groupID)
+	// Zaten üyeyse bağlantının kullanım hakkı harcanmaz
+	var memberCount int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_id = ?
+	`, link.GroupID, userID).Scan(&memberCount)
 	if err != nil {
-		return 0, fmt.Errorf("group not found: %w", err)
+		return nil, fmt.Errorf("üye kontrolü sırasında hata: %w", err)
+	}
+	if memberCount > 0 {
+		return result, nil
 	}
 
-	// 2. Kullanıcıyı bu gruba ekle (zaten varsa ekleme)
-	_, err = repo.DB.Exec(`
+	// 2. Bağlantı hâlâ geçerli mi?
+	if err := link.usable(); err != nil {
+		return nil, err
+	}
+
+	if link.RequiresApproval || groupApproval {
+		// 3a. Onay gerektiren bağlantılar ve onay modundaki gruplar yöneticiye katılım isteği olarak düşer
+		requestID, err := createJoinRequestTx(ctx, tx, link, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+		}
+		result.RequestID = requestID
+		result.Pending = true
+		return result, nil
+	}
+
+	// 3b. Kullanıcıyı bu gruba ekle
+	_, err = tx.ExecContext(ctx, `
 		INSERT INTO group_members (group_id, user_id)
 		VALUES (?, ?)
-		ON DUPLICATE KEY UPDATE user_id = user_id
-	`, groupID, userID)
+	`, link.GroupID, userID)
 	if err != nil {
-		return 0, fmt.Errorf("insert failed: %w", err)
+		return nil, fmt.Errorf("insert failed: %w", err)
+	}
+	if err := recordInviteLinkUseTx(ctx, tx, link.LinkID, userID); err != nil {
+		return nil, err
+	}
+	if err := touchGroupMembers(ctx, tx, link.GroupID); err != nil {
+		return nil, err
 	}
 
-	return groupID, nil
-}
+	err = logGroupActivity(ctx, tx, link.GroupID, userID, activityMemberJoined, "member", userID, nil,
+		map[string]interface{}{"user_id": userID, "via": "invite_link", "link_id": link.LinkID})
+	if err != nil {
+		return nil, err
+	}
 
-func (repo *KasaRepository) deleteGroupExpense(
-	ctx context.Context,
-	tx *sql.Tx,
-	userID string,
-	expenseID int64,
-) (*ExpenseWithParticipantsAndBalances, error) {
+	// Bu email için açılmış geçici üyeler varsa kullanıcıya devret
+	if _, err := claimMatchingPlaceholdersTx(ctx, tx, link.GroupID, userID); err != nil {
+		return nil, err
+	}
 
-	// Harcama bilgilerini ve creator_id'yi çek
-	var expense ExpenseWithParticipants
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return result, nil
+}
+
+// loadExpenseSnapshot harcamayı katılımcılarıyla, grup yöneticisini ve silinme zamanını okur.
+// deletedAt harcama silinmemişse 0'dır.
+func (repo *KasaRepository) loadExpenseSnapshot(ctx context.Context, tx *sql.Tx, expenseID int64) (expense ExpenseWithParticipants, creatorID string, deletedAt int64, err error) {
 	var participantsRaw sql.NullString
-	var paymentDateUnix int64
-	var creatorID string
+	var deletedAtRaw sql.NullInt64
 
-	txErr := tx.QueryRowContext(ctx, `
-		SELECT
-			e.expense_id, e.group_id, e.payer_id, u.fullname AS payer_name,
-			e.amount, e.description_note, e.payment_title, UNIX_TIMESTAMP(e.payment_date), e.bill_image_url,
-			g.creator_id,
-			(
-				SELECT JSON_ARRAYAGG(JSON_OBJECT(
-					'user_id', ep.user_id,
-					'user_name', uu.fullname,
-					'amount_share', ep.amount_share,
-					'payment_status', ep.payment_status
-				))
-				FROM group_expense_participants ep
-				LEFT JOIN users uu ON uu.id = ep.user_id
-				WHERE ep.expense_id = e.expense_id
-			) AS participants
+	err = tx.QueryRowContext(ctx, `
+		SELECT `+expenseColumns(repo.dialect)+`,
+			g.creator_id, `+repo.dialect.unix("e.deleted_at")+`,
+			`+participantsJSON(repo.dialect)+` AS participants
 		FROM group_expenses e
 		LEFT JOIN users u ON u.id = e.payer_id
 		JOIN groups g ON g.id = e.group_id
 		WHERE e.expense_id = ?
+		`+repo.dialect.forUpdate()+`
 	`, expenseID).Scan(
 		&expense.ExpenseID,
 		&expense.GroupID,
@@ -822,93 +1043,178 @@ func (repo *KasaRepository) deleteGroupExpense(
 		&expense.Amount,
 		&expense.DescriptionNote,
 		&expense.PaymentTitle,
-		&paymentDateUnix,
+		&expense.PaymentDate,
 		&expense.BillImageURL,
 		&creatorID,
+		&deletedAtRaw,
 		&participantsRaw,
 	)
+	if err == sql.ErrNoRows {
+		return expense, "", 0, errExpenseNotFound
+	}
+	if err != nil {
+		return expense, "", 0, fmt.Errorf("harcama bilgileri alınamadı: %w", err)
+	}
+
+	expense.Participants = []Participant{}
+	if err := decodeJSONList(participantsRaw, &expense.Participants); err != nil {
+		return expense, "", 0, err
+	}
+	return expense, creatorID, deletedAtRaw.Int64, nil
+}
+
+// loadGroupBalances kullanıcının gruptaki güncel borç ve alacaklarını döner (silinmiş harcamalar hariç)
+func (repo *KasaRepository) loadGroupBalances(ctx context.Context, tx *sql.Tx, userID string, groupID int64) ([]Debt, []Credit, error) {
+	var debtsRaw, creditsRaw sql.NullString
+
+	err := tx.QueryRowContext(ctx, "SELECT "+balancesJSON(repo.dialect, "?"), userID, groupID, userID, groupID).Scan(&debtsRaw, &creditsRaw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("borç/alacak bilgileri alınamadı: %w", err)
+	}
+
+	debts := []Debt{}
+	if err := decodeJSONList(debtsRaw, &debts); err != nil {
+		return nil, nil, err
+	}
+	credits := []Credit{}
+	if err := decodeJSONList(creditsRaw, &credits); err != nil {
+		return nil, nil, err
+	}
+	return debts, credits, nil
+}
+
+// expenseRestoreWindow silinen harcamanın geri alınabileceği süre (EXPENSE_RESTORE_WINDOW_HOURS, varsayılan 72 saat)
+func expenseRestoreWindow() time.Duration {
+	return envDuration("EXPENSE_RESTORE_WINDOW_HOURS", time.Hour, 72*time.Hour)
+}
+
+var (
+	errExpenseNotFound       = newDomainError(ErrNotFound, "error.expense.not_found", "harcama bulunamadı")
+	errExpenseAlreadyDeleted = newDomainError(ErrConflict, "error.expense.already_deleted", "harcama zaten silinmiş")
+	errExpenseNotDeleted     = newDomainError(ErrConflict, "error.expense.not_deleted", "harcama silinmemiş")
+	errRestoreWindowExpired  = newDomainError(ErrGone, "error.expense.restore_expired", "harcamanın geri alma süresi dolmuş")
+
+	errExpenseDeleteForbidden  = newDomainError(ErrForbidden, "error.expense.delete_forbidden", "yetkisiz işlem: sadece grup sahibi veya harcamayı yapan kişi silebilir")
+	errExpenseRestoreForbidden = newDomainError(ErrForbidden, "error.expense.restore_forbidden", "yetkisiz işlem: sadece grup sahibi veya harcamayı yapan kişi geri alabilir")
+	errShareAmountMissing      = newDomainError(ErrValidation, "error.expense.share_required", "katılımcı tutarı boş olamaz")
+)
+
+// deleteGroupExpense harcamayı yumuşak siler; geri alma süresi boyunca restoreGroupExpense ile geri getirilebilir.
+// groupID 0 değilse harcama o gruba ait olmalıdır, değilse bulunamadı sayılır; eski yol grubu vermez.
+func (repo *KasaRepository) deleteGroupExpense(ctx context.Context, userID string, groupID, expenseID int64) (*ExpenseWithParticipantsAndBalances, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Harcama bilgilerini ve creator_id'yi çek
+	expense, creatorID, deletedAt, txErr := repo.loadExpenseSnapshot(ctx, tx, expenseID)
 	if txErr != nil {
-		return nil, fmt.Errorf("harcama bilgileri alınamadı: %w", txErr)
+		return nil, txErr
+	}
+	if groupID != 0 && expense.GroupID != groupID {
+		return nil, errExpenseNotFound
 	}
-	expense.PaymentDate = paymentDateUnix
-	expense.Participants = []byte("[]")
-	if participantsRaw.Valid && participantsRaw.String != "" {
-		expense.Participants = json.RawMessage(participantsRaw.String)
+	if deletedAt != 0 {
+		return nil, errExpenseAlreadyDeleted
 	}
 
 	// 🛡️ Yetki kontrolü: user, payer veya grup sahibi mi?
 	if userID != expense.PayerID && userID != creatorID {
-		return nil, fmt.Errorf("yetkisiz işlem: sadece grup sahibi veya harcamayı yapan kişi silebilir")
+		return nil, errExpenseDeleteForbidden
 	}
 
-	// ❌ Katılımcıları sil
+	// 🗑️ Harcamayı silindi olarak işaretle, katılımcılar yerinde kalır
 	_, txErr = tx.ExecContext(ctx, `
-		DELETE FROM group_expense_participants
+		UPDATE group_expenses
+		SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ?
 		WHERE expense_id = ?
-	`, expenseID)
+	`, userID, expenseID)
 	if txErr != nil {
-		return nil, fmt.Errorf("katılımcılar silinemedi: %w", txErr)
+		return nil, fmt.Errorf("harcama silinemedi: %w", txErr)
+	}
+	if txErr = touchGroupMembers(ctx, tx, expense.GroupID); txErr != nil {
+		return nil, txErr
 	}
 
-	// ❌ Harcamayı sil
-	_, txErr = tx.ExecContext(ctx, `
-		DELETE FROM group_expenses
-		WHERE expense_id = ?
-	`, expenseID)
+	// Silinen harcama aktivite kaydındaki tam anlık görüntüden yeniden oluşturulabilir
+	txErr = logGroupActivity(ctx, tx, expense.GroupID, userID, activityExpenseDeleted, "expense", fmt.Sprint(expense.ExpenseID), expense,
+		map[string]interface{}{"deleted_by": userID, "restorable_for_hours": int(expenseRestoreWindow().Hours())})
 	if txErr != nil {
-		return nil, fmt.Errorf("harcama silinemedi: %w", txErr)
+		return nil, txErr
 	}
 
 	// 📊 Borç/alacak hesapla (kullanıcının yeni durumu için)
-	var debtsRaw, creditsRaw sql.NullString
+	debts, credits, txErr := repo.loadGroupBalances(ctx, tx, userID, expense.GroupID)
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+
+	return &ExpenseWithParticipantsAndBalances{
+		Expense: expense,
+		Debts:   debts,
+		Credits: credits,
+	}, nil
+}
+
+// restoreGroupExpense yumuşak silinmiş harcamayı geri getirir; sadece harcamayı yapan kişi veya grup yöneticisi, geri alma süresi içinde.
+// groupID deleteGroupExpense'teki gibi kontrol edilir.
+func (repo *KasaRepository) restoreGroupExpense(ctx context.Context, userID string, groupID, expenseID int64) (*ExpenseWithParticipantsAndBalances, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	expense, creatorID, deletedAt, txErr := repo.loadExpenseSnapshot(ctx, tx, expenseID)
+	if txErr != nil {
+		return nil, txErr
+	}
+	if groupID != 0 && expense.GroupID != groupID {
+		return nil, errExpenseNotFound
+	}
+	if deletedAt == 0 {
+		return nil, errExpenseNotDeleted
+	}
+
+	if userID != expense.PayerID && userID != creatorID {
+		return nil, errExpenseRestoreForbidden
+	}
+
+	// Süre, listeleme ve kalıcı silmedeki gibi veritabanı saatiyle ölçülür
+	res, txErr := tx.ExecContext(ctx, `
+		UPDATE group_expenses
+		SET deleted_at = NULL, deleted_by = NULL
+		WHERE expense_id = ? AND deleted_at >= `+repo.dialect.minus("CURRENT_TIMESTAMP", "SECOND")+`
+	`, expenseID, int64(expenseRestoreWindow().Seconds()))
+	if txErr != nil {
+		return nil, fmt.Errorf("harcama geri alınamadı: %w", txErr)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return nil, errRestoreWindowExpired
+	}
+	if txErr = touchGroupMembers(ctx, tx, expense.GroupID); txErr != nil {
+		return nil, txErr
+	}
 
-	txErr = tx.QueryRowContext(ctx, `
-		SELECT 
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'user_id', e.payer_id,
-						'username', payer.fullname,
-						'iban', payer.iban,
-						'amount', p.amount_share,
-						'status', p.payment_status,
-						'expenses', JSON_ARRAY(p.expense_id)
-					)
-				)
-				FROM group_expense_participants p
-				JOIN group_expenses e ON p.expense_id = e.expense_id
-				JOIN users payer ON payer.id = e.payer_id
-				WHERE p.user_id = ? AND e.payer_id != p.user_id AND e.group_id = ?
-			) AS debts,
-			(
-				SELECT JSON_ARRAYAGG(
-					JSON_OBJECT(
-						'user_id', p.user_id,
-						'username', u.fullname,
-						'iban', u.iban,
-						'amount', p.amount_share,
-						'status', p.payment_status,
-						'expenses', JSON_ARRAY(p.expense_id)
-					)
-				)
-				FROM group_expenses e
-				JOIN group_expense_participants p ON e.expense_id = p.expense_id
-				JOIN users u ON u.id = p.user_id
-				WHERE e.payer_id = ? AND p.user_id != e.payer_id AND e.group_id = ?
-			) AS credits
-	`, userID, expense.GroupID, userID, expense.GroupID).Scan(&debtsRaw, &creditsRaw)
+	txErr = logGroupActivity(ctx, tx, expense.GroupID, userID, activityExpenseRestored, "expense", fmt.Sprint(expense.ExpenseID),
+		map[string]interface{}{"deleted_at": deletedAt}, expense)
 	if txErr != nil {
-		return nil, fmt.Errorf("borç/alacak bilgileri alınamadı: %w", txErr)
+		return nil, txErr
 	}
 
-	// JSON dönüşümleri
-	debts := []byte("[]")
-	if debtsRaw.Valid && debtsRaw.String != "" {
-		debts = json.RawMessage(debtsRaw.String)
+	debts, credits, txErr := repo.loadGroupBalances(ctx, tx, userID, expense.GroupID)
+	if txErr != nil {
+		return nil, txErr
 	}
-	credits := []byte("[]")
-	if creditsRaw.Valid && creditsRaw.String != "" {
-		credits = json.RawMessage(creditsRaw.String)
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
 	}
 
 	return &ExpenseWithParticipantsAndBalances{
@@ -918,36 +1224,2878 @@ func (repo *KasaRepository) deleteGroupExpense(
 	}, nil
 }
 
-func (repo *KasaRepository) deleteAccount(ctx context.Context, userID string) (bool, error) {
-	tx, err := repo.DB.BeginTx(ctx, nil)
+type DeletedExpense struct {
+	ExpenseWithParticipants
+	DeletedAt    int64  `json:"deleted_at"`
+	DeletedBy    string `json:"deleted_by"`
+	RestoreUntil int64  `json:"restore_until"`
+}
+
+// Gruptaki, geri alma süresi dolmamış silinmiş harcamalar
+func (repo *KasaRepository) getDeletedExpenses(ctx context.Context, groupID int64) ([]DeletedExpense, error) {
+	window := expenseRestoreWindow()
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT `+expenseColumns(repo.dialect)+`,
+			`+repo.dialect.unix("e.deleted_at")+`, COALESCE(e.deleted_by, ''),
+			`+participantsJSON(repo.dialect)+` AS participants
+		FROM group_expenses e
+		LEFT JOIN users u ON u.id = e.payer_id
+		WHERE e.group_id = ? AND e.deleted_at IS NOT NULL AND e.deleted_at >= `+repo.dialect.minus("CURRENT_TIMESTAMP", "SECOND")+`
+		ORDER BY e.deleted_at DESC, e.expense_id DESC
+	`, groupID, int64(window.Seconds()))
 	if err != nil {
-		log.Println("Transaction başlatılamadı:", err)
-		return false, err
+		return nil, fmt.Errorf("silinmiş harcamalar alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	expenses := make([]DeletedExpense, 0)
+	for rows.Next() {
+		var e DeletedExpense
+		var participantsRaw sql.NullString
+		if err := rows.Scan(
+			&e.ExpenseID, &e.GroupID, &e.PayerID, &e.PayerName,
+			&e.Amount, &e.DescriptionNote, &e.PaymentTitle, &e.PaymentDate, &e.BillImageURL,
+			&e.DeletedAt, &e.DeletedBy, &participantsRaw,
+		); err != nil {
+			return nil, fmt.Errorf("silinmiş harcama okunamadı: %w", err)
+		}
+		e.Participants = []Participant{}
+		if err := decodeJSONList(participantsRaw, &e.Participants); err != nil {
+			return nil, err
+		}
+		e.RestoreUntil = e.DeletedAt + int64(window.Seconds())
+		expenses = append(expenses, e)
+	}
+	return expenses, rows.Err()
+}
+
+// purgeDeletedExpenses geri alma süresi dolan harcamaları kalıcı olarak siler, silinen sayıyı döner
+func (repo *KasaRepository) purgeDeletedExpenses(ctx context.Context, limit int) (int, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT expense_id, group_id
+		FROM group_expenses
+		WHERE deleted_at IS NOT NULL AND deleted_at < `+repo.dialect.minus("CURRENT_TIMESTAMP", "SECOND")+`
+		ORDER BY deleted_at
+		LIMIT ?
+	`, int64(expenseRestoreWindow().Seconds()), limit)
+	if err != nil {
+		return 0, fmt.Errorf("silinecek harcamalar alınamadı: %w", err)
+	}
+	type purgeTarget struct{ expenseID, groupID int64 }
+	var targets []purgeTarget
+	for rows.Next() {
+		var t purgeTarget
+		if err := rows.Scan(&t.expenseID, &t.groupID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		targets = append(targets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
 	}
 
-	// Başarısızlık durumunda rollback yap
-	defer func() {
-		if p := recover(); p != nil {
+	purged := 0
+	for _, t := range targets {
+		tx, err := repo.DB.BeginTx(ctx, nil)
+		if err != nil {
+			return purged, err
+		}
+		// Katılımcılar ON DELETE CASCADE ile silinir
+		if _, err := tx.ExecContext(ctx, "DELETE FROM group_expenses WHERE expense_id = ? AND deleted_at IS NOT NULL", t.expenseID); err != nil {
+			tx.Rollback()
+			return purged, fmt.Errorf("harcama kalıcı olarak silinemedi: %w", err)
+		}
+		if err := logGroupActivity(ctx, tx, t.groupID, "", activityExpensePurged, "expense", fmt.Sprint(t.expenseID), nil, nil); err != nil {
 			tx.Rollback()
-			panic(p) // panik tekrar fırlatılır
+			return purged, err
 		}
-	}()
+		if err := tx.Commit(); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
 
-	// Kullanıcıyı "sil" (deleted = 1)
-	_, err = tx.ExecContext(ctx, `
-        UPDATE users
-        SET deleted = TRUE
-        WHERE id = ?
-    `, userID)
+const (
+	deletionStatusActive     = "active"
+	deletionStatusPending    = "pending"
+	deletionStatusAnonymized = "anonymized"
+	deletionStatusCompleted  = "completed"
+)
+
+var errNoPendingDeletion = newDomainError(ErrConflict, "error.account.no_pending_deletion", "bekleyen bir hesap silme talebi yok")
+
+type AccountDeletionStatus struct {
+	Status      string `json:"status"`
+	RequestedAt *int64 `json:"requested_at"`
+	ScheduledAt *int64 `json:"scheduled_at"`
+	CompletedAt *int64 `json:"completed_at"`
+}
+
+func (repo *KasaRepository) GetAccountDeletionStatus(ctx context.Context, userID string) (*AccountDeletionStatus, error) {
+	var status AccountDeletionStatus
+	var requestedAt, scheduledAt, completedAt sql.NullInt64
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT deletion_status, `+repo.dialect.unix("deletion_requested_at")+`, `+repo.dialect.unix("deletion_scheduled_at")+`, `+repo.dialect.unix("deletion_completed_at")+`
+		FROM users
+		WHERE id = ?
+	`, userID).Scan(&status.Status, &requestedAt, &scheduledAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+	if requestedAt.Valid {
+		status.RequestedAt = &requestedAt.Int64
+	}
+	if scheduledAt.Valid {
+		status.ScheduledAt = &scheduledAt.Int64
+	}
+	if completedAt.Valid {
+		status.CompletedAt = &completedAt.Int64
+	}
+	return &status, nil
+}
+
+// requestAccountDeletion hesabı silinmiş olarak işaretler ve anonimleştirmeyi bekleme süresi sonrasına planlar.
+// Bekleme süresi boyunca restoreAccount ile geri alınabilir.
+func (repo *KasaRepository) requestAccountDeletion(ctx context.Context, userID string, grace time.Duration) (*AccountDeletionStatus, error) {
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE users
+		SET deleted = TRUE,
+			deletion_status = 'pending',
+			deletion_requested_at = CURRENT_TIMESTAMP,
+			deletion_scheduled_at = `+repo.dialect.plus("CURRENT_TIMESTAMP", "SECOND")+`,
+			deletion_error = NULL,
+			data_version = data_version + 1
+		WHERE id = ? AND deletion_status = 'active'
+	`, int64(grace.Seconds()), userID)
 	if err != nil {
-		tx.Rollback()
 		log.Println("Kullanıcı silinemedi:", err)
-		return false, err
+		return nil, err
 	}
-	if err := tx.Commit(); err != nil {
-		log.Println("Transaction commit edilemedi:", err)
-		return false, err
+	return repo.GetAccountDeletionStatus(ctx, userID)
+}
+
+func (repo *KasaRepository) restoreAccount(ctx context.Context, userID string) error {
+	res, err := repo.DB.ExecContext(ctx, `
+		UPDATE users
+		SET deleted = FALSE,
+			deletion_status = 'active',
+			deletion_requested_at = NULL,
+			deletion_scheduled_at = NULL,
+			data_version = data_version + 1
+		WHERE id = ? AND deletion_status = 'pending'
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("hesap geri alınamadı: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errNoPendingDeletion
+	}
+	return nil
+}
+
+// Bekleme süresi dolmuş ya da Firebase silmesi yarım kalmış hesaplar
+func (repo *KasaRepository) getDueAccountDeletions(ctx context.Context, limit int) (map[string]string, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT id, deletion_status
+		FROM users
+		WHERE (deletion_status = 'pending' AND deletion_scheduled_at <= CURRENT_TIMESTAMP)
+			OR deletion_status = 'anonymized'
+		ORDER BY deletion_scheduled_at
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("silinecek hesaplar alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	due := map[string]string{}
+	for rows.Next() {
+		var id, status string
+		if err := rows.Scan(&id, &status); err != nil {
+			return nil, err
+		}
+		due[id] = status
+	}
+	return due, rows.Err()
+}
+
+// anonymizeUser kişisel verileri siler. Kullanıcı satırı ve harcama geçmişi yerinde kalır,
+// böylece gruplardaki bakiyeler tutarlı kalır; isim herkesin ekranında anonim görünür.
+func (repo *KasaRepository) anonymizeUser(ctx context.Context, userID string) error {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Email benzersiz kalmalı ki aynı kişi ileride tekrar kayıt olabilsin
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET fullname = ?,
+			email = CONCAT('deleted+', id, '@kasa.invalid'),
+			password_hash = NULL,
+			iban = NULL,
+			deletion_status = 'anonymized'
+		WHERE id = ? AND deletion_status = 'pending'
+	`, T(defaultLocale, "account.deleted_user_name", nil), userID)
+	if err != nil {
+		return fmt.Errorf("kullanıcı anonimleştirilemedi: %w", err)
+	}
+	// Anonim isim grup üyelerinin ve bekleyen isteği gören yöneticilerin ekranında da değişir
+	if err := touchUserAndGroupmates(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	// Cihazlar, tokenlar ve kişisel gelen kutusu
+	if _, err := tx.ExecContext(ctx, "DELETE FROM fcm_table WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("FCM token silinemedi: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM notifications WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("bildirimler silinemedi: %w", err)
+	}
+
+	// Hazırlanmış veri arşivleri kişisel veri içerir, temizlik işi dosyaları siler
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE data_exports SET expires_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND status = 'ready'
+	`, userID); err != nil {
+		return fmt.Errorf("veri arşivleri kapatılamadı: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE data_exports SET status = 'expired'
+		WHERE user_id = ? AND status IN ('pending', 'processing')
+	`, userID); err != nil {
+		return fmt.Errorf("veri arşivleri kapatılamadı: %w", err)
 	}
 
-	return true, nil
+	// Arkadaşlıklar ve bekleyen arkadaşlık istekleri; grup dışı harcama geçmişi yerinde kalır
+	if _, err := tx.ExecContext(ctx, "DELETE FROM friendships WHERE user_id = ? OR friend_id = ?", userID, userID); err != nil {
+		return fmt.Errorf("arkadaşlıklar silinemedi: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE friend_requests SET request_status = 'rejected', responded_at = CURRENT_TIMESTAMP
+		WHERE (user_id = ? OR requester_id = ?) AND request_status = 'pending'
+	`, userID, userID); err != nil {
+		return fmt.Errorf("arkadaşlık istekleri kapatılamadı: %w", err)
+	}
+
+	// Bekleyen grup isteklerini reddet; grup yöneticileri nedenini aktivite akışında görür
+	rows, err := tx.QueryContext(ctx, `
+		SELECT request_id, group_id, request_direction
+		FROM group_add_requests
+		WHERE user_id = ? AND request_status = 'pending'
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("bekleyen istekler alınamadı: %w", err)
+	}
+	type pendingRequest struct {
+		requestID int64
+		groupID   int64
+		direction string
+	}
+	var pending []pendingRequest
+	for rows.Next() {
+		var p pendingRequest
+		if err := rows.Scan(&p.requestID, &p.groupID, &p.direction); err != nil {
+			rows.Close()
+			return fmt.Errorf("bekleyen istek okunamadı: %w", err)
+		}
+		pending = append(pending, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE group_add_requests SET request_status = 'rejected'
+		WHERE user_id = ? AND request_status = 'pending'
+	`, userID); err != nil {
+		return fmt.Errorf("bekleyen istekler kapatılamadı: %w", err)
+	}
+	for _, p := range pending {
+		err := logGroupActivity(ctx, tx, p.groupID, userID, activityRequestRejected, "add_request", fmt.Sprint(p.requestID),
+			map[string]interface{}{"request_status": "pending"},
+			map[string]interface{}{"request_status": "rejected", "request_direction": p.direction, "reason": "account_deleted"})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Firebase silme sonucunu kaydet; hata varsa iş bir sonraki turda tekrar dener
+func (repo *KasaRepository) completeAccountDeletion(ctx context.Context, userID string, firebaseErr error) error {
+	if firebaseErr != nil {
+		_, err := repo.DB.ExecContext(ctx, "UPDATE users SET deletion_error = ? WHERE id = ?", firebaseErr.Error(), userID)
+		return err
+	}
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE users
+		SET deletion_status = 'completed', deletion_completed_at = CURRENT_TIMESTAMP, deletion_error = NULL,
+			data_version = data_version + 1
+		WHERE id = ? AND deletion_status = 'anonymized'
+	`, userID)
+	return err
+}
+
+type InboxNotification struct {
+	ID        int64             `json:"id"`
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data"`
+	IsRead    bool              `json:"is_read"`
+	CreatedAt int64             `json:"created_at"`
+}
+
+// Gönderilen her bildirimi kullanıcının gelen kutusuna kaydet
+func (repo *KasaRepository) SaveNotification(ctx context.Context, userID, title, body string, data map[string]string) (int64, error) {
+	var dataJSON interface{}
+	if len(data) > 0 {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return 0, fmt.Errorf("bildirim verisi çözümlenemedi: %w", err)
+		}
+		dataJSON = string(b)
+	}
+
+	res, err := repo.DB.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, notification_type, title, body, data)
+		VALUES (?, ?, ?, ?, ?)
+	`, userID, data["type"], title, body, dataJSON)
+	if err != nil {
+		return 0, fmt.Errorf("bildirim kaydedilemedi: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+func (repo *KasaRepository) GetNotifications(ctx context.Context, userID string, limit, offset int) ([]InboxNotification, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT id, COALESCE(notification_type, ''), title, body, data, is_read, `+repo.dialect.unix("created_at")+`
+		FROM notifications
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("bildirimler alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]InboxNotification, 0)
+	for rows.Next() {
+		var n InboxNotification
+		var dataRaw sql.NullString
+		if err := rows.Scan(&n.ID, &n.Type, &n.Title, &n.Body, &dataRaw, &n.IsRead, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("bildirim okunamadı: %w", err)
+		}
+		n.Data = map[string]string{}
+		if dataRaw.Valid && dataRaw.String != "" {
+			if err := json.Unmarshal([]byte(dataRaw.String), &n.Data); err != nil {
+				log.Printf("Bildirim verisi çözümlenemedi (id=%d): %v", n.ID, err)
+			}
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (repo *KasaRepository) CountUnreadNotifications(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notifications WHERE user_id = ? AND is_read = FALSE
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("okunmamış bildirim sayısı alınamadı: %w", err)
+	}
+	return count, nil
+}
+
+// Sadece kullanıcının kendi bildirimleri okundu olarak işaretlenir
+func (repo *KasaRepository) MarkNotificationsRead(ctx context.Context, userID string, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, userID)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	res, err := repo.DB.ExecContext(ctx, `
+		UPDATE notifications
+		SET is_read = TRUE, read_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND is_read = FALSE AND id IN (`+placeholders+`)
+	`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("bildirimler okundu olarak işaretlenemedi: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (repo *KasaRepository) MarkAllNotificationsRead(ctx context.Context, userID string) (int64, error) {
+	res, err := repo.DB.ExecContext(ctx, `
+		UPDATE notifications
+		SET is_read = TRUE, read_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND is_read = FALSE
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("bildirimler okundu olarak işaretlenemedi: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// Kullanıcı grubun üyesi mi?
+func (repo *KasaRepository) isGroupMember(ctx context.Context, groupID int64, userID string) (bool, error) {
+	var count int
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_id = ?
+	`, groupID, userID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("üyelik kontrolü sırasında hata: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Grubun kurucusunu (yöneticisini) getir, grup yoksa sql.ErrNoRows döner
+func (repo *KasaRepository) getGroupCreatorID(ctx context.Context, groupID int64) (string, error) {
+	var creatorID string
+	err := repo.DB.QueryRowContext(ctx, "SELECT creator_id FROM groups WHERE id = ?", groupID).Scan(&creatorID)
+	if err != nil {
+		return "", err
+	}
+	return creatorID, nil
+}
+
+// getGroupCurrency grubun para birimini döner
+func (repo *KasaRepository) getGroupCurrency(ctx context.Context, groupID int64) (string, error) {
+	var currency string
+	err := repo.DB.QueryRowContext(ctx, "SELECT currency FROM groups WHERE id = ?", groupID).Scan(&currency)
+	if err != nil {
+		return "", err
+	}
+	return currency, nil
+}
+
+type ReminderSettings struct {
+	GroupID           int64 `json:"group_id"`
+	Enabled           bool  `json:"enabled"`
+	FirstReminderDays int   `json:"first_reminder_days" validate:"min=1,max=365"`
+	RepeatEveryDays   int   `json:"repeat_every_days" validate:"min=1,max=365"`
+}
+
+// Grup için ayar yoksa hatırlatmalar kapalı kabul edilir
+func (repo *KasaRepository) GetReminderSettings(ctx context.Context, groupID int64) (*ReminderSettings, error) {
+	settings := ReminderSettings{GroupID: groupID, Enabled: false, FirstReminderDays: 3, RepeatEveryDays: 7}
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT enabled, first_reminder_days, repeat_every_days
+		FROM group_reminder_settings
+		WHERE group_id = ?
+	`, groupID).Scan(&settings.Enabled, &settings.FirstReminderDays, &settings.RepeatEveryDays)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("hatırlatma ayarları alınamadı: %w", err)
+	}
+	return &settings, nil
+}
+
+func (repo *KasaRepository) SaveReminderSettings(ctx context.Context, actorID string, settings ReminderSettings) error {
+	before, err := repo.GetReminderSettings(ctx, settings.GroupID)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.DB.ExecContext(ctx, `
+		INSERT INTO group_reminder_settings (group_id, enabled, first_reminder_days, repeat_every_days)
+		VALUES (?, ?, ?, ?)
+		`+repo.dialect.upsert("group_id", "enabled", "first_reminder_days", "repeat_every_days")+`
+	`, settings.GroupID, settings.Enabled, settings.FirstReminderDays, settings.RepeatEveryDays)
+	if err != nil {
+		return fmt.Errorf("hatırlatma ayarları kaydedilemedi: %w", err)
+	}
+	return logGroupActivity(ctx, repo.DB, settings.GroupID, actorID, activityRemindersUpdated, "reminder_settings", fmt.Sprint(settings.GroupID), before, settings)
+}
+
+// Hatırlatmaları açık olan tüm grupların ayarlarını getir
+func (repo *KasaRepository) getEnabledReminderSettings(ctx context.Context) (map[int64]ReminderSettings, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT group_id, enabled, first_reminder_days, repeat_every_days
+		FROM group_reminder_settings
+		WHERE enabled = TRUE
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("hatırlatma ayarları alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	settings := map[int64]ReminderSettings{}
+	for rows.Next() {
+		var s ReminderSettings
+		if err := rows.Scan(&s.GroupID, &s.Enabled, &s.FirstReminderDays, &s.RepeatEveryDays); err != nil {
+			return nil, fmt.Errorf("hatırlatma ayarı okunamadı: %w", err)
+		}
+		settings[s.GroupID] = s
+	}
+	return settings, rows.Err()
+}
+
+type OutstandingDebt struct {
+	GroupID      int64
+	GroupName    string
+	Currency     string
+	CreditorID   string
+	CreditorName string
+	DebtorID     string
+	Amount       float64
+	OldestUnpaid int64 // en eski ödenmemiş harcamanın tarihi (unix)
+}
+
+// Ödenmemiş payları (grup, alacaklı, borçlu) bazında toplar.
+// groupID 0 verilirse hatırlatmaları açık olan tüm gruplar taranır.
+func (repo *KasaRepository) getOutstandingDebts(ctx context.Context, groupID int64) ([]OutstandingDebt, error) {
+	filter := "e.group_id = ?"
+	args := []interface{}{groupID}
+	if groupID == 0 {
+		filter = "e.group_id IN (SELECT group_id FROM group_reminder_settings WHERE enabled = TRUE)"
+		args = nil
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT
+			e.group_id, g.group_name, g.currency,
+			e.payer_id, payer.fullname,
+			p.user_id,
+			SUM(p.amount_share),
+			`+repo.dialect.unix("MIN(e.payment_date)")+`
+		FROM group_expense_participants p
+		JOIN group_expenses e ON e.expense_id = p.expense_id
+		JOIN groups g ON g.id = e.group_id
+		JOIN users payer ON payer.id = e.payer_id
+		JOIN users debtor ON debtor.id = p.user_id
+		WHERE p.payment_status = 'unpaid' AND p.user_id != e.payer_id AND e.deleted_at IS NULL
+			AND debtor.is_placeholder = FALSE AND `+filter+`
+		GROUP BY e.group_id, g.group_name, g.currency, e.payer_id, payer.fullname, p.user_id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ödenmemiş borçlar alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	var debts []OutstandingDebt
+	for rows.Next() {
+		var d OutstandingDebt
+		if err := rows.Scan(&d.GroupID, &d.GroupName, &d.Currency, &d.CreditorID, &d.CreditorName, &d.DebtorID, &d.Amount, &d.OldestUnpaid); err != nil {
+			return nil, fmt.Errorf("borç satırı okunamadı: %w", err)
+		}
+		debts = append(debts, d)
+	}
+	return debts, rows.Err()
+}
+
+// reservePaymentReminder son cooldown içinde aynı türde hatırlatma yoksa hatırlatmayı kaydeder ve
+// true döner; bildirim bu kayıttan sonra gönderilir. Kontrol ve kayıt aynı transaction'da, grup
+// satırı kilitlenerek yapılır; aynı anda gelen iki istekten sadece biri hatırlatma gönderir.
+func (repo *KasaRepository) reservePaymentReminder(ctx context.Context, debt OutstandingDebt, reminderType string, cooldown time.Duration) (bool, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var locked int64
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM groups WHERE id = ? "+repo.dialect.forUpdate(), debt.GroupID).Scan(&locked); err != nil {
+		return false, fmt.Errorf("grup kilitlenemedi: %w", err)
+	}
+
+	var recent int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM payment_reminders
+		WHERE group_id = ? AND creditor_id = ? AND debtor_id = ? AND reminder_type = ?
+		  AND sent_at > `+repo.dialect.minus("CURRENT_TIMESTAMP", "SECOND")+`
+	`, debt.GroupID, debt.CreditorID, debt.DebtorID, reminderType, int64(cooldown/time.Second)).Scan(&recent)
+	if err != nil {
+		return false, fmt.Errorf("son hatırlatma alınamadı: %w", err)
+	}
+	if recent > 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO payment_reminders (group_id, creditor_id, debtor_id, reminder_type, amount)
+		VALUES (?, ?, ?, ?, ?)
+	`, debt.GroupID, debt.CreditorID, debt.DebtorID, reminderType, debt.Amount)
+	if err != nil {
+		return false, fmt.Errorf("hatırlatma kaydedilemedi: %w", err)
+	}
+
+	// Zamanlanmış hatırlatmaların bir eyleyeni yok
+	actorID := ""
+	if reminderType == reminderTypeNudge {
+		actorID = debt.CreditorID
+	}
+	err = logGroupActivity(ctx, tx, debt.GroupID, actorID, activityPaymentReminded, "payment_reminder", debt.DebtorID, nil, map[string]interface{}{
+		"reminder_type": reminderType,
+		"creditor_id":   debt.CreditorID,
+		"debtor_id":     debt.DebtorID,
+		"amount":        debt.Amount,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+type GroupActivity struct {
+	ID         int64           `json:"id"`
+	GroupID    int64           `json:"group_id"`
+	ActorID    *string         `json:"actor_id"`
+	ActorName  *string         `json:"actor_name"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   *string         `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  int64           `json:"created_at"`
+}
+
+// Grubun aktivite akışı, en yeni kayıt önce gelir
+func (repo *KasaRepository) GetGroupActivity(ctx context.Context, groupID int64, limit, offset int) ([]GroupActivity, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT a.id, a.group_id, a.actor_id, u.fullname, a.action, a.entity_type, a.entity_id,
+			a.before_data, a.after_data, `+repo.dialect.unix("a.created_at")+`
+		FROM group_activity a
+		LEFT JOIN users u ON u.id = a.actor_id
+		WHERE a.group_id = ?
+		ORDER BY a.id DESC
+		LIMIT ? OFFSET ?
+	`, groupID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("aktivite kaydı alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	activity := make([]GroupActivity, 0)
+	for rows.Next() {
+		var a GroupActivity
+		var actorID, actorName, entityID, before, after sql.NullString
+		if err := rows.Scan(&a.ID, &a.GroupID, &actorID, &actorName, &a.Action, &a.EntityType, &entityID, &before, &after, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("aktivite satırı okunamadı: %w", err)
+		}
+		if actorID.Valid {
+			a.ActorID = &actorID.String
+		}
+		if actorName.Valid {
+			a.ActorName = &actorName.String
+		}
+		if entityID.Valid {
+			a.EntityID = &entityID.String
+		}
+		a.Before = json.RawMessage("null")
+		if before.Valid {
+			a.Before = json.RawMessage(before.String)
+		}
+		a.After = json.RawMessage("null")
+		if after.Valid {
+			a.After = json.RawMessage(after.String)
+		}
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
+const (
+	dataExportPending    = "pending"
+	dataExportProcessing = "processing"
+	dataExportReady      = "ready"
+	dataExportFailed     = "failed"
+	dataExportExpired    = "expired"
+)
+
+type DataExport struct {
+	ID          int64  `json:"id"`
+	Status      string `json:"status"`
+	DownloadURL string `json:"download_url,omitempty"`
+	RequestedAt int64  `json:"requested_at"`
+	CompletedAt *int64 `json:"completed_at"`
+	ExpiresAt   *int64 `json:"expires_at"`
+
+	userID   string
+	token    string
+	filePath string
+}
+
+func (repo *KasaRepository) dataExportColumns() string {
+	d := repo.dialect
+	return `
+	id, user_id, status, COALESCE(download_token, ''), COALESCE(file_path, ''),
+	` + d.unix("requested_at") + `, ` + d.unix("completed_at") + `, ` + d.unix("expires_at") + `
+`
+}
+
+func scanDataExport(row interface{ Scan(...any) error }) (*DataExport, error) {
+	var e DataExport
+	var completedAt, expiresAt sql.NullInt64
+	err := row.Scan(&e.ID, &e.userID, &e.Status, &e.token, &e.filePath, &e.RequestedAt, &completedAt, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		e.CompletedAt = &completedAt.Int64
+	}
+	if expiresAt.Valid {
+		e.ExpiresAt = &expiresAt.Int64
+	}
+	return &e, nil
+}
+
+// createDataExport yeni bir dışa aktarma talebi açar. Sırada bekleyen bir talep varsa onu döner.
+func (repo *KasaRepository) createDataExport(ctx context.Context, userID string) (*DataExport, bool, error) {
+	existing, err := scanDataExport(repo.DB.QueryRowContext(ctx, `
+		SELECT `+repo.dataExportColumns()+`
+		FROM data_exports
+		WHERE user_id = ? AND status IN ('pending', 'processing')
+		ORDER BY id DESC
+		LIMIT 1
+	`, userID))
+	if err == nil {
+		return existing, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("dışa aktarma talebi alınamadı: %w", err)
+	}
+
+	res, err := repo.DB.ExecContext(ctx, "INSERT INTO data_exports (user_id) VALUES (?)", userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("dışa aktarma talebi oluşturulamadı: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, false, err
+	}
+	export, err := scanDataExport(repo.DB.QueryRowContext(ctx, "SELECT "+repo.dataExportColumns()+" FROM data_exports WHERE id = ?", id))
+	return export, true, err
+}
+
+func (repo *KasaRepository) getDataExports(ctx context.Context, userID string) ([]DataExport, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT `+repo.dataExportColumns()+`
+		FROM data_exports
+		WHERE user_id = ?
+		ORDER BY id DESC
+		LIMIT 10
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("dışa aktarma talepleri alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []DataExport{}
+	for rows.Next() {
+		e, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, *e)
+	}
+	return exports, rows.Err()
+}
+
+// İndirme bağlantısı sadece hazır ve süresi dolmamış arşivler için geçerlidir
+func (repo *KasaRepository) getDownloadableDataExport(ctx context.Context, token string) (*DataExport, error) {
+	return scanDataExport(repo.DB.QueryRowContext(ctx, `
+		SELECT `+repo.dataExportColumns()+`
+		FROM data_exports
+		WHERE download_token = ? AND status = 'ready' AND expires_at > CURRENT_TIMESTAMP
+	`, token))
+}
+
+// claimPendingDataExports sıradaki talepleri 'processing' olarak işaretleyip döner. staleAfter'dan
+// uzun süredir 'processing' olan talepler (işlenirken süreç kapanmış) yeniden alınır; süre
+// veritabanı saatine göre ölçülür.
+func (repo *KasaRepository) claimPendingDataExports(ctx context.Context, limit int, staleAfter time.Duration) ([]DataExport, error) {
+	claimable := "(status = 'pending' OR (status = 'processing' AND claimed_at < " + repo.dialect.minus("CURRENT_TIMESTAMP", "SECOND") + "))"
+	stale := int64(staleAfter.Seconds())
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT `+repo.dataExportColumns()+`
+		FROM data_exports
+		WHERE `+claimable+`
+		ORDER BY requested_at
+		LIMIT ?
+	`, stale, limit)
+	if err != nil {
+		return nil, fmt.Errorf("bekleyen dışa aktarmalar alınamadı: %w", err)
+	}
+	var pending []DataExport
+	for rows.Next() {
+		e, err := scanDataExport(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pending = append(pending, *e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var claimed []DataExport
+	for _, e := range pending {
+		res, err := repo.DB.ExecContext(ctx, "UPDATE data_exports SET status = 'processing', claimed_at = CURRENT_TIMESTAMP WHERE id = ? AND "+claimable, e.ID, stale)
+		if err != nil {
+			return nil, err
+		}
+		if affected, _ := res.RowsAffected(); affected > 0 {
+			e.Status = dataExportProcessing
+			claimed = append(claimed, e)
+		}
+	}
+	return claimed, nil
+}
+
+func (repo *KasaRepository) completeDataExport(ctx context.Context, id int64, token, filePath string, ttl time.Duration) error {
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE data_exports
+		SET status = 'ready', download_token = ?, file_path = ?, completed_at = CURRENT_TIMESTAMP, expires_at = `+repo.dialect.plus("CURRENT_TIMESTAMP", "SECOND")+`
+		WHERE id = ?
+	`, token, filePath, int64(ttl.Seconds()), id)
+	return err
+}
+
+func (repo *KasaRepository) failDataExport(ctx context.Context, id int64, cause error) error {
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE data_exports SET status = 'failed', error_message = ?, completed_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, cause.Error(), id)
+	return err
+}
+
+// Süresi dolan arşivler; dosyaları silindikten sonra markDataExportExpired ile kapatılır
+func (repo *KasaRepository) getExpiredDataExports(ctx context.Context, limit int) ([]DataExport, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT `+repo.dataExportColumns()+`
+		FROM data_exports
+		WHERE status = 'ready' AND expires_at <= CURRENT_TIMESTAMP
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("süresi dolan dışa aktarmalar alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	var expired []DataExport
+	for rows.Next() {
+		e, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		expired = append(expired, *e)
+	}
+	return expired, rows.Err()
+}
+
+func (repo *KasaRepository) markDataExportExpired(ctx context.Context, id int64) error {
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE data_exports SET status = 'expired', download_token = NULL, file_path = NULL WHERE id = ?
+	`, id)
+	return err
+}
+
+type ExportProfile struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	FullName  string `json:"full_name"`
+	IBAN      string `json:"iban"`
+	Locale    string `json:"locale"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type ExportGroupMembership struct {
+	GroupID   int64  `json:"group_id"`
+	GroupName string `json:"group_name"`
+	IsCreator bool   `json:"is_creator"`
+	JoinedAt  int64  `json:"joined_at"`
+}
+
+type ExportExpensePaid struct {
+	ExpenseID    int64   `json:"expense_id"`
+	GroupID      int64   `json:"group_id"`
+	GroupName    string  `json:"group_name"`
+	Title        string  `json:"title"`
+	Note         string  `json:"note"`
+	Amount       float64 `json:"amount"`
+	PaymentDate  int64   `json:"payment_date"`
+	BillImageURL string  `json:"bill_image_url"`
+	DeletedAt    *int64  `json:"deleted_at"`
+}
+
+type ExportShareOwed struct {
+	ExpenseID     int64   `json:"expense_id"`
+	GroupID       int64   `json:"group_id"`
+	GroupName     string  `json:"group_name"`
+	Title         string  `json:"title"`
+	PayerID       string  `json:"payer_id"`
+	PayerName     string  `json:"payer_name"`
+	AmountShare   float64 `json:"amount_share"`
+	PaymentStatus string  `json:"payment_status"`
+	PaymentDate   int64   `json:"payment_date"`
+}
+
+type ExportSettlement struct {
+	ExpenseID   int64   `json:"expense_id"`
+	GroupID     int64   `json:"group_id"`
+	GroupName   string  `json:"group_name"`
+	Direction   string  `json:"direction"` // paid: kullanıcı ödedi, received: kullanıcıya ödendi
+	OtherUserID string  `json:"other_user_id"`
+	OtherName   string  `json:"other_name"`
+	Amount      float64 `json:"amount"`
+}
+
+type ExportAddRequest struct {
+	RequestID   int64  `json:"request_id"`
+	GroupID     int64  `json:"group_id"`
+	GroupName   string `json:"group_name"`
+	Status      string `json:"status"`
+	RequestedAt int64  `json:"requested_at"`
+}
+
+type UserDataExport struct {
+	GeneratedAt  int64                   `json:"generated_at"`
+	Profile      ExportProfile           `json:"profile"`
+	Groups       []ExportGroupMembership `json:"groups"`
+	ExpensesPaid []ExportExpensePaid     `json:"expenses_paid"`
+	SharesOwed   []ExportShareOwed       `json:"shares_owed"`
+	Settlements  []ExportSettlement      `json:"settlements"`
+	AddRequests  []ExportAddRequest      `json:"add_requests"`
+}
+
+// collectUserData kullanıcıya ait tüm kayıtları dışa aktarma için toplar
+func (repo *KasaRepository) collectUserData(ctx context.Context, userID string) (*UserDataExport, error) {
+	data := &UserDataExport{
+		GeneratedAt:  time.Now().Unix(),
+		Groups:       []ExportGroupMembership{},
+		ExpensesPaid: []ExportExpensePaid{},
+		SharesOwed:   []ExportShareOwed{},
+		Settlements:  []ExportSettlement{},
+		AddRequests:  []ExportAddRequest{},
+	}
+
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT id, email, fullname, COALESCE(iban, ''), locale, `+repo.dialect.unix("created_at")+`
+		FROM users WHERE id = ?
+	`, userID).Scan(&data.Profile.ID, &data.Profile.Email, &data.Profile.FullName, &data.Profile.IBAN, &data.Profile.Locale, &data.Profile.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("profil alınamadı: %w", err)
+	}
+
+	// Grup üyelikleri
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT g.id, g.group_name, g.creator_id = ?, `+repo.dialect.unix("gm.joined_at")+`
+		FROM group_members gm
+		JOIN groups g ON g.id = gm.group_id
+		WHERE gm.user_id = ?
+		ORDER BY gm.joined_at
+	`, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("gruplar alınamadı: %w", err)
+	}
+	for rows.Next() {
+		var g ExportGroupMembership
+		if err := rows.Scan(&g.GroupID, &g.GroupName, &g.IsCreator, &g.JoinedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.Groups = append(data.Groups, g)
+	}
+	rows.Close()
+
+	// Kullanıcının ödediği harcamalar (geri alınabilir şekilde silinenler dahil)
+	rows, err = repo.DB.QueryContext(ctx, `
+		SELECT e.expense_id, COALESCE(e.group_id, 0), COALESCE(g.group_name, ''), e.payment_title, COALESCE(e.description_note, ''),
+			e.amount, `+repo.dialect.unix("e.payment_date")+`, COALESCE(e.bill_image_url, ''), `+repo.dialect.unix("e.deleted_at")+`
+		FROM group_expenses e
+		LEFT JOIN groups g ON g.id = e.group_id
+		WHERE e.payer_id = ?
+		ORDER BY e.payment_date
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("harcamalar alınamadı: %w", err)
+	}
+	for rows.Next() {
+		var e ExportExpensePaid
+		var deletedAt sql.NullInt64
+		if err := rows.Scan(&e.ExpenseID, &e.GroupID, &e.GroupName, &e.Title, &e.Note, &e.Amount, &e.PaymentDate, &e.BillImageURL, &deletedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if deletedAt.Valid {
+			e.DeletedAt = &deletedAt.Int64
+		}
+		data.ExpensesPaid = append(data.ExpensesPaid, e)
+	}
+	rows.Close()
+
+	// Başkalarının ödediği harcamalardaki payları
+	rows, err = repo.DB.QueryContext(ctx, `
+		SELECT e.expense_id, COALESCE(e.group_id, 0), COALESCE(g.group_name, ''), e.payment_title, e.payer_id, u.fullname,
+			p.amount_share, p.payment_status, `+repo.dialect.unix("e.payment_date")+`
+		FROM group_expense_participants p
+		JOIN group_expenses e ON e.expense_id = p.expense_id
+		LEFT JOIN groups g ON g.id = e.group_id
+		JOIN users u ON u.id = e.payer_id
+		WHERE p.user_id = ? AND e.payer_id <> ? AND e.deleted_at IS NULL
+		ORDER BY e.payment_date
+	`, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("paylar alınamadı: %w", err)
+	}
+	for rows.Next() {
+		var s ExportShareOwed
+		if err := rows.Scan(&s.ExpenseID, &s.GroupID, &s.GroupName, &s.Title, &s.PayerID, &s.PayerName, &s.AmountShare, &s.PaymentStatus, &s.PaymentDate); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.SharesOwed = append(data.SharesOwed, s)
+	}
+	rows.Close()
+
+	// Ödenmiş paylar: kullanıcının ödedikleri ve kullanıcıya ödenenler
+	rows, err = repo.DB.QueryContext(ctx, `
+		SELECT e.expense_id, COALESCE(e.group_id, 0), COALESCE(g.group_name, ''),
+			CASE WHEN p.user_id = ? THEN 'paid' ELSE 'received' END,
+			CASE WHEN p.user_id = ? THEN e.payer_id ELSE p.user_id END,
+			CASE WHEN p.user_id = ? THEN payer.fullname ELSE participant.fullname END,
+			p.amount_share
+		FROM group_expense_participants p
+		JOIN group_expenses e ON e.expense_id = p.expense_id
+		LEFT JOIN groups g ON g.id = e.group_id
+		JOIN users payer ON payer.id = e.payer_id
+		JOIN users participant ON participant.id = p.user_id
+		WHERE p.payment_status = 'paid'
+			AND p.user_id <> e.payer_id
+			AND (p.user_id = ? OR e.payer_id = ?)
+			AND e.deleted_at IS NULL
+		ORDER BY e.payment_date
+	`, userID, userID, userID, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ödemeler alınamadı: %w", err)
+	}
+	for rows.Next() {
+		var s ExportSettlement
+		if err := rows.Scan(&s.ExpenseID, &s.GroupID, &s.GroupName, &s.Direction, &s.OtherUserID, &s.OtherName, &s.Amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.Settlements = append(data.Settlements, s)
+	}
+	rows.Close()
+
+	// Kullanıcıya gelen grup ekleme istekleri
+	rows, err = repo.DB.QueryContext(ctx, `
+		SELECT r.request_id, r.group_id, g.group_name, r.request_status, `+repo.dialect.unix("r.requested_at")+`
+		FROM group_add_requests r
+		JOIN groups g ON g.id = r.group_id
+		WHERE r.user_id = ?
+		ORDER BY r.requested_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("istekler alınamadı: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a ExportAddRequest
+		if err := rows.Scan(&a.RequestID, &a.GroupID, &a.GroupName, &a.Status, &a.RequestedAt); err != nil {
+			return nil, err
+		}
+		data.AddRequests = append(data.AddRequests, a)
+	}
+	return data, rows.Err()
+}
+
+type GroupReportShare struct {
+	UserID        string  `json:"user_id"`
+	FullName      string  `json:"fullname"`
+	AmountShare   float64 `json:"amount_share"`
+	PaymentStatus string  `json:"payment_status"`
+}
+
+type GroupReportExpense struct {
+	ExpenseID    int64              `json:"expense_id"`
+	Title        string             `json:"title"`
+	Note         string             `json:"note"`
+	PayerID      string             `json:"payer_id"`
+	PayerName    string             `json:"payer_name"`
+	Amount       float64            `json:"amount"`
+	PaymentDate  int64              `json:"payment_date"`
+	Participants []GroupReportShare `json:"participants"`
+}
+
+type GroupReport struct {
+	GroupID     int64                `json:"group_id"`
+	GroupName   string               `json:"group_name"`
+	Currency    string               `json:"currency"`
+	GeneratedAt int64                `json:"generated_at"`
+	Members     map[string]string    `json:"members"` // id -> ad
+	Expenses    []GroupReportExpense `json:"expenses"`
+}
+
+// getGroupReport grubun silinmemiş tüm harcamalarını katılımcı paylarıyla birlikte döner
+func (repo *KasaRepository) getGroupReport(ctx context.Context, groupID int64) (*GroupReport, error) {
+	report := &GroupReport{
+		GroupID:     groupID,
+		GeneratedAt: time.Now().Unix(),
+		Members:     map[string]string{},
+	}
+
+	err := repo.DB.QueryRowContext(ctx, "SELECT group_name, currency FROM groups WHERE id = ?", groupID).
+		Scan(&report.GroupName, &report.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT u.id, u.fullname
+		FROM group_members gm
+		JOIN users u ON u.id = gm.user_id
+		WHERE gm.group_id = ?
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("grup üyeleri alınamadı: %w", err)
+	}
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.Members[id] = name
+	}
+	rows.Close()
+
+	rows, err = repo.DB.QueryContext(ctx, `
+		SELECT e.expense_id, e.payment_title, COALESCE(e.description_note, ''), e.payer_id, payer.fullname,
+			e.amount, `+repo.dialect.unix("e.payment_date")+`,
+			p.user_id, participant.fullname, COALESCE(p.amount_share, 0), p.payment_status
+		FROM group_expenses e
+		JOIN users payer ON payer.id = e.payer_id
+		JOIN group_expense_participants p ON p.expense_id = e.expense_id
+		JOIN users participant ON participant.id = p.user_id
+		WHERE e.group_id = ? AND e.deleted_at IS NULL
+		ORDER BY e.payment_date, e.expense_id, participant.fullname
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("grup harcamaları alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	report.Expenses = []GroupReportExpense{}
+	for rows.Next() {
+		var e GroupReportExpense
+		var share GroupReportShare
+		if err := rows.Scan(&e.ExpenseID, &e.Title, &e.Note, &e.PayerID, &e.PayerName, &e.Amount, &e.PaymentDate,
+			&share.UserID, &share.FullName, &share.AmountShare, &share.PaymentStatus); err != nil {
+			return nil, err
+		}
+
+		// Gruptan ayrılmış olsa da harcamada geçen herkes raporda yer alır
+		if _, ok := report.Members[e.PayerID]; !ok {
+			report.Members[e.PayerID] = e.PayerName
+		}
+		if _, ok := report.Members[share.UserID]; !ok {
+			report.Members[share.UserID] = share.FullName
+		}
+
+		last := len(report.Expenses) - 1
+		if last < 0 || report.Expenses[last].ExpenseID != e.ExpenseID {
+			report.Expenses = append(report.Expenses, e)
+			last++
+		}
+		report.Expenses[last].Participants = append(report.Expenses[last].Participants, share)
+	}
+	return report, rows.Err()
+}
+
+type GroupMemberRef struct {
+	ID            string `json:"id"`
+	FullName      string `json:"fullname"`
+	Email         string `json:"email"`
+	IsPlaceholder bool   `json:"is_placeholder"`
+}
+
+func (repo *KasaRepository) getGroupMemberRefs(ctx context.Context, groupID int64) ([]GroupMemberRef, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT u.id, u.fullname, u.email, u.is_placeholder
+		FROM group_members gm
+		JOIN users u ON u.id = gm.user_id
+		WHERE gm.group_id = ?
+		ORDER BY gm.joined_at
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("grup üyeleri alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	var members []GroupMemberRef
+	for rows.Next() {
+		var m GroupMemberRef
+		if err := rows.Scan(&m.ID, &m.FullName, &m.Email, &m.IsPlaceholder); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// createPlaceholderMemberTx hesabı olmayan bir kişiyi sadece bu grupta yaşayan bir üye olarak ekler.
+// Email benzersiz ve zorunlu olduğu için giriş yapılamayan sahte bir adres verilir.
+func createPlaceholderMemberTx(ctx context.Context, tx *sql.Tx, groupID int64, actorID, fullName, contactEmail, contactPhone string) (string, error) {
+	token, err := generateToken(24)
+	if err != nil {
+		return "", err
+	}
+	id := placeholderIDPrefix + token
+
+	if runes := []rune(fullName); len(runes) > 50 {
+		fullName = string(runes[:50])
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO users (id, fullname, email, is_placeholder, placeholder_group_id, contact_email, contact_phone)
+		VALUES (?, ?, ?, TRUE, ?, NULLIF(?, ''), NULLIF(?, ''))
+	`, id, fullName, "placeholder+"+id+"@kasa.invalid", groupID, contactEmail, contactPhone)
+	if err != nil {
+		return "", fmt.Errorf("geçici üye oluşturulamadı: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO group_members (group_id, user_id) VALUES (?, ?)", groupID, id); err != nil {
+		return "", fmt.Errorf("geçici üye gruba eklenemedi: %w", err)
+	}
+	if err := touchGroupMembers(ctx, tx, groupID); err != nil {
+		return "", err
+	}
+
+	err = logGroupActivity(ctx, tx, groupID, actorID, activityPlaceholderAdded, "user", id, nil, map[string]interface{}{
+		"fullname": fullName,
+	})
+	return id, err
+}
+
+// commitExpenseImport geçici üyeleri ve tüm harcamaları tek transaction içinde oluşturur.
+// placeholders anahtarı içe aktarılan dosyadaki isimdir; harcamalardaki UserID alanları bu isimlerle doldurulmuştur.
+func (repo *KasaRepository) commitExpenseImport(ctx context.Context, groupID int64, actorID string, placeholders []string, expenses []importedExpense) ([]int64, map[string]string, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	created := map[string]string{}
+	for _, name := range placeholders {
+		id, err := createPlaceholderMemberTx(ctx, tx, groupID, actorID, name, "", "")
+		if err != nil {
+			return nil, nil, err
+		}
+		created[name] = id
+	}
+	resolve := func(ref importPerson) string {
+		if ref.UserID != "" {
+			return ref.UserID
+		}
+		return created[ref.Name]
+	}
+
+	expenseIDs := make([]int64, 0, len(expenses))
+	for _, e := range expenses {
+		req := CreateExpenseRequest{
+			GroupID:      int(groupID),
+			TotalAmount:  e.Amount,
+			Note:         e.Note,
+			PaymentTitle: e.Title,
+			PaymentDate:  e.Date,
+		}
+		for _, share := range e.Shares {
+			amount := share.Amount
+			req.Users = append(req.Users, ExpenseUser{UserID: resolve(share.Person), Amount: &amount})
+		}
+
+		expense, err := repo.createGroupExpenseTx(ctx, tx, actorID, resolve(e.Payer), req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("satır %d içe aktarılamadı: %w", e.Row, err)
+		}
+		expenseIDs = append(expenseIDs, expense.ExpenseID)
+	}
+
+	err = logGroupActivity(ctx, tx, groupID, actorID, activityExpensesImported, "group", fmt.Sprint(groupID), nil, map[string]interface{}{
+		"expense_count":     len(expenseIDs),
+		"placeholder_count": len(created),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return expenseIDs, created, nil
+}
+
+// Geçici üye ID'leri bu önekle başlar; Firebase UID'leri alt çizgi içermez
+const placeholderIDPrefix = "ph_"
+
+func isPlaceholderID(userID string) bool {
+	return strings.HasPrefix(userID, placeholderIDPrefix)
+}
+
+var (
+	errPlaceholderNotFound  = newDomainError(ErrNotFound, "error.placeholder.not_found", "geçici üye bulunamadı")
+	errPlaceholderForbidden = newDomainError(ErrForbidden, "error.placeholder.claim_forbidden", "bu geçici üyeyi sahiplenme yetkiniz yok")
+)
+
+type PlaceholderMember struct {
+	ID           string  `json:"id"`
+	GroupID      int64   `json:"group_id"`
+	GroupName    string  `json:"group_name"`
+	FullName     string  `json:"fullname"`
+	ContactEmail *string `json:"contact_email"`
+	ContactPhone *string `json:"contact_phone"`
+	CreatedAt    int64   `json:"created_at"`
+}
+
+func (repo *KasaRepository) placeholderColumns() string {
+	return `
+	u.id, u.placeholder_group_id, g.group_name, u.fullname, u.contact_email, u.contact_phone, ` + repo.dialect.unix("u.created_at") + `
+`
+}
+
+func scanPlaceholders(rows *sql.Rows) ([]PlaceholderMember, error) {
+	defer rows.Close()
+	placeholders := []PlaceholderMember{}
+	for rows.Next() {
+		var p PlaceholderMember
+		var email, phone sql.NullString
+		if err := rows.Scan(&p.ID, &p.GroupID, &p.GroupName, &p.FullName, &email, &phone, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		if email.Valid {
+			p.ContactEmail = &email.String
+		}
+		if phone.Valid {
+			p.ContactPhone = &phone.String
+		}
+		placeholders = append(placeholders, p)
+	}
+	return placeholders, rows.Err()
+}
+
+func (repo *KasaRepository) createPlaceholderMember(ctx context.Context, groupID int64, actorID, fullName, contactEmail, contactPhone string) (*PlaceholderMember, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := createPlaceholderMemberTx(ctx, tx, groupID, actorID, fullName, contactEmail, contactPhone)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, "SELECT "+repo.placeholderColumns()+" FROM users u JOIN groups g ON g.id = u.placeholder_group_id WHERE u.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	placeholders, err := scanPlaceholders(rows)
+	if err != nil || len(placeholders) == 0 {
+		return nil, fmt.Errorf("geçici üye okunamadı: %w", err)
+	}
+	return &placeholders[0], nil
+}
+
+func (repo *KasaRepository) getGroupPlaceholders(ctx context.Context, groupID int64) ([]PlaceholderMember, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT `+repo.placeholderColumns()+`
+		FROM users u
+		JOIN groups g ON g.id = u.placeholder_group_id
+		WHERE u.is_placeholder = TRUE AND u.placeholder_group_id = ?
+		ORDER BY u.created_at
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("geçici üyeler alınamadı: %w", err)
+	}
+	return scanPlaceholders(rows)
+}
+
+// getClaimablePlaceholders iletişim emaili kullanıcının emailiyle eşleşen geçici üyeleri döner
+func (repo *KasaRepository) getClaimablePlaceholders(ctx context.Context, userID string) ([]PlaceholderMember, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT `+repo.placeholderColumns()+`
+		FROM users u
+		JOIN groups g ON g.id = u.placeholder_group_id
+		JOIN users me ON me.id = ?
+		WHERE u.is_placeholder = TRUE AND LOWER(u.contact_email) = LOWER(me.email)
+		ORDER BY u.created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("sahiplenilebilir geçici üyeler alınamadı: %w", err)
+	}
+	return scanPlaceholders(rows)
+}
+
+// claimPlaceholder geçici üyeyi gerçek kullanıcıya birleştirir. Kullanıcı ya geçici üyenin
+// iletişim emailine sahip olmalı ya da zaten aynı grubun üyesi olmalıdır.
+func (repo *KasaRepository) claimPlaceholder(ctx context.Context, placeholderID, userID string) (int64, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	var groupID int64
+	var emailMatches, isMember bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT u.placeholder_group_id,
+			COALESCE(LOWER(u.contact_email) = LOWER(me.email), FALSE),
+			EXISTS (SELECT 1 FROM group_members gm WHERE gm.group_id = u.placeholder_group_id AND gm.user_id = me.id)
+		FROM users u
+		JOIN users me ON me.id = ?
+		WHERE u.id = ? AND u.is_placeholder = TRUE
+		`+repo.dialect.forUpdate()+`
+	`, userID, placeholderID).Scan(&groupID, &emailMatches, &isMember)
+	if err == sql.ErrNoRows {
+		return 0, errPlaceholderNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("geçici üye alınamadı: %w", err)
+	}
+	if !emailMatches && !isMember {
+		return 0, errPlaceholderForbidden
+	}
+
+	if err := mergePlaceholderTx(ctx, tx, groupID, placeholderID, userID, userID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return groupID, nil
+}
+
+// claimMatchingPlaceholdersTx gruba katılan kullanıcının emailiyle açılmış geçici üyeleri otomatik birleştirir
+func claimMatchingPlaceholdersTx(ctx context.Context, tx *sql.Tx, groupID int64, userID string) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT u.id
+		FROM users u
+		JOIN users me ON me.id = ?
+		WHERE u.is_placeholder = TRUE AND u.placeholder_group_id = ? AND LOWER(u.contact_email) = LOWER(me.email)
+	`, userID, groupID)
+	if err != nil {
+		return 0, fmt.Errorf("eşleşen geçici üyeler alınamadı: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if err := mergePlaceholderTx(ctx, tx, groupID, id, userID, userID); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// mergePlaceholderTx geçici üyenin tüm kayıtlarını gerçek kullanıcıya taşır ve geçici üyeyi siler.
+// Aynı harcamada ikisi de katılımcıysa paylar toplanır.
+func mergePlaceholderTx(ctx context.Context, tx *sql.Tx, groupID int64, placeholderID, userID, actorID string) error {
+	var placeholderName string
+	if err := tx.QueryRowContext(ctx, "SELECT fullname FROM users WHERE id = ?", placeholderID).Scan(&placeholderName); err != nil {
+		return fmt.Errorf("geçici üye alınamadı: %w", err)
+	}
+
+	// Aynı harcamada ikisi de katılımcıysa geçici üyenin payı kullanıcının satırına eklenir, değilse satır devredilir.
+	// MySQL aynı tabloyu alt sorguda güncellemeye izin vermediği için satır satır yapılır (SQLite ile ortak kod).
+	if err := mergePlaceholderSharesTx(ctx, tx, placeholderID, userID); err != nil {
+		return err
+	}
+
+	steps := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"UPDATE group_expenses SET payer_id = ? WHERE payer_id = ?", []interface{}{userID, placeholderID}},
+
+		// Kendi ödediği harcamadaki payı ödenmiş sayılır
+		{`
+			UPDATE group_expense_participants
+			SET payment_status = 'paid'
+			WHERE user_id = ? AND expense_id IN (SELECT expense_id FROM group_expenses WHERE payer_id = ?)
+		`, []interface{}{userID, userID}},
+
+		{"UPDATE payment_reminders SET creditor_id = ? WHERE creditor_id = ?", []interface{}{userID, placeholderID}},
+		{"UPDATE payment_reminders SET debtor_id = ? WHERE debtor_id = ?", []interface{}{userID, placeholderID}},
+		{"DELETE FROM payment_reminders WHERE creditor_id = debtor_id", nil},
+		{"UPDATE group_activity SET actor_id = ? WHERE actor_id = ?", []interface{}{userID, placeholderID}},
+		{"DELETE FROM notifications WHERE user_id = ?", []interface{}{placeholderID}},
+		{"DELETE FROM group_members WHERE user_id = ?", []interface{}{placeholderID}},
+		{"DELETE FROM users WHERE id = ? AND is_placeholder = TRUE", []interface{}{placeholderID}},
+	}
+	for _, step := range steps {
+		if _, err := tx.ExecContext(ctx, step.query, step.args...); err != nil {
+			return fmt.Errorf("geçici üye birleştirilemedi: %w", err)
+		}
+	}
+
+	var isMember int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID).Scan(&isMember); err != nil {
+		return fmt.Errorf("üye kontrolü sırasında hata: %w", err)
+	}
+	if isMember == 0 {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO group_members (group_id, user_id) VALUES (?, ?)", groupID, userID); err != nil {
+			return fmt.Errorf("geçici üye birleştirilemedi: %w", err)
+		}
+	}
+	if err := touchGroupMembers(ctx, tx, groupID); err != nil {
+		return err
+	}
+
+	return logGroupActivity(ctx, tx, groupID, actorID, activityPlaceholderMerged, "user", placeholderID,
+		map[string]interface{}{"placeholder_id": placeholderID, "fullname": placeholderName},
+		map[string]interface{}{"user_id": userID})
+}
+
+func mergePlaceholderSharesTx(ctx context.Context, tx *sql.Tx, placeholderID, userID string) error {
+	type share struct {
+		expenseID int64
+		amount    sql.NullFloat64
+		status    string
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT expense_id, amount_share, payment_status FROM group_expense_participants WHERE user_id = ?
+	`, placeholderID)
+	if err != nil {
+		return fmt.Errorf("geçici üyenin payları alınamadı: %w", err)
+	}
+	var shares []share
+	for rows.Next() {
+		var sh share
+		if err := rows.Scan(&sh.expenseID, &sh.amount, &sh.status); err != nil {
+			rows.Close()
+			return err
+		}
+		shares = append(shares, sh)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, sh := range shares {
+		var mineStatus string
+		err := tx.QueryRowContext(ctx, `
+			SELECT payment_status FROM group_expense_participants WHERE expense_id = ? AND user_id = ?
+		`, sh.expenseID, userID).Scan(&mineStatus)
+		if err == sql.ErrNoRows {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE group_expense_participants SET user_id = ? WHERE expense_id = ? AND user_id = ?
+			`, userID, sh.expenseID, placeholderID)
+			if err != nil {
+				return fmt.Errorf("geçici üyenin payı devredilemedi: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("kullanıcının payı alınamadı: %w", err)
+		}
+
+		status := "unpaid"
+		if mineStatus == "paid" && sh.status == "paid" {
+			status = "paid"
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE group_expense_participants
+			SET amount_share = amount_share + ?, payment_status = ?
+			WHERE expense_id = ? AND user_id = ?
+		`, sh.amount, status, sh.expenseID, userID)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `
+				DELETE FROM group_expense_participants WHERE expense_id = ? AND user_id = ?
+			`, sh.expenseID, placeholderID)
+		}
+		if err != nil {
+			return fmt.Errorf("geçici üyenin payı birleştirilemedi: %w", err)
+		}
+	}
+	return nil
+}
+
+var (
+	errFriendNotFound        = newDomainError(ErrNotFound, "error.user.not_found", "kullanıcı bulunamadı")
+	errFriendSelf            = newDomainError(ErrValidation, "error.friend.self", "kendinize arkadaşlık isteği gönderemezsiniz")
+	errAlreadyFriends        = newDomainError(ErrConflict, "error.friend.already_friends", "bu kullanıcı zaten arkadaşınız")
+	errFriendRequestPending  = newDomainError(ErrConflict, "error.friend.request_pending", "bu kullanıcıyla bekleyen bir arkadaşlık isteği var")
+	errFriendRequestNotFound = newDomainError(ErrNotFound, "error.friend.request_not_found", "bu istek zaten işlenmiş veya mevcut değil")
+	errNotFriends            = newDomainError(ErrForbidden, "error.friend.not_friends", "bu kullanıcı arkadaşınız değil")
+	errFriendExpenseNotFound = newDomainError(ErrNotFound, "error.expense.not_found", "harcama bulunamadı")
+)
+
+// sendFriendRequest email ile bulunan kullanıcıya arkadaşlık isteği gönderir (grup isteğiyle aynı akış)
+func (repo *KasaRepository) sendFriendRequest(ctx context.Context, requesterID, email string) (int64, string, error) {
+	var userID string
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT id FROM users WHERE email = ? AND is_placeholder = FALSE AND deleted = FALSE
+	`, email).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, "", errFriendNotFound
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("kullanıcı kontrolü sırasında hata: %w", err)
+	}
+	if userID == requesterID {
+		return 0, "", errFriendSelf
+	}
+
+	friends, err := repo.areFriends(ctx, requesterID, userID)
+	if err != nil {
+		return 0, "", err
+	}
+	if friends {
+		return 0, "", errAlreadyFriends
+	}
+
+	var pending int
+	err = repo.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM friend_requests
+		WHERE request_status = 'pending'
+			AND ((requester_id = ? AND user_id = ?) OR (requester_id = ? AND user_id = ?))
+	`, requesterID, userID, userID, requesterID).Scan(&pending)
+	if err != nil {
+		return 0, "", fmt.Errorf("istek kontrolü sırasında hata: %w", err)
+	}
+	if pending > 0 {
+		return 0, "", errFriendRequestPending
+	}
+
+	res, err := repo.DB.ExecContext(ctx, "INSERT INTO friend_requests (requester_id, user_id) VALUES (?, ?)", requesterID, userID)
+	if err != nil {
+		return 0, "", fmt.Errorf("arkadaşlık isteği eklenemedi: %w", err)
+	}
+	requestID, err := res.LastInsertId()
+	return requestID, userID, err
+}
+
+type FriendRequest struct {
+	RequestID      int64  `json:"request_id"`
+	RequesterID    string `json:"requester_id"`
+	RequesterName  string `json:"requester_name"`
+	RequesterEmail string `json:"requester_email"`
+	RequestedAt    int64  `json:"requested_at"`
+}
+
+func (repo *KasaRepository) getMyFriendRequests(ctx context.Context, userID string) ([]FriendRequest, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT fr.request_id, u.id, u.fullname, u.email, `+repo.dialect.unix("fr.requested_at")+`
+		FROM friend_requests fr
+		JOIN users u ON u.id = fr.requester_id
+		WHERE fr.user_id = ? AND fr.request_status = 'pending'
+		ORDER BY fr.requested_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("arkadaşlık istekleri alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []FriendRequest{}
+	for rows.Next() {
+		var fr FriendRequest
+		if err := rows.Scan(&fr.RequestID, &fr.RequesterID, &fr.RequesterName, &fr.RequesterEmail, &fr.RequestedAt); err != nil {
+			return nil, err
+		}
+		requests = append(requests, fr)
+	}
+	return requests, rows.Err()
+}
+
+// respondFriendRequest isteği kabul ya da reddeder; kabulde arkadaşlık iki yönlü yazılır.
+// İsteği gönderenin ID'sini döner.
+func (repo *KasaRepository) respondFriendRequest(ctx context.Context, requestID int64, userID string, accept bool) (string, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	var requesterID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT requester_id FROM friend_requests
+		WHERE request_id = ? AND user_id = ? AND request_status = 'pending'
+		`+repo.dialect.forUpdate()+`
+	`, requestID, userID).Scan(&requesterID)
+	if err == sql.ErrNoRows {
+		return "", errFriendRequestNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("arkadaşlık isteği alınamadı: %w", err)
+	}
+
+	status := "rejected"
+	if accept {
+		status = "accepted"
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE friend_requests SET request_status = ?, responded_at = CURRENT_TIMESTAMP WHERE request_id = ?
+	`, status, requestID); err != nil {
+		return "", fmt.Errorf("arkadaşlık isteği güncellenemedi: %w", err)
+	}
+
+	if accept {
+		if _, err := tx.ExecContext(ctx, `
+			`+repo.dialect.insertIgnore()+` INTO friendships (user_id, friend_id) VALUES (?, ?), (?, ?)
+		`, userID, requesterID, requesterID, userID); err != nil {
+			return "", fmt.Errorf("arkadaşlık eklenemedi: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return requesterID, nil
+}
+
+func (repo *KasaRepository) areFriends(ctx context.Context, userID, friendID string) (bool, error) {
+	var exists bool
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM friendships WHERE user_id = ? AND friend_id = ?)
+	`, userID, friendID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("arkadaşlık kontrolü başarısız: %w", err)
+	}
+	return exists, nil
+}
+
+func (repo *KasaRepository) removeFriend(ctx context.Context, userID, friendID string) error {
+	res, err := repo.DB.ExecContext(ctx, `
+		DELETE FROM friendships
+		WHERE (user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)
+	`, userID, friendID, friendID, userID)
+	if err != nil {
+		return fmt.Errorf("arkadaşlık silinemedi: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errNotFriends
+	}
+	return nil
+}
+
+// CounterpartyBalance bir kişiyle olan net bakiye. Pozitifse karşı taraf borçlu, negatifse kullanıcı borçlu.
+type CounterpartyBalance struct {
+	UserID        string  `json:"user_id"`
+	FullName      string  `json:"fullname"`
+	Email         string  `json:"email"`
+	IsFriend      bool    `json:"is_friend"`
+	GroupBalance  float64 `json:"group_balance"`
+	FriendBalance float64 `json:"friend_balance"`
+	Total         float64 `json:"total"`
+}
+
+// getCounterpartyBalances ödenmemiş payları kişi bazında toplar; grup ve grup dışı harcamalar ayrı gösterilir.
+// Arkadaşlar bakiyesi sıfır olsa da listede yer alır.
+func (repo *KasaRepository) getCounterpartyBalances(ctx context.Context, userID string) ([]CounterpartyBalance, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT u.id, u.fullname, u.email,
+			EXISTS (SELECT 1 FROM friendships f WHERE f.user_id = ? AND f.friend_id = u.id),
+			COALESCE(SUM(CASE WHEN b.is_group THEN b.amount END), 0),
+			COALESCE(SUM(CASE WHEN NOT b.is_group THEN b.amount END), 0)
+		FROM (
+			SELECT p.user_id AS other_id, e.group_id IS NOT NULL AS is_group, p.amount_share AS amount
+			FROM group_expense_participants p
+			JOIN group_expenses e ON e.expense_id = p.expense_id
+			WHERE e.payer_id = ? AND p.user_id <> ? AND p.payment_status = 'unpaid' AND e.deleted_at IS NULL
+
+			UNION ALL
+
+			SELECT e.payer_id, e.group_id IS NOT NULL, -p.amount_share
+			FROM group_expense_participants p
+			JOIN group_expenses e ON e.expense_id = p.expense_id
+			WHERE p.user_id = ? AND e.payer_id <> ? AND p.payment_status = 'unpaid' AND e.deleted_at IS NULL
+
+			UNION ALL
+
+			SELECT f.friend_id, FALSE, 0
+			FROM friendships f
+			WHERE f.user_id = ?
+		) b
+		JOIN users u ON u.id = b.other_id
+		GROUP BY u.id, u.fullname, u.email
+		ORDER BY u.fullname
+	`, userID, userID, userID, userID, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("bakiyeler alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	balances := []CounterpartyBalance{}
+	for rows.Next() {
+		var b CounterpartyBalance
+		if err := rows.Scan(&b.UserID, &b.FullName, &b.Email, &b.IsFriend, &b.GroupBalance, &b.FriendBalance); err != nil {
+			return nil, err
+		}
+		b.GroupBalance = math.Round(b.GroupBalance*100) / 100
+		b.FriendBalance = math.Round(b.FriendBalance*100) / 100
+		b.Total = math.Round((b.GroupBalance+b.FriendBalance)*100) / 100
+		if b.Total == 0 && !b.IsFriend {
+			continue
+		}
+		balances = append(balances, b)
+	}
+	return balances, rows.Err()
+}
+
+// createFriendExpense grup dışı bir harcama ekler; ödeyen katılımcılar arasında olmalı, ödeyen dışındaki
+// herkes de ödeyenin arkadaşı olmalıdır
+func (repo *KasaRepository) createFriendExpense(ctx context.Context, payerID string, req CreateExpenseRequest) (*ExpenseWithParticipants, error) {
+	if err := checkExpensePayer(payerID, req.Users); err != nil {
+		return nil, err
+	}
+	for _, u := range req.Users {
+		if u.UserID == payerID {
+			continue
+		}
+		friends, err := repo.areFriends(ctx, payerID, u.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !friends {
+			return nil, errNotFriends
+		}
+	}
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	req.GroupID = 0
+	expense, err := repo.createGroupExpenseTx(ctx, tx, payerID, payerID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return expense, nil
+}
+
+// getFriendExpenses iki kişinin birlikte yer aldığı grup dışı harcamaları döner
+func (repo *KasaRepository) getFriendExpenses(ctx context.Context, userID, friendID string) ([]ExpenseWithParticipants, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT
+			e.expense_id, e.payer_id, u.fullname,
+			e.amount, e.description_note, e.payment_title, `+repo.dialect.unix("e.payment_date")+`, e.bill_image_url,
+			`+participantsJSON(repo.dialect)+` AS participants
+		FROM group_expenses e
+		LEFT JOIN users u ON u.id = e.payer_id
+		WHERE e.group_id IS NULL AND e.deleted_at IS NULL
+			AND (e.payer_id = ? OR EXISTS (SELECT 1 FROM group_expense_participants p WHERE p.expense_id = e.expense_id AND p.user_id = ?))
+			AND (e.payer_id = ? OR EXISTS (SELECT 1 FROM group_expense_participants p WHERE p.expense_id = e.expense_id AND p.user_id = ?))
+		ORDER BY e.payment_date DESC
+	`, userID, userID, friendID, friendID)
+	if err != nil {
+		return nil, fmt.Errorf("harcamalar alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	expenses := []ExpenseWithParticipants{}
+	for rows.Next() {
+		var e ExpenseWithParticipants
+		var participantsRaw sql.NullString
+		if err := rows.Scan(&e.ExpenseID, &e.PayerID, &e.PayerName, &e.Amount, &e.DescriptionNote, &e.PaymentTitle,
+			&e.PaymentDate, &e.BillImageURL, &participantsRaw); err != nil {
+			return nil, err
+		}
+		e.Participants = []Participant{}
+		if err := decodeJSONList(participantsRaw, &e.Participants); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, e)
+	}
+	return expenses, rows.Err()
+}
+
+// settleFriendBalance iki kişi arasındaki grup dışı ödenmemiş payları iki yönde de ödendi işaretler
+func (repo *KasaRepository) settleFriendBalance(ctx context.Context, userID, friendID string) (int64, error) {
+	res, err := repo.DB.ExecContext(ctx, `
+		UPDATE group_expense_participants
+		SET payment_status = 'paid'
+		WHERE payment_status = 'unpaid'
+			AND ((user_id = ? AND expense_id IN (
+					SELECT expense_id FROM group_expenses WHERE group_id IS NULL AND deleted_at IS NULL AND payer_id = ?
+				))
+				OR (user_id = ? AND expense_id IN (
+					SELECT expense_id FROM group_expenses WHERE group_id IS NULL AND deleted_at IS NULL AND payer_id = ?
+				)))
+	`, friendID, userID, userID, friendID)
+	if err != nil {
+		return 0, fmt.Errorf("ödeme kaydedilemedi: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// deleteFriendExpense grup dışı harcamayı kalıcı olarak siler; sadece ödeyen silebilir
+func (repo *KasaRepository) deleteFriendExpense(ctx context.Context, userID string, expenseID int64) error {
+	res, err := repo.DB.ExecContext(ctx, `
+		DELETE FROM group_expenses WHERE expense_id = ? AND group_id IS NULL AND payer_id = ?
+	`, expenseID, userID)
+	if err != nil {
+		return fmt.Errorf("harcama silinemedi: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errFriendExpenseNotFound
+	}
+	return nil
+}
+
+// group_add_requests yönleri: yöneticinin kullanıcıyı davet etmesi ya da kullanıcının katılmak istemesi
+const (
+	requestDirectionInvite = "invite"
+	requestDirectionJoin   = "join"
+)
+
+// Davet bağlantısının durumları
+const (
+	inviteStatusActive    = "active"
+	inviteStatusExpired   = "expired"
+	inviteStatusExhausted = "exhausted"
+	inviteStatusRevoked   = "revoked"
+)
+
+var (
+	errInviteNotFound     = newDomainError(ErrNotFound, "error.invite.not_found", "davet bağlantısı bulunamadı")
+	errInviteRevoked      = newDomainError(ErrGone, "error.invite.revoked", "davet bağlantısı iptal edilmiş")
+	errInviteExpired      = newDomainError(ErrGone, "error.invite.expired", "davet bağlantısının süresi dolmuş")
+	errInviteExhausted    = newDomainError(ErrGone, "error.invite.exhausted", "davet bağlantısının kullanım hakkı dolmuş")
+	errJoinRequestPending = newDomainError(ErrConflict, "error.invite.request_pending", "bu grup için bekleyen bir katılım isteği zaten var")
+)
+
+// canRespondAddRequest davetleri davet edilen kullanıcının, katılım isteklerini ise
+// grup yöneticisinin yanıtlayabileceğini kontrol eder
+func canRespondAddRequest(direction, userID, reqUserID, creatorID string) bool {
+	if direction == requestDirectionJoin {
+		return userID == creatorID
+	}
+	return userID == reqUserID
+}
+
+type InviteLinkUse struct {
+	UserID   string `json:"user_id"`
+	FullName string `json:"fullname"`
+	UsedAt   int64  `json:"used_at"`
+}
+
+// InviteLink bir grubun davet bağlantısıdır. ExpiresAt ve MaxUses nil ise sınırsızdır;
+// onay bekleyen katılım istekleri de kullanım hakkından düşer.
+type InviteLink struct {
+	LinkID           int64           `json:"link_id"`
+	GroupID          int64           `json:"group_id"`
+	Token            string          `json:"token"`
+	CreatedBy        string          `json:"created_by"`
+	CreatedAt        int64           `json:"created_at"`
+	ExpiresAt        *int64          `json:"expires_at"`
+	MaxUses          *int            `json:"max_uses"`
+	UseCount         int             `json:"use_count"`
+	PendingRequests  int             `json:"pending_requests"`
+	RequiresApproval bool            `json:"requires_approval"`
+	RevokedAt        *int64          `json:"revoked_at"`
+	IsDefault        bool            `json:"is_default"`
+	Status           string          `json:"status"`
+	Uses             []InviteLinkUse `json:"uses"`
+}
+
+func (l *InviteLink) status(now time.Time) string {
+	switch {
+	case l.RevokedAt != nil:
+		return inviteStatusRevoked
+	case l.ExpiresAt != nil && *l.ExpiresAt <= now.Unix():
+		return inviteStatusExpired
+	case l.MaxUses != nil && l.UseCount+l.PendingRequests >= *l.MaxUses:
+		return inviteStatusExhausted
+	}
+	return inviteStatusActive
+}
+
+func (l *InviteLink) usable() error {
+	switch l.status(time.Now()) {
+	case inviteStatusRevoked:
+		return errInviteRevoked
+	case inviteStatusExpired:
+		return errInviteExpired
+	case inviteStatusExhausted:
+		return errInviteExhausted
+	}
+	return nil
+}
+
+type CreateInviteLinkRequest struct {
+	ExpiresInHours   int  `json:"expires_in_hours" validate:"min=0"`
+	MaxUses          int  `json:"max_uses" validate:"min=0"`
+	RequiresApproval bool `json:"requires_approval"`
+}
+
+func (repo *KasaRepository) inviteLinkColumns() string {
+	d := repo.dialect
+	return `
+	l.link_id, l.group_id, l.token, l.created_by, ` + d.unix("l.created_at") + `,
+	` + d.unix("l.expires_at") + `, l.max_uses, l.use_count, l.requires_approval, ` + d.unix("l.revoked_at") + `,
+	l.token = g.group_token,
+	(
+		SELECT COUNT(*) FROM group_add_requests r
+		WHERE r.invite_link_id = l.link_id AND r.request_status = 'pending'
+	)`
+}
+
+func scanInviteLink(row interface{ Scan(...any) error }) (*InviteLink, error) {
+	var l InviteLink
+	var expiresAt, maxUses, revokedAt sql.NullInt64
+	err := row.Scan(&l.LinkID, &l.GroupID, &l.Token, &l.CreatedBy, &l.CreatedAt,
+		&expiresAt, &maxUses, &l.UseCount, &l.RequiresApproval, &revokedAt,
+		&l.IsDefault, &l.PendingRequests)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		l.ExpiresAt = &expiresAt.Int64
+	}
+	if maxUses.Valid {
+		n := int(maxUses.Int64)
+		l.MaxUses = &n
+	}
+	if revokedAt.Valid {
+		l.RevokedAt = &revokedAt.Int64
+	}
+	l.Status = l.status(time.Now())
+	l.Uses = []InviteLinkUse{}
+	return &l, nil
+}
+
+// ensureLegacyInviteLink bağlantılardan önce açılmış gruplarda group_token için
+// bağlantı kaydı oluşturur; böylece eski token da sayılır ve iptal edilebilir.
+func (repo *KasaRepository) ensureLegacyInviteLink(ctx context.Context, db sqlExecer, groupID int64, token string) error {
+	_, err := db.ExecContext(ctx, `
+		`+repo.dialect.insertIgnore()+` INTO group_invite_links (group_id, token, created_by, created_at)
+		SELECT g.id, g.group_token, g.creator_id, g.created_at
+		FROM groups g
+		WHERE (g.id = ? OR g.group_token = ?)
+			AND NOT EXISTS (SELECT 1 FROM group_invite_links l WHERE l.token = g.group_token)
+	`, groupID, token)
+	if err != nil {
+		return fmt.Errorf("varsayılan davet bağlantısı oluşturulamadı: %w", err)
+	}
+	return nil
+}
+
+func (repo *KasaRepository) lockInviteLinkByToken(ctx context.Context, tx *sql.Tx, token string) (*InviteLink, error) {
+	link, err := scanInviteLink(tx.QueryRowContext(ctx, `
+		SELECT `+repo.inviteLinkColumns()+`
+		FROM group_invite_links l
+		JOIN groups g ON g.id = l.group_id
+		WHERE l.token = ?
+		`+repo.dialect.forUpdate()+`
+	`, token))
+	if err == sql.ErrNoRows {
+		return nil, errInviteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("davet bağlantısı okunamadı: %w", err)
+	}
+	return link, nil
+}
+
+func (repo *KasaRepository) lockInviteLinkByID(ctx context.Context, tx *sql.Tx, groupID, linkID int64) (*InviteLink, error) {
+	link, err := scanInviteLink(tx.QueryRowContext(ctx, `
+		SELECT `+repo.inviteLinkColumns()+`
+		FROM group_invite_links l
+		JOIN groups g ON g.id = l.group_id
+		WHERE l.link_id = ? AND l.group_id = ?
+		`+repo.dialect.forUpdate()+`
+	`, linkID, groupID))
+	if err == sql.ErrNoRows {
+		return nil, errInviteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("davet bağlantısı okunamadı: %w", err)
+	}
+	return link, nil
+}
+
+// recordInviteLinkUseTx bağlantıyla katılan kullanıcıyı kaydeder ve kullanım sayısını artırır
+func recordInviteLinkUseTx(ctx context.Context, tx *sql.Tx, linkID int64, userID string) error {
+	// Aynı kullanıcı bağlantıyı ikinci kez harcamaz (MySQL ve SQLite'ta ortak çalışsın diye INSERT IGNORE kullanılmaz)
+	var used int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM group_invite_link_uses WHERE link_id = ? AND user_id = ?
+	`, linkID, userID).Scan(&used)
+	if err != nil {
+		return fmt.Errorf("davet bağlantısı kullanımı okunamadı: %w", err)
+	}
+	if used > 0 {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO group_invite_link_uses (link_id, user_id) VALUES (?, ?)
+	`, linkID, userID)
+	if err != nil {
+		return fmt.Errorf("davet bağlantısı kullanımı yazılamadı: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE group_invite_links SET use_count = use_count + 1 WHERE link_id = ?", linkID)
+	if err != nil {
+		return fmt.Errorf("davet bağlantısı kullanımı yazılamadı: %w", err)
+	}
+	return nil
+}
+
+// createJoinRequestTx onay isteyen bir bağlantı için yöneticinin yanıtlayacağı katılım isteği açar
+func createJoinRequestTx(ctx context.Context, tx *sql.Tx, link *InviteLink, userID string) (int64, error) {
+	var pending int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM group_add_requests
+		WHERE group_id = ? AND user_id = ? AND request_status = 'pending'
+	`, link.GroupID, userID).Scan(&pending)
+	if err != nil {
+		return 0, fmt.Errorf("mevcut istek kontrolü sırasında hata: %w", err)
+	}
+	if pending > 0 {
+		return 0, errJoinRequestPending
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO group_add_requests (group_id, user_id, request_direction, invite_link_id)
+		VALUES (?, ?, 'join', ?)
+	`, link.GroupID, userID, link.LinkID)
+	if err != nil {
+		return 0, fmt.Errorf("katılım isteği oluşturulamadı: %w", err)
+	}
+	requestID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := touchGroupMembers(ctx, tx, link.GroupID); err != nil {
+		return 0, err
+	}
+
+	err = logGroupActivity(ctx, tx, link.GroupID, userID, activityJoinRequested, "add_request", fmt.Sprint(requestID), nil,
+		map[string]interface{}{"user_id": userID, "request_direction": requestDirectionJoin, "link_id": link.LinkID})
+	if err != nil {
+		return 0, err
+	}
+	return requestID, nil
+}
+
+// createInviteLink gruba yeni bir davet bağlantısı ekler. Sıfır değerler sınırsız demektir.
+func (repo *KasaRepository) createInviteLink(ctx context.Context, groupID int64, actorID string, req CreateInviteLinkRequest) (*InviteLink, error) {
+	token, err := generateToken(8)
+	if err != nil {
+		return nil, fmt.Errorf("token üretilemedi: %w", err)
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+	}
+	var maxUses *int
+	if req.MaxUses > 0 {
+		maxUses = &req.MaxUses
+	}
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	linkID, err := insertInviteLinkTx(ctx, tx, groupID, token, actorID, expiresAt, maxUses, req.RequiresApproval)
+	if err != nil {
+		return nil, err
+	}
+	link, err := repo.lockInviteLinkByID(ctx, tx, groupID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = logGroupActivity(ctx, tx, groupID, actorID, activityInviteLinkCreated, "invite_link", fmt.Sprint(linkID), nil, link)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return link, nil
+}
+
+func insertInviteLinkTx(ctx context.Context, tx *sql.Tx, groupID int64, token, createdBy string, expiresAt *time.Time, maxUses *int, requiresApproval bool) (int64, error) {
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO group_invite_links (group_id, token, created_by, expires_at, max_uses, requires_approval)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, groupID, token, createdBy, expiresAt, maxUses, requiresApproval)
+	if err != nil {
+		return 0, fmt.Errorf("davet bağlantısı oluşturulamadı: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// getGroupInviteLinks grubun tüm davet bağlantılarını bağlantıyla katılan kullanıcılarla birlikte döner
+func (repo *KasaRepository) getGroupInviteLinks(ctx context.Context, groupID int64) ([]*InviteLink, error) {
+	if err := repo.ensureLegacyInviteLink(ctx, repo.DB, groupID, ""); err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT `+repo.inviteLinkColumns()+`
+		FROM group_invite_links l
+		JOIN groups g ON g.id = l.group_id
+		WHERE l.group_id = ?
+		ORDER BY l.created_at DESC, l.link_id DESC
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("davet bağlantıları alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	links := []*InviteLink{}
+	byID := map[int64]*InviteLink{}
+	for rows.Next() {
+		link, err := scanInviteLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("davet bağlantısı okunamadı: %w", err)
+		}
+		links = append(links, link)
+		byID[link.LinkID] = link
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	useRows, err := repo.DB.QueryContext(ctx, `
+		SELECT lu.link_id, u.id, u.fullname, `+repo.dialect.unix("lu.used_at")+`
+		FROM group_invite_link_uses lu
+		JOIN group_invite_links l ON l.link_id = lu.link_id
+		JOIN users u ON u.id = lu.user_id
+		WHERE l.group_id = ?
+		ORDER BY lu.used_at
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("davet bağlantısı kullanımları alınamadı: %w", err)
+	}
+	defer useRows.Close()
+
+	for useRows.Next() {
+		var linkID int64
+		var use InviteLinkUse
+		if err := useRows.Scan(&linkID, &use.UserID, &use.FullName, &use.UsedAt); err != nil {
+			return nil, fmt.Errorf("davet bağlantısı kullanımı okunamadı: %w", err)
+		}
+		if link, ok := byID[linkID]; ok {
+			link.Uses = append(link.Uses, use)
+		}
+	}
+	return links, useRows.Err()
+}
+
+// revokeInviteLink bağlantıyı iptal eder; iptal edilmiş bir bağlantıda işlem yapılmaz
+func (repo *KasaRepository) revokeInviteLink(ctx context.Context, groupID, linkID int64, actorID string) error {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	link, err := repo.lockInviteLinkByID(ctx, tx, groupID, linkID)
+	if err != nil {
+		return err
+	}
+	if link.RevokedAt != nil {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE group_invite_links SET revoked_at = CURRENT_TIMESTAMP, revoked_by = ? WHERE link_id = ?
+	`, actorID, linkID)
+	if err != nil {
+		return fmt.Errorf("davet bağlantısı iptal edilemedi: %w", err)
+	}
+
+	err = logGroupActivity(ctx, tx, groupID, actorID, activityInviteLinkRevoked, "invite_link", fmt.Sprint(linkID),
+		map[string]interface{}{"status": link.Status},
+		map[string]interface{}{"status": inviteStatusRevoked})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return nil
+}
+
+// rotateInviteLink bağlantıyı aynı ayarlarla yeni bir token'a taşır ve eskisini iptal eder.
+// Varsayılan bağlantı yenilenirse groups.group_token da güncellenir.
+func (repo *KasaRepository) rotateInviteLink(ctx context.Context, groupID, linkID int64, actorID string) (*InviteLink, error) {
+	token, err := generateToken(8)
+	if err != nil {
+		return nil, fmt.Errorf("token üretilemedi: %w", err)
+	}
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	old, err := repo.lockInviteLinkByID(ctx, tx, groupID, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if old.RevokedAt != nil {
+		return nil, errInviteRevoked
+	}
+
+	var expiresAt *time.Time
+	if old.ExpiresAt != nil {
+		t := time.Unix(*old.ExpiresAt, 0)
+		expiresAt = &t
+	}
+	// Kalan kullanım hakkı yeni bağlantıya aktarılır; bekleyen katılım istekleri de aşağıda yeni
+	// bağlantıya taşındığı için hakkın bir kısmını tutmaya devam eder
+	var maxUses *int
+	if old.MaxUses != nil {
+		remaining := *old.MaxUses - old.UseCount
+		if remaining < 0 {
+			remaining = 0
+		}
+		maxUses = &remaining
+	}
+
+	newID, err := insertInviteLinkTx(ctx, tx, groupID, token, actorID, expiresAt, maxUses, old.RequiresApproval)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE group_add_requests SET invite_link_id = ? WHERE invite_link_id = ? AND request_status = 'pending'
+	`, newID, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("bekleyen katılım istekleri taşınamadı: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE group_invite_links SET revoked_at = CURRENT_TIMESTAMP, revoked_by = ? WHERE link_id = ?
+	`, actorID, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("davet bağlantısı iptal edilemedi: %w", err)
+	}
+	if old.IsDefault {
+		if _, err := tx.ExecContext(ctx, "UPDATE groups SET group_token = ? WHERE id = ?", token, groupID); err != nil {
+			return nil, fmt.Errorf("grup token'ı güncellenemedi: %w", err)
+		}
+		// group_token grup yanıtında görünür
+		if err := touchGroupMembers(ctx, tx, groupID); err != nil {
+			return nil, err
+		}
+	}
+
+	link, err := repo.lockInviteLinkByID(ctx, tx, groupID, newID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = logGroupActivity(ctx, tx, groupID, actorID, activityInviteLinkRotated, "invite_link", fmt.Sprint(linkID),
+		map[string]interface{}{"link_id": linkID},
+		map[string]interface{}{"link_id": newID})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return link, nil
+}
+
+type GroupJoinSettings struct {
+	GroupID          int64 `json:"group_id"`
+	RequiresApproval bool  `json:"requires_approval"`
+}
+
+func (repo *KasaRepository) getGroupJoinSettings(ctx context.Context, groupID int64) (*GroupJoinSettings, error) {
+	settings := GroupJoinSettings{GroupID: groupID}
+	err := repo.DB.QueryRowContext(ctx, "SELECT join_requires_approval FROM groups WHERE id = ?", groupID).Scan(&settings.RequiresApproval)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// setGroupJoinApproval açıkken grup token'ı ya da davet bağlantısıyla gelen herkes yönetici onayı bekler
+func (repo *KasaRepository) setGroupJoinApproval(ctx context.Context, actorID string, settings GroupJoinSettings) error {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	var before bool
+	err = tx.QueryRowContext(ctx, "SELECT join_requires_approval FROM groups WHERE id = ? "+repo.dialect.forUpdate(), settings.GroupID).Scan(&before)
+	if err != nil {
+		return err
+	}
+	if before == settings.RequiresApproval {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE groups SET join_requires_approval = ? WHERE id = ?", settings.RequiresApproval, settings.GroupID)
+	if err != nil {
+		return fmt.Errorf("katılım ayarı kaydedilemedi: %w", err)
+	}
+
+	err = logGroupActivity(ctx, tx, settings.GroupID, actorID, activityJoinSettingsUpdated, "group", fmt.Sprint(settings.GroupID),
+		map[string]interface{}{"requires_approval": before},
+		map[string]interface{}{"requires_approval": settings.RequiresApproval})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return nil
+}
+
+var errInvitationPending = newDomainError(ErrConflict, "error.invitation.pending", "bu email adresine zaten bekleyen bir davet gönderilmiş")
+
+// EmailInvitation henüz hesabı olmayan bir email adresine gönderilen grup davetidir.
+// Kişi bu email ile kayıt olduğunda davet normal bir grup ekleme isteğine dönüşür.
+type EmailInvitation struct {
+	InvitationID int64  `json:"invitation_id"`
+	GroupID      int64  `json:"group_id"`
+	GroupName    string `json:"group_name"`
+	Email        string `json:"email"`
+	InvitedBy    string `json:"invited_by"`
+	InviterName  string `json:"inviter_name"`
+	CreatedAt    int64  `json:"created_at"`
+	Status       string `json:"status"`
+	RequestID    *int64 `json:"request_id"`
+}
+
+func (repo *KasaRepository) emailInvitationColumns() string {
+	return `
+	i.invitation_id, i.group_id, g.group_name, i.email, i.invited_by, u.fullname,
+	` + repo.dialect.unix("i.created_at") + `, i.invitation_status, i.request_id`
+}
+
+func scanEmailInvitation(row interface{ Scan(...any) error }) (*EmailInvitation, error) {
+	var inv EmailInvitation
+	var requestID sql.NullInt64
+	err := row.Scan(&inv.InvitationID, &inv.GroupID, &inv.GroupName, &inv.Email, &inv.InvitedBy, &inv.InviterName,
+		&inv.CreatedAt, &inv.Status, &requestID)
+	if err != nil {
+		return nil, err
+	}
+	if requestID.Valid {
+		inv.RequestID = &requestID.Int64
+	}
+	return &inv, nil
+}
+
+func (repo *KasaRepository) createEmailInvitation(ctx context.Context, groupID, email, invitedBy string) (*EmailInvitation, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	groupIDInt, err := strconv.ParseInt(groupID, 10, 64)
+	if err != nil {
+		return nil, errInvalidGroupID
+	}
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	var pending int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM email_invitations
+		WHERE group_id = ? AND email = ? AND invitation_status = 'pending'
+	`, groupIDInt, email).Scan(&pending)
+	if err != nil {
+		return nil, fmt.Errorf("mevcut davet kontrolü sırasında hata: %w", err)
+	}
+	if pending > 0 {
+		return nil, errInvitationPending
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO email_invitations (group_id, email, invited_by) VALUES (?, ?, ?)
+	`, groupIDInt, email, invitedBy)
+	if err != nil {
+		return nil, fmt.Errorf("davet oluşturulamadı: %w", err)
+	}
+	invitationID, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	err = logGroupActivity(ctx, tx, groupIDInt, invitedBy, activityInvitationSent, "email_invitation", fmt.Sprint(invitationID), nil,
+		map[string]interface{}{"invitation_id": invitationID, "email": email})
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := scanEmailInvitation(tx.QueryRowContext(ctx, `
+		SELECT `+repo.emailInvitationColumns()+`
+		FROM email_invitations i
+		JOIN groups g ON g.id = i.group_id
+		JOIN users u ON u.id = i.invited_by
+		WHERE i.invitation_id = ?
+	`, invitationID))
+	if err != nil {
+		return nil, fmt.Errorf("davet okunamadı: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return invitation, nil
+}
+
+// convertEmailInvitations yeni kayıt olan kullanıcının email adresine açılmış davetleri
+// bekleyen grup ekleme isteklerine çevirir. Zaten üye olunan ya da isteği bekleyen
+// gruplar için istek açılmaz, davet yine de kapatılır.
+func (repo *KasaRepository) convertEmailInvitations(ctx context.Context, userID, email string) ([]*EmailInvitation, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+repo.emailInvitationColumns()+`
+		FROM email_invitations i
+		JOIN groups g ON g.id = i.group_id
+		JOIN users u ON u.id = i.invited_by
+		WHERE i.email = ? AND i.invitation_status = 'pending'
+		ORDER BY i.created_at
+		`+repo.dialect.forUpdate()+`
+	`, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return nil, fmt.Errorf("davetler alınamadı: %w", err)
+	}
+	var invitations []*EmailInvitation
+	for rows.Next() {
+		inv, err := scanEmailInvitation(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("davet okunamadı: %w", err)
+		}
+		invitations = append(invitations, inv)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var converted []*EmailInvitation
+	for _, inv := range invitations {
+		var existing int
+		err := tx.QueryRowContext(ctx, `
+			SELECT
+				(SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_id = ?) +
+				(SELECT COUNT(*) FROM group_add_requests WHERE group_id = ? AND user_id = ? AND request_status = 'pending')
+		`, inv.GroupID, userID, inv.GroupID, userID).Scan(&existing)
+		if err != nil {
+			return nil, fmt.Errorf("üyelik kontrolü sırasında hata: %w", err)
+		}
+
+		var requestID interface{}
+		if existing == 0 {
+			res, err := tx.ExecContext(ctx, "INSERT INTO group_add_requests (group_id, user_id) VALUES (?, ?)", inv.GroupID, userID)
+			if err != nil {
+				return nil, fmt.Errorf("grup ekleme isteği oluşturulamadı: %w", err)
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return nil, err
+			}
+			requestID = id
+			inv.RequestID = &id
+			if err := touchGroupMembers(ctx, tx, inv.GroupID); err != nil {
+				return nil, err
+			}
+
+			err = logGroupActivity(ctx, tx, inv.GroupID, inv.InvitedBy, activityRequestSent, "add_request", fmt.Sprint(id), nil, map[string]interface{}{
+				"request_id":    id,
+				"user_id":       userID,
+				"email":         inv.Email,
+				"invitation_id": inv.InvitationID,
+			})
+			if err != nil {
+				return nil, err
+			}
+			converted = append(converted, inv)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE email_invitations
+			SET invitation_status = 'converted', request_id = ?, converted_at = CURRENT_TIMESTAMP
+			WHERE invitation_id = ?
+		`, requestID, inv.InvitationID)
+		if err != nil {
+			return nil, fmt.Errorf("davet güncellenemedi: %w", err)
+		}
+		inv.Status = "converted"
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return converted, nil
+}
+
+// OutgoingAddRequest yöneticinin grubundan gönderilmiş bir davettir
+type OutgoingAddRequest struct {
+	RequestID      int64  `json:"request_id"`
+	GroupID        int64  `json:"group_id"`
+	GroupName      string `json:"group_name"`
+	UserID         string `json:"user_id"`
+	FullName       string `json:"fullname"`
+	Email          string `json:"email"`
+	RequestedAt    int64  `json:"requested_at"`
+	LastNotifiedAt *int64 `json:"last_notified_at"`
+	Status         string `json:"request_status"`
+}
+
+func (repo *KasaRepository) outgoingAddRequestColumns() string {
+	return `
+	r.request_id, r.group_id, g.group_name, r.user_id, u.fullname, u.email,
+	` + repo.dialect.unix("r.requested_at") + `, ` + repo.dialect.unix("r.last_notified_at") + `, r.request_status`
+}
+
+func scanOutgoingAddRequest(row interface{ Scan(...any) error }) (*OutgoingAddRequest, error) {
+	var req OutgoingAddRequest
+	var lastNotified sql.NullInt64
+	err := row.Scan(&req.RequestID, &req.GroupID, &req.GroupName, &req.UserID, &req.FullName, &req.Email,
+		&req.RequestedAt, &lastNotified, &req.Status)
+	if err != nil {
+		return nil, err
+	}
+	if lastNotified.Valid {
+		req.LastNotifiedAt = &lastNotified.Int64
+	}
+	return &req, nil
+}
+
+// getGroupOutgoingRequests grubun gönderdiği davetleri listeler; status boşsa hepsi döner
+func (repo *KasaRepository) getGroupOutgoingRequests(ctx context.Context, groupID int64, status string) ([]*OutgoingAddRequest, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT `+repo.outgoingAddRequestColumns()+`
+		FROM group_add_requests r
+		JOIN groups g ON g.id = r.group_id
+		JOIN users u ON u.id = r.user_id
+		WHERE r.group_id = ? AND r.request_direction = 'invite' AND (? = '' OR r.request_status = ?)
+		ORDER BY r.requested_at DESC, r.request_id DESC
+	`, groupID, status, status)
+	if err != nil {
+		return nil, fmt.Errorf("gönderilen istekler alınamadı: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []*OutgoingAddRequest{}
+	for rows.Next() {
+		req, err := scanOutgoingAddRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("istek okunamadı: %w", err)
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
+
+func (repo *KasaRepository) lockOutgoingAddRequest(ctx context.Context, tx *sql.Tx, groupID, requestID int64) (*OutgoingAddRequest, error) {
+	req, err := scanOutgoingAddRequest(tx.QueryRowContext(ctx, `
+		SELECT `+repo.outgoingAddRequestColumns()+`
+		FROM group_add_requests r
+		JOIN groups g ON g.id = r.group_id
+		JOIN users u ON u.id = r.user_id
+		WHERE r.request_id = ? AND r.group_id = ? AND r.request_direction = 'invite'
+		`+repo.dialect.forUpdate()+`
+	`, requestID, groupID))
+	if err == sql.ErrNoRows {
+		return nil, errAddRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("istek okunamadı: %w", err)
+	}
+	if req.Status != "pending" {
+		return nil, errAddRequestNotPending
+	}
+	return req, nil
+}
+
+// cancelAddRequest bekleyen daveti geri çeker
+func (repo *KasaRepository) cancelAddRequest(ctx context.Context, groupID, requestID int64, actorID string) error {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	req, err := repo.lockOutgoingAddRequest(ctx, tx, groupID, requestID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE group_add_requests SET request_status = 'cancelled', cancelled_by = ? WHERE request_id = ?
+	`, actorID, requestID)
+	if err != nil {
+		return fmt.Errorf("istek iptal edilemedi: %w", err)
+	}
+	if err := touchGroupMembers(ctx, tx, groupID); err != nil {
+		return err
+	}
+
+	err = logGroupActivity(ctx, tx, groupID, actorID, activityRequestCancelled, "add_request", fmt.Sprint(requestID),
+		map[string]interface{}{"request_status": req.Status, "user_id": req.UserID},
+		map[string]interface{}{"request_status": "cancelled", "user_id": req.UserID})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return nil
+}
+
+// resendAddRequest bekleyen davet için bildirimi tekrar göndermeye izin verip zamanı kaydeder.
+// Son bildirimden bu yana cooldown geçmediyse errAddRequestResendTooSoon ve kalan süre döner.
+func (repo *KasaRepository) resendAddRequest(ctx context.Context, groupID, requestID int64, actorID string, cooldown time.Duration) (*OutgoingAddRequest, time.Duration, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("transaction başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	req, err := repo.lockOutgoingAddRequest(ctx, tx, groupID, requestID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	last := req.RequestedAt
+	if req.LastNotifiedAt != nil {
+		last = *req.LastNotifiedAt
+	}
+	if wait := cooldown - time.Since(time.Unix(last, 0)); wait > 0 {
+		return nil, wait, errAddRequestResendTooSoon
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE group_add_requests SET last_notified_at = CURRENT_TIMESTAMP WHERE request_id = ?", requestID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("istek güncellenemedi: %w", err)
+	}
+
+	err = logGroupActivity(ctx, tx, groupID, actorID, activityRequestResent, "add_request", fmt.Sprint(requestID), nil,
+		map[string]interface{}{"user_id": req.UserID})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("transaction commit edilemedi: %w", err)
+	}
+	return req, 0, nil
+}
+
+// expirePendingAddRequests ttlDays günden eski bekleyen istekleri 'expired' yapar, en fazla limit kadar işler.
+// requested_at veritabanı saatiyle yazıldığından karşılaştırma da veritabanında yapılır.
+func (repo *KasaRepository) expirePendingAddRequests(ctx context.Context, ttlDays int, limit int) (int, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT request_id, group_id, request_direction
+		FROM group_add_requests
+		WHERE request_status = 'pending' AND requested_at < `+repo.dialect.minus("CURRENT_TIMESTAMP", "DAY")+`
+		ORDER BY requested_at
+		LIMIT ?
+	`, ttlDays, limit)
+	if err != nil {
+		return 0, fmt.Errorf("süresi dolan istekler alınamadı: %w", err)
+	}
+	type target struct {
+		requestID int64
+		groupID   int64
+		direction string
+	}
+	var targets []target
+	for rows.Next() {
+		var t target
+		if err := rows.Scan(&t.requestID, &t.groupID, &t.direction); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		targets = append(targets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, t := range targets {
+		tx, err := repo.DB.BeginTx(ctx, nil)
+		if err != nil {
+			return expired, fmt.Errorf("transaction başlatılamadı: %w", err)
+		}
+		// Bu arada yanıtlanmış istekler atlanır
+		res, err := tx.ExecContext(ctx, `
+			UPDATE group_add_requests SET request_status = 'expired'
+			WHERE request_id = ? AND request_status = 'pending'
+		`, t.requestID)
+		if err != nil {
+			tx.Rollback()
+			return expired, fmt.Errorf("istek güncellenemedi: %w", err)
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			tx.Rollback()
+			continue
+		}
+		if err := touchGroupMembers(ctx, tx, t.groupID); err != nil {
+			tx.Rollback()
+			return expired, err
+		}
+		err = logGroupActivity(ctx, tx, t.groupID, "", activityRequestExpired, "add_request", fmt.Sprint(t.requestID),
+			map[string]interface{}{"request_status": "pending"},
+			map[string]interface{}{"request_status": "expired", "request_direction": t.direction})
+		if err != nil {
+			tx.Rollback()
+			return expired, err
+		}
+		if err := tx.Commit(); err != nil {
+			return expired, fmt.Errorf("transaction commit edilemedi: %w", err)
+		}
+		expired++
+	}
+	return expired, nil
 }
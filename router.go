@@ -0,0 +1,161 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Middleware bir handler'ı sarar (kimlik doğrulama, eski yol uyarısı vb.)
+type Middleware func(http.Handler) http.Handler
+
+// Router http.ServeMux'un metot ve yol parametresi desteği üzerine ön ek, middleware zinciri
+// ve yerelleştirilmiş 405 yanıtı ekler. Group ile oluşturulan alt router'lar aynı mux'u paylaşır.
+type Router struct {
+	mux        *http.ServeMux
+	prefix     string
+	middleware []Middleware
+	global     []Middleware
+	allowed    *allowedMethods
+}
+
+// allowedMethods yol başına kayıtlı metotlardır; 405 yanıtındaki Allow başlığı için tutulur
+type allowedMethods struct {
+	mu      sync.RWMutex
+	methods map[string][]string
+}
+
+func NewRouter() *Router {
+	return &Router{
+		mux:     http.NewServeMux(),
+		allowed: &allowedMethods{methods: map[string][]string{}},
+	}
+}
+
+// Group ön eki ve middleware'leri mevcut router'ınkilere ekleyen bir alt router döner
+func (rt *Router) Group(prefix string, mw ...Middleware) *Router {
+	return &Router{
+		mux:        rt.mux,
+		prefix:     rt.prefix + prefix,
+		middleware: append(append([]Middleware{}, rt.middleware...), mw...),
+		allowed:    rt.allowed,
+	}
+}
+
+// Handle handler'ı verilen metot ve yola kaydeder. Yolda {id} gibi parametreler kullanılabilir,
+// handler içinde r.PathValue ile okunur. Aynı yola başka bir metotla gelen istek 405 alır.
+func (rt *Router) Handle(method, path string, h http.Handler, mw ...Middleware) {
+	full := rt.prefix + path
+	h = chain(h, append(append([]Middleware{}, rt.middleware...), mw...)...)
+	rt.mux.Handle(method+" "+full, h)
+
+	rt.allowed.mu.Lock()
+	defer rt.allowed.mu.Unlock()
+	if _, seen := rt.allowed.methods[full]; !seen {
+		// Metotsuz desen metotlu olanlardan daha az özeldir, sadece diğer metotları yakalar
+		rt.mux.Handle(full, rt.methodNotAllowed(full))
+	}
+	rt.allowed.methods[full] = append(rt.allowed.methods[full], method)
+}
+
+func (rt *Router) HandleFunc(method, path string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(method, path, h, mw...)
+}
+
+// Mount deseni metot kontrolü olmadan kaydeder (statik dosyalar, ana sayfa gibi)
+func (rt *Router) Mount(pattern string, h http.Handler) {
+	rt.mux.Handle(pattern, chain(h, rt.middleware...))
+}
+
+// Use yol eşleşmesinden önce, 405 dahil tüm isteklere uygulanan middleware ekler. Sadece kök router'da anlamlıdır.
+func (rt *Router) Use(mw ...Middleware) {
+	rt.global = append(rt.global, mw...)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	chain(rt.mux, rt.global...).ServeHTTP(w, r)
+}
+
+func (rt *Router) methodNotAllowed(path string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rt.allowed.mu.RLock()
+		methods := append([]string{}, rt.allowed.methods[path]...)
+		rt.allowed.mu.RUnlock()
+		for _, m := range methods {
+			if m == http.MethodGet {
+				methods = append(methods, http.MethodHead)
+				break
+			}
+		}
+		sort.Strings(methods)
+
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		httpError(w, r, "error.method_not_allowed", http.StatusMethodNotAllowed, nil)
+	})
+}
+
+// chain middleware'leri sırayla uygular; ilk verilen en dışta çalışır
+func chain(h http.Handler, mw ...Middleware) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// deprecated eski RPC tarzı yolları işaretler; istemci yanıt başlıklarından yeni yolu öğrenir.
+// successor'daki {id} gibi parametreler eski yolun aynı adlı değerleriyle doldurulur. Değeri
+// gövdede gelen eski yollarda (örn. /delete-expense) yeni yol bilinemez, Link başlığı eklenmez.
+func deprecated(successor string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			if link, ok := expandPath(successor, r); ok {
+				w.Header().Set("Link", "<"+link+`>; rel="successor-version"`)
+			}
+			log.Printf("Eski yol kullanıldı: %s %s (yeni: %s)", r.Method, r.URL.Path, successor)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// expandPath desendeki {ad} parametrelerini isteğin yol değerleriyle değiştirir; değeri olmayan
+// parametre varsa ok false döner
+func expandPath(pattern string, r *http.Request) (path string, ok bool) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			b.WriteString(pattern)
+			return b.String(), true
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return "", false
+		}
+		value := r.PathValue(pattern[start+1 : start+end])
+		if value == "" {
+			return "", false
+		}
+		b.WriteString(pattern[:start])
+		b.WriteString(url.PathEscape(value))
+		pattern = pattern[start+end+1:]
+	}
+}
+
+// pathInt64 yol parametresini sayı olarak okur. Parametre yolda yoksa ok false döner; eski yollar
+// aynı değeri istek gövdesinden okur. Geçersiz değer 0 döner.
+func pathInt64(r *http.Request, name string) (value int64, ok bool) {
+	raw := r.PathValue(name)
+	if raw == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, true
+	}
+	return value, true
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeprecatedLink(t *testing.T) {
+	rt := NewRouter()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	rt.Handle("GET", "/groups/{id}/activity", ok, deprecated(apiV1+"/groups/{id}/activity"))
+	rt.Handle("DELETE", "/delete-expense", ok, deprecated(apiV1+"/groups/{id}/expenses/{expenseId}"))
+	rt.Handle("GET", "/get-me", ok, deprecated(apiV1+"/me"))
+
+	cases := []struct {
+		method, path, link string
+	}{
+		{"GET", "/groups/42/activity", `</api/v1/groups/42/activity>; rel="successor-version"`},
+		{"GET", "/get-me", `</api/v1/me>; rel="successor-version"`},
+		// Grup ve harcama ID'si gövdede gelir, yeni yol bilinmez
+		{"DELETE", "/delete-expense", ""},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		if w.Header().Get("Deprecation") != "true" {
+			t.Errorf("%s %s: Deprecation başlığı yok", c.method, c.path)
+		}
+		if got := w.Header().Get("Link"); got != c.link {
+			t.Errorf("%s %s: Link %q, beklenen %q", c.method, c.path, got, c.link)
+		}
+	}
+}
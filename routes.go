@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// apiV1 güncel API'nin ön ekidir
+const apiV1 = "/api/v1"
+
+// newRouter tüm HTTP uçlarını kaydeder. Kaynak yolları /api/v1 altındadır; uygulama taşınana
+// kadar ilk sürümdeki RPC tarzı yollar aynı handler'lara, Deprecation başlığıyla yönlenir. Yeni
+// uçlar sadece /api/v1 altında kaydedilir.
+func newRouter(repo Repository) *Router {
+	rt := NewRouter()
+	rt.Use(RequestIDMiddleware)
+	auth := func(next http.Handler) http.Handler {
+		return AuthMiddleware(next, repo)
+	}
+	// Tekrar denenen değiştirme istekleri Idempotency-Key ile bir kez işlenir, bkz. idempotency.go
+	ttl := idempotencyKeyTTL()
+	idempotent := func(next http.Handler) http.Handler {
+		return IdempotencyMiddleware(next, repo, ttl)
+	}
+
+	rt.Mount("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintln(w, `
+        <!DOCTYPE html>
+        <html>
+        <head>
+            <title>Deneme Sayfası</title>
+        </head>
+        <body>
+            <h1>Hoşgeldin!</h1>
+            <p>Bu bir basit HTML sayfasıdır.</p>
+        </body>
+        </html>
+    `)
+	}))
+
+	rt.Mount("/v", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "Merhaba, test serverı çalışıyor!")
+	}))
+
+	fs := http.FileServer(http.Dir("./uploads"))
+	rt.Mount("/uploads/", http.StripPrefix("/uploads/", fs))
+
+	// API sözleşmesi, bkz. api/openapi.json
+	rt.Handle("GET", "/openapi.json", handleOpenAPISpec())
+
+	v1 := rt.Group(apiV1)
+	api := v1.Group("", auth, idempotent)
+
+	// İlk sürümdeki yollar; her biri yeni yolun takma adıdır
+	legacy := func(method, path, successor string, h http.Handler, withAuth bool) {
+		mw := []Middleware{deprecated(apiV1 + successor)}
+		if withAuth {
+			mw = append(mw, auth, idempotent)
+		}
+		rt.Handle(method, path, h, mw...)
+	}
+
+	// Kimlik
+	v1.Handle("POST", "/auth/register", RegisterUserHandler(repo))
+	v1.Handle("POST", "/auth/login", LoginUserHandler(repo))
+	v1.Handle("POST", "/auth/google", LoginWGoogleHandler(repo))
+	legacy("POST", "/register", "/auth/register", RegisterUserHandler(repo), false)
+	legacy("POST", "/login", "/auth/login", LoginUserHandler(repo), false)
+	legacy("POST", "/login-google", "/auth/google", LoginWGoogleHandler(repo), false)
+
+	// Hesap
+	api.Handle("GET", "/me", getMeHandler(repo))
+	api.Handle("PATCH", "/me", updateUserHandler(repo))
+	api.Handle("DELETE", "/me", handleDeleteAccount(repo))
+	api.Handle("POST", "/me/restore", handleRestoreAccount(repo))
+	api.Handle("GET", "/me/deletion", handleGetAccountDeletionStatus(repo))
+	api.Handle("PUT", "/me/fcm-token", handleSaveFCMToken(repo))
+	api.Handle("GET", "/me/data-exports", handleGetDataExports(repo))
+	api.Handle("POST", "/me/data-exports", handleRequestDataExport(repo))
+	v1.Handle("GET", "/data-exports/download", handleDownloadDataExport(repo))
+	api.Handle("POST", "/uploads/photos", uploadPhotoHandler(repo))
+	legacy("GET", "/get-me", "/me", getMeHandler(repo), true)
+	legacy("PATCH", "/update-user", "/me", updateUserHandler(repo), true)
+	legacy("DELETE", "/delete-account", "/me", handleDeleteAccount(repo), true)
+	legacy("POST", "/save-fcm-token", "/me/fcm-token", handleSaveFCMToken(repo), true)
+	legacy("POST", "/upload-photo", "/uploads/photos", uploadPhotoHandler(repo), true)
+
+	// Gruplar
+	api.Handle("GET", "/groups", GetGroups(repo))
+	api.Handle("POST", "/groups", CreateGroupHandler(repo))
+	api.Handle("POST", "/invites/{token}/join", addGroupWithTokenHandler(repo))
+	api.Handle("GET", "/groups/{id}/activity", handleGetGroupActivity(repo))
+	api.Handle("GET", "/groups/{id}/export", handleExportGroup(repo))
+	api.Handle("POST", "/groups/{id}/import", handleImportExpenses(repo))
+	api.Handle("GET", "/groups/{id}/join-settings", handleGetGroupJoinSettings(repo))
+	api.Handle("PUT", "/groups/{id}/join-settings", handleUpdateGroupJoinSettings(repo))
+	api.Handle("GET", "/groups/{id}/reminder-settings", handleGetReminderSettings(repo))
+	api.Handle("PUT", "/groups/{id}/reminder-settings", handleSaveReminderSettings(repo))
+	api.Handle("POST", "/groups/{id}/nudges", handleNudgeDebtor(repo))
+	legacy("GET", "/groups", "/groups", GetGroups(repo), true)
+	legacy("POST", "/create-group", "/groups", CreateGroupHandler(repo), true)
+	legacy("POST", "/add-group-token", "/invites/{token}/join", addGroupWithTokenHandler(repo), true)
+
+	// Harcamalar ve ödemeler
+	api.Handle("POST", "/groups/{id}/expenses", handleCreateGroupExpense(repo))
+	api.Handle("DELETE", "/groups/{id}/expenses/{expenseId}", handleDeleteExpense(repo))
+	api.Handle("POST", "/groups/{id}/expenses/{expenseId}/restore", handleRestoreExpense(repo))
+	api.Handle("GET", "/groups/{id}/deleted-expenses", handleGetDeletedExpenses(repo))
+	api.Handle("POST", "/groups/{id}/payments", handlePayGroupExpense(repo))
+	legacy("POST", "/add-group-expense", "/groups/{id}/expenses", handleCreateGroupExpense(repo), true)
+	legacy("DELETE", "/delete-expense", "/groups/{id}/expenses/{expenseId}", handleDeleteExpense(repo), true)
+	legacy("POST", "/pay-group-expense", "/groups/{id}/payments", handlePayGroupExpense(repo), true)
+
+	// Grup ekleme istekleri
+	api.Handle("GET", "/add-requests", handleGetAddRequests(repo))
+	api.Handle("POST", "/add-requests/{requestId}/accept", handleAcceptAddRequest(repo))
+	api.Handle("POST", "/add-requests/{requestId}/reject", handleRejectAddRequest(repo))
+	api.Handle("GET", "/groups/{id}/add-requests", handleGetOutgoingRequests(repo))
+	api.Handle("POST", "/groups/{id}/add-requests", SendAddRequest(repo))
+	api.Handle("DELETE", "/groups/{id}/add-requests/{requestId}", handleCancelAddRequest(repo))
+	api.Handle("POST", "/groups/{id}/add-requests/{requestId}/resend", handleResendAddRequest(repo))
+	legacy("GET", "/get-my-add-requests", "/add-requests", handleGetAddRequests(repo), true)
+	legacy("POST", "/accept-add-request", "/add-requests/{requestId}/accept", handleAcceptAddRequest(repo), true)
+	legacy("POST", "/reject-add-request", "/add-requests/{requestId}/reject", handleRejectAddRequest(repo), true)
+	legacy("POST", "/send-add-group-request", "/groups/{id}/add-requests", SendAddRequest(repo), true)
+
+	// Davet bağlantıları
+	api.Handle("GET", "/groups/{id}/invite-links", handleGetInviteLinks(repo))
+	api.Handle("POST", "/groups/{id}/invite-links", handleCreateInviteLink(repo))
+	api.Handle("DELETE", "/groups/{id}/invite-links/{linkId}", handleRevokeInviteLink(repo))
+	api.Handle("POST", "/groups/{id}/invite-links/{linkId}/rotate", handleRotateInviteLink(repo))
+
+	// Geçici üyeler
+	api.Handle("GET", "/groups/{id}/placeholders", handleGetGroupPlaceholders(repo))
+	api.Handle("POST", "/groups/{id}/placeholders", handleCreateGroupPlaceholder(repo))
+	api.Handle("GET", "/placeholders/claimable", handleGetClaimablePlaceholders(repo))
+	api.Handle("POST", "/placeholders/{id}/claim", handleClaimPlaceholder(repo))
+
+	// Bildirimler
+	api.Handle("GET", "/notifications", handleGetNotifications(repo))
+	api.Handle("GET", "/notifications/unread-count", handleGetUnreadNotificationCount(repo))
+	api.Handle("POST", "/notifications/mark-read", handleMarkNotificationsRead(repo))
+	api.Handle("POST", "/notifications/mark-all-read", handleMarkAllNotificationsRead(repo))
+
+	// Arkadaşlar
+	api.Handle("GET", "/friend-requests", handleGetFriendRequests(repo))
+	api.Handle("POST", "/friend-requests", handleSendFriendRequest(repo))
+	api.Handle("POST", "/friend-requests/{requestId}/accept", handleRespondFriendRequest(repo, true))
+	api.Handle("POST", "/friend-requests/{requestId}/reject", handleRespondFriendRequest(repo, false))
+	api.Handle("GET", "/friends", handleGetFriends(repo, true))
+	api.Handle("DELETE", "/friends/{id}", handleRemoveFriend(repo))
+	api.Handle("GET", "/friends/{id}/expenses", handleGetFriendExpenses(repo))
+	api.Handle("POST", "/friends/{id}/settle", handleSettleFriend(repo))
+	api.Handle("POST", "/friend-expenses", handleCreateFriendExpense(repo))
+	api.Handle("DELETE", "/friend-expenses/{id}", handleDeleteFriendExpense(repo))
+	api.Handle("GET", "/balances", handleGetFriends(repo, false))
+
+	return rt
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteSchema MySQL migration'larının SQLite karşılığıdır; testler ve DB_DRIVER=sqlite ile yerelde
+// çalışan sunucu KasaRepository'yi MySQL sunucusu olmadan bu şema ve SQLite lehçesiyle kullanır.
+//
+//go:embed database/sqlite/schema.sql
+var sqliteSchema string
+
+// useSQLite DB_DRIVER=sqlite ise true döner. SQLite sadece yerelde MySQL olmadan çalışmak içindir;
+// migration'ları yoktur, şema her açılışta IF NOT EXISTS ile uygulanır.
+func useSQLite() bool {
+	return strings.EqualFold(os.Getenv("DB_DRIVER"), "sqlite")
+}
+
+// sqlitePath DB_PATH'teki veritabanı dosyasıdır, boşsa ./kasa.db
+func sqlitePath() string {
+	if path := os.Getenv("DB_PATH"); path != "" {
+		return path
+	}
+	return "./kasa.db"
+}
+
+// openSQLiteRepository verilen dosyayı (":memory:" bellekte) açar, şemayı uygular ve
+// SQLite lehçesiyle çalışan bir KasaRepository döner
+func openSQLiteRepository(path string) (*KasaRepository, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("sqlite açılamadı: %w", err)
+	}
+	// SQLite tek yazıcıya izin verir; bellekteki veritabanı da bağlantıya özeldir
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite şeması uygulanamadı: %w", err)
+	}
+	return &KasaRepository{DB: db, dialect: dialectSQLite}, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestOpenSQLiteRepositoryReopen sunucu yeniden başladığında şemanın mevcut dosyaya tekrar
+// uygulanabildiğini ve kayıtların korunduğunu doğrular
+func TestOpenSQLiteRepositoryReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kasa.db")
+	repo, err := openSQLiteRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateUser("sqlite-user", "Kullanıcı", "sqlite@example.com", "hash", "TR00", "tr"); err != nil {
+		t.Fatal(err)
+	}
+	repo.DB.Close()
+
+	repo, err = openSQLiteRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.DB.Close()
+	user, err := repo.GetUserByID("sqlite-user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "sqlite@example.com" {
+		t.Errorf("email %q, beklenen sqlite@example.com", user.Email)
+	}
+}
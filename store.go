@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// Depolama katmanı alan bazında arayüzlere bölünmüştür; handler'lar ve arka plan işleri
+// ihtiyaç duydukları en dar arayüzü, birden fazla alana dokunanlar Repository'yi alır.
+// Tek uygulama KasaRepository'dir: üretimde MySQL, testlerde ve DB_DRIVER=sqlite ile yerelde
+// SQLite lehçesiyle çalışır (bkz. dialect.go, sqlite.go ve conformance_test.go).
+
+// UserStore kullanıcı hesapları
+type UserStore interface {
+	CreateUser(id, username, email, hashedPassword string, iban string, locale string) error
+	// InsertUser kullanıcı varsa ad ve IBAN'ı günceller (Google ile giriş)
+	InsertUser(user User) error
+	// GetUserByID kullanıcı yoksa sql.ErrNoRows döner
+	GetUserByID(userID string) (*User, error)
+	// GetUserIDByEmail kullanıcı yoksa boş string döner
+	GetUserIDByEmail(email string) (string, error)
+	GetUserLocale(ctx context.Context, userID string) (string, error)
+	UpdateUser(user *User) error
+	// GetDataVersion /me ve /groups ETag'lerinin dayandığı sürümdür, bkz. data_version.go
+	GetDataVersion(ctx context.Context, userID string) (int64, error)
+}
+
+// GroupStore gruplar ve üyelik
+type GroupStore interface {
+	CreateGroup(creatorID, groupName string, groupToken string, currency string) (int64, error)
+	getMyGroups(userID string) ([]*Group, error)
+	// getGroupDetails grup yoksa sql.ErrNoRows döner
+	getGroupDetails(groupID, currentUserID string) (*Group, error)
+	isGroupMember(ctx context.Context, groupID int64, userID string) (bool, error)
+	getGroupCreatorID(ctx context.Context, groupID int64) (string, error)
+	getGroupCurrency(ctx context.Context, groupID int64) (string, error)
+}
+
+// AddRequestStore grup ekleme istekleri
+type AddRequestStore interface {
+	// sendAddGroupRequest hesabı olmayan email için istek yerine email daveti açar
+	sendAddGroupRequest(groupID, addedMemberEmail, currentUserID string) (*Group, *EmailInvitation, error)
+	getMyAddRequests(userID string) ([]MyAddRequest, error)
+	acceptAddRequest(requestID int64, userID string) (*AddRequestDecision, error)
+	rejectAddRequest(requestID int64, userID string) (*AddRequestDecision, error)
+	// getGroupOutgoingRequests status boşsa tüm durumlardaki davetleri döner
+	getGroupOutgoingRequests(ctx context.Context, groupID int64, status string) ([]*OutgoingAddRequest, error)
+	cancelAddRequest(ctx context.Context, groupID, requestID int64, actorID string) error
+	// resendAddRequest bekleme süresi dolmadıysa kalan süreyi döner
+	resendAddRequest(ctx context.Context, groupID, requestID int64, actorID string, cooldown time.Duration) (*OutgoingAddRequest, time.Duration, error)
+	expirePendingAddRequests(ctx context.Context, ttlDays int, limit int) (int, error)
+}
+
+// ExpenseStore grup harcamaları ve ödemeler
+type ExpenseStore interface {
+	createGroupExpense(ctx context.Context, payerID string, req CreateExpenseRequest) (*ExpenseWithParticipantsAndBalances, error)
+	PayGroupExpense(userID string, sendedUserID string, groupID int64) error
+	deleteGroupExpense(ctx context.Context, userID string, groupID, expenseID int64) (*ExpenseWithParticipantsAndBalances, error)
+	restoreGroupExpense(ctx context.Context, userID string, groupID, expenseID int64) (*ExpenseWithParticipantsAndBalances, error)
+	getDeletedExpenses(ctx context.Context, groupID int64) ([]DeletedExpense, error)
+	// purgeDeletedExpenses geri alma süresi dolan harcamaları kalıcı olarak siler
+	purgeDeletedExpenses(ctx context.Context, limit int) (int, error)
+}
+
+// ReportStore grup dışa ve içe aktarma
+type ReportStore interface {
+	getGroupReport(ctx context.Context, groupID int64) (*GroupReport, error)
+	getGroupMemberRefs(ctx context.Context, groupID int64) ([]GroupMemberRef, error)
+	commitExpenseImport(ctx context.Context, groupID int64, actorID string, placeholders []string, expenses []importedExpense) ([]int64, map[string]string, error)
+}
+
+// PlaceholderStore hesabı olmayan kişiler için açılan geçici üyeler
+type PlaceholderStore interface {
+	createPlaceholderMember(ctx context.Context, groupID int64, actorID, fullName, contactEmail, contactPhone string) (*PlaceholderMember, error)
+	getGroupPlaceholders(ctx context.Context, groupID int64) ([]PlaceholderMember, error)
+	getClaimablePlaceholders(ctx context.Context, userID string) ([]PlaceholderMember, error)
+	claimPlaceholder(ctx context.Context, placeholderID, userID string) (int64, error)
+}
+
+// InviteStore davet bağlantıları, katılım ayarları ve email davetleri
+type InviteStore interface {
+	addUserToGroupWithToken(userID string, groupToken string) (*InviteJoinResult, error)
+	createInviteLink(ctx context.Context, groupID int64, actorID string, req CreateInviteLinkRequest) (*InviteLink, error)
+	getGroupInviteLinks(ctx context.Context, groupID int64) ([]*InviteLink, error)
+	revokeInviteLink(ctx context.Context, groupID, linkID int64, actorID string) error
+	rotateInviteLink(ctx context.Context, groupID, linkID int64, actorID string) (*InviteLink, error)
+	getGroupJoinSettings(ctx context.Context, groupID int64) (*GroupJoinSettings, error)
+	setGroupJoinApproval(ctx context.Context, actorID string, settings GroupJoinSettings) error
+	// convertEmailInvitations yeni kullanıcının email davetlerini bekleyen isteklere çevirir
+	convertEmailInvitations(ctx context.Context, userID, email string) ([]*EmailInvitation, error)
+}
+
+// FriendStore arkadaşlıklar ve grup dışı harcamalar
+type FriendStore interface {
+	sendFriendRequest(ctx context.Context, requesterID, email string) (int64, string, error)
+	getMyFriendRequests(ctx context.Context, userID string) ([]FriendRequest, error)
+	respondFriendRequest(ctx context.Context, requestID int64, userID string, accept bool) (string, error)
+	areFriends(ctx context.Context, userID, friendID string) (bool, error)
+	removeFriend(ctx context.Context, userID, friendID string) error
+	getCounterpartyBalances(ctx context.Context, userID string) ([]CounterpartyBalance, error)
+	createFriendExpense(ctx context.Context, payerID string, req CreateExpenseRequest) (*ExpenseWithParticipants, error)
+	getFriendExpenses(ctx context.Context, userID, friendID string) ([]ExpenseWithParticipants, error)
+	settleFriendBalance(ctx context.Context, userID, friendID string) (int64, error)
+	deleteFriendExpense(ctx context.Context, userID string, expenseID int64) error
+}
+
+// ActivityStore grup aktivite geçmişi
+type ActivityStore interface {
+	GetGroupActivity(ctx context.Context, groupID int64, limit, offset int) ([]GroupActivity, error)
+}
+
+// ReminderStore ödeme hatırlatmaları
+type ReminderStore interface {
+	GetReminderSettings(ctx context.Context, groupID int64) (*ReminderSettings, error)
+	SaveReminderSettings(ctx context.Context, actorID string, settings ReminderSettings) error
+	getEnabledReminderSettings(ctx context.Context) (map[int64]ReminderSettings, error)
+	getOutstandingDebts(ctx context.Context, groupID int64) ([]OutstandingDebt, error)
+	// reservePaymentReminder bekleme süresi içinde hatırlatma gönderildiyse false döner
+	reservePaymentReminder(ctx context.Context, debt OutstandingDebt, reminderType string, cooldown time.Duration) (bool, error)
+}
+
+// NotificationStore uygulama içi bildirim kutusu
+type NotificationStore interface {
+	SaveNotification(ctx context.Context, userID, title, body string, data map[string]string) (int64, error)
+	GetNotifications(ctx context.Context, userID string, limit, offset int) ([]InboxNotification, error)
+	CountUnreadNotifications(ctx context.Context, userID string) (int, error)
+	MarkNotificationsRead(ctx context.Context, userID string, ids []int64) (int64, error)
+	MarkAllNotificationsRead(ctx context.Context, userID string) (int64, error)
+}
+
+// DeviceStore push bildirimleri için cihaz token'ları
+type DeviceStore interface {
+	SaveFCMToken(userID string, token string) error
+	// GetFCMTokenByUserID token yoksa boş string döner
+	GetFCMTokenByUserID(ctx context.Context, userID string) (string, error)
+}
+
+// IdempotencyStore Idempotency-Key başlığıyla gelen isteklerin kayıtlı yanıtları, bkz. idempotency.go
+type IdempotencyStore interface {
+	// reserveIdempotencyKey anahtarı istek için ayırır ve nil döner. Anahtar kullanılıyorsa mevcut
+	// kaydı döner; süresi dolan ya da yanıtı kaydedilmeden yarıda kalan kaydın yerine yenisi açılır.
+	reserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, now, expiresAt time.Time) (*IdempotentResponse, error)
+	saveIdempotentResponse(ctx context.Context, userID, key string, resp *IdempotentResponse) error
+	// releaseIdempotencyKey ayrılan anahtarı siler; istek tekrar geldiğinde yeniden işlenir
+	releaseIdempotencyKey(ctx context.Context, userID, key string) error
+	purgeExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+// AccountStore hesap silme (bkz. account_deletion.go)
+type AccountStore interface {
+	GetAccountDeletionStatus(ctx context.Context, userID string) (*AccountDeletionStatus, error)
+	requestAccountDeletion(ctx context.Context, userID string, grace time.Duration) (*AccountDeletionStatus, error)
+	restoreAccount(ctx context.Context, userID string) error
+	// getDueAccountDeletions silinme zamanı gelen kullanıcıların ID -> silme durumu eşlemesidir
+	getDueAccountDeletions(ctx context.Context, limit int) (map[string]string, error)
+	anonymizeUser(ctx context.Context, userID string) error
+	completeAccountDeletion(ctx context.Context, userID string, firebaseErr error) error
+}
+
+// DataExportStore kullanıcı verisinin dışa aktarılması (bkz. data_export.go)
+type DataExportStore interface {
+	// createDataExport bekleyen bir dışa aktarma varsa onu ve false döner
+	createDataExport(ctx context.Context, userID string) (*DataExport, bool, error)
+	getDataExports(ctx context.Context, userID string) ([]DataExport, error)
+	getDownloadableDataExport(ctx context.Context, token string) (*DataExport, error)
+	// claimPendingDataExports staleAfter'dan uzun süredir işlenen talepleri de yeniden alır
+	claimPendingDataExports(ctx context.Context, limit int, staleAfter time.Duration) ([]DataExport, error)
+	completeDataExport(ctx context.Context, id int64, token, filePath string, ttl time.Duration) error
+	failDataExport(ctx context.Context, id int64, cause error) error
+	getExpiredDataExports(ctx context.Context, limit int) ([]DataExport, error)
+	markDataExportExpired(ctx context.Context, id int64) error
+	collectUserData(ctx context.Context, userID string) (*UserDataExport, error)
+}
+
+type Repository interface {
+	UserStore
+	GroupStore
+	AddRequestStore
+	ExpenseStore
+	ReportStore
+	PlaceholderStore
+	InviteStore
+	FriendStore
+	ActivityStore
+	ReminderStore
+	NotificationStore
+	DeviceStore
+	IdempotencyStore
+	AccountStore
+	DataExportStore
+}
+
+var _ Repository = (*KasaRepository)(nil)
+
+// MyAddRequest kullanıcıya gelen grup davetidir
+type MyAddRequest struct {
+	RequestID     int64  `json:"request_id"`
+	GroupID       int64  `json:"group_id"`
+	GroupName     string `json:"group_name"`
+	RequestedAt   int64  `json:"requested_at"`
+	RequestStatus string `json:"request_status"`
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxJSONBodyBytes JSON istek gövdelerinin üst sınırıdır; dosya yüklemeleri kendi sınırlarını kullanır
+const maxJSONBodyBytes = 1 << 20
+
+// İstek yapılarının alanları validate etiketiyle doğrulanır, kurallar virgülle ayrılır:
+//
+//	required      boş olamaz; metinde sadece boşluk da boş sayılır, işaretçide nil
+//	min=N, max=N  metinde karakter, dizide eleman sayısı, sayıda değer sınırı
+//	gt=N          sayı N'den büyük olmalı
+//	email         geçerli bir email adresi
+//	oneof=a b     boşlukla ayrılmış değerlerden biri
+//	unique=alan   dizideki nesnelerde alanın (JSON adı) değeri tekrar etmez
+//
+// İsteğe bağlı metin alanı boş gelirse diğer kurallara takılmaz. İşaretçi alanda required
+// alanın gönderilmesini ister, diğer kurallar gönderilen değere uygulanır. İç içe yapılar ve
+// yapı dizileri de doğrulanır; hata anahtarı alanın yoludur (users[1].user_id gibi).
+//
+// Etiketler her tip için ilk doğrulamadan önce bir kez checkTags ile denetlenir; bilinmeyen kural,
+// sayı olmayan sınır ya da tipe uymayan kural istek yolunda panic yerine hata olarak döner.
+// TestValidateTags de istek yapılarının etiketlerini test sırasında denetler.
+
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// jsonIndexPattern encoding/json'ın users.0.amount biçimindeki yolunu users[0].amount'a çevirmek içindir
+var jsonIndexPattern = regexp.MustCompile(`\.(\d+)`)
+
+type fieldError struct {
+	rule  string
+	param string
+}
+
+// decodeJSON isteğin gövdesini dst'ye çözer ve validate etiketlerine göre doğrular. Bilinmeyen
+// alanlar, birden fazla JSON değeri ve maxJSONBodyBytes'ı aşan gövdeler reddedilir; alan
+// hataları error.validation yanıtının details'inde alan başına mesaj olarak döner.
+// Hata yanıtı yazıldıysa false döner.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(dst)
+	if err == nil {
+		var extra json.RawMessage
+		if extraErr := dec.Decode(&extra); extraErr != io.EOF {
+			err = errors.Join(errors.New("gövdede birden fazla JSON değeri var"), extraErr)
+		}
+	}
+
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+	case errors.As(err, &tooLarge):
+		httpError(w, r, "error.body_too_large", http.StatusRequestEntityTooLarge, map[string]string{"limit": strconv.FormatInt(tooLarge.Limit, 10)})
+		return false
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json bilinmeyen alan için ayrı bir hata tipi sunmuyor
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		httpError(w, r, "error.unknown_field", http.StatusBadRequest, map[string]string{"field": field})
+		return false
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		httpError(w, r, "error.validation", http.StatusBadRequest, map[string]string{
+			jsonIndexPattern.ReplaceAllString(typeErr.Field, "[$1]"): T(requestLocale(r), "validation.type", nil),
+		})
+		return false
+	default:
+		httpError(w, r, "error.invalid_json", http.StatusBadRequest, nil)
+		return false
+	}
+
+	errs, err := validateRequest(dst)
+	if err != nil {
+		log.Printf("❌ %T doğrulanamadı: %v", dst, err)
+		httpError(w, r, "error.server", http.StatusInternalServerError, nil)
+		return false
+	}
+	if len(errs) == 0 {
+		return true
+	}
+	locale := requestLocale(r)
+	details := make(map[string]string, len(errs))
+	for field, fe := range errs {
+		details[field] = T(locale, "validation."+fe.rule, map[string]string{"param": fe.param})
+	}
+	httpError(w, r, "error.validation", http.StatusBadRequest, details)
+	return false
+}
+
+// validateRequest v'nin validate etiketlerini uygular, alan yolu → hata döner. Etiketler
+// hatalıysa hiçbir kural uygulanmaz ve hata döner.
+func validateRequest(v interface{}) (map[string]fieldError, error) {
+	if err := cachedCheckTags(reflect.TypeOf(v)); err != nil {
+		return nil, err
+	}
+	errs := map[string]fieldError{}
+	validateNested(reflect.ValueOf(v), "", errs)
+	return errs, nil
+}
+
+// checkedTags tip → checkTags sonucudur; etiketler derlemede sabit olduğundan bir kez denetlenir
+var checkedTags sync.Map
+
+func cachedCheckTags(t reflect.Type) error {
+	if t == nil {
+		return nil
+	}
+	if err, ok := checkedTags.Load(t); ok {
+		if err == nil {
+			return nil
+		}
+		return err.(error)
+	}
+	err := checkTags(t)
+	checkedTags.Store(t, err)
+	return err
+}
+
+// checkTags t'deki ve iç içe yapılarındaki validate etiketlerinin geçerli olduğunu, her kuralın
+// bilindiğini ve alanın tipine uygulanabildiğini kontrol eder
+func checkTags(t reflect.Type) error {
+	return checkTypeTags(t, map[reflect.Type]bool{})
+}
+
+func checkTypeTags(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() || jsonFieldName(sf) == "-" {
+			continue
+		}
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			for _, part := range strings.Split(tag, ",") {
+				rule, param, _ := strings.Cut(part, "=")
+				if err := checkRuleType(ft, rule, param); err != nil {
+					return fmt.Errorf("validate: %s.%s: %w", t, sf.Name, err)
+				}
+			}
+		}
+		if err := checkTypeTags(sf.Type, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRuleType kuralın bilindiğini, parametresinin geçerli olduğunu ve t tipine uygulanabildiğini kontrol eder
+func checkRuleType(t reflect.Type, rule, param string) error {
+	switch rule {
+	case "required":
+	case "min", "max", "gt":
+		if _, err := strconv.ParseFloat(param, 64); err != nil {
+			return fmt.Errorf("%s kuralı için geçersiz sınır %q", rule, param)
+		}
+		if _, ok := measure(reflect.Zero(t)); !ok {
+			return fmt.Errorf("%s kuralı %s tipine uygulanamaz", rule, t)
+		}
+	case "email", "oneof":
+		if t.Kind() != reflect.String {
+			return fmt.Errorf("%s kuralı %s tipine uygulanamaz", rule, t)
+		}
+		if rule == "oneof" && len(strings.Fields(param)) == 0 {
+			return errors.New("oneof kuralı değer içermiyor")
+		}
+	case "unique":
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return fmt.Errorf("unique kuralı %s tipine uygulanamaz", t)
+		}
+		elem := t.Elem()
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			return fmt.Errorf("unique kuralı %s tipine uygulanamaz", t)
+		}
+		if _, ok := jsonField(elem, param); !ok {
+			return fmt.Errorf("%s içinde %q alanı yok", elem, param)
+		}
+	default:
+		return fmt.Errorf("bilinmeyen kural %q", rule)
+	}
+	return nil
+}
+
+func validateNested(v reflect.Value, path string, errs map[string]fieldError) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		validateStruct(v, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+func validateStruct(v reflect.Value, prefix string, errs map[string]fieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := jsonFieldName(sf)
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			if fe, ok := checkRules(fv, tag); !ok {
+				errs[path] = fe
+				continue
+			}
+		}
+		validateNested(fv, path, errs)
+	}
+}
+
+func jsonFieldName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+// jsonField t'de JSON adı name olan alanın indeksini döner
+func jsonField(t reflect.Type, name string) (int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if jsonFieldName(t.Field(i)) == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// checkRules etiketteki kuralları sırayla uygular, ilk başarısız kuralı döner
+func checkRules(v reflect.Value, tag string) (fieldError, bool) {
+	optional := true
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return fieldError{rule: "required"}, !strings.Contains(","+tag+",", ",required,")
+		}
+		v = v.Elem()
+		optional = false
+	}
+
+	for _, part := range strings.Split(tag, ",") {
+		rule, param, _ := strings.Cut(part, "=")
+		if rule == "required" {
+			if optional && isBlank(v) {
+				return fieldError{rule: "required"}, false
+			}
+			continue
+		}
+		if optional && v.Kind() == reflect.String && isBlank(v) {
+			return fieldError{}, true
+		}
+		if ok := checkRule(v, rule, param); !ok {
+			return fieldError{rule: ruleKey(v, rule), param: param}, false
+		}
+	}
+	return fieldError{}, true
+}
+
+func isBlank(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// ruleKey min/max kuralının mesaj anahtarını alan tipine göre seçer
+func ruleKey(v reflect.Value, rule string) string {
+	if rule != "min" && rule != "max" {
+		return rule
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return rule + "_length"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rule + "_items"
+	}
+	return rule
+}
+
+// checkRule kuralı uygular; kuralın ve parametresinin geçerliliği checkTags'te denetlendiğinden
+// uygulanamayan kural başarısız sayılır
+func checkRule(v reflect.Value, rule, param string) bool {
+	switch rule {
+	case "min", "max", "gt":
+		limit, err := strconv.ParseFloat(param, 64)
+		n, ok := measure(v)
+		if err != nil || !ok {
+			return false
+		}
+		switch rule {
+		case "min":
+			return n >= limit
+		case "max":
+			return n <= limit
+		}
+		return n > limit
+	case "email":
+		return emailPattern.MatchString(strings.TrimSpace(v.String()))
+	case "oneof":
+		value := strings.TrimSpace(v.String())
+		for _, allowed := range strings.Fields(param) {
+			if value == allowed {
+				return true
+			}
+		}
+		return false
+	case "unique":
+		return isUnique(v, param)
+	}
+	return false
+}
+
+// measure metnin karakter, dizinin eleman sayısını ya da sayının değerini döner
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(strings.TrimSpace(v.String()))), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// isUnique yapı dizisinde JSON adı field olan alanın değerlerinin tekrar etmediğini kontrol eder;
+// nil elemanlar atlanır
+func isUnique(v reflect.Value, field string) bool {
+	seen := map[string]bool{}
+	for i := 0; i < v.Len(); i++ {
+		item := reflect.Indirect(v.Index(i))
+		if !item.IsValid() {
+			continue
+		}
+		if item.Kind() != reflect.Struct {
+			return false
+		}
+		j, ok := jsonField(item.Type(), field)
+		if !ok {
+			return false
+		}
+		key := fmt.Sprint(item.Field(j).Interface())
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// validatedRequests validate etiketi taşıyan istek yapılarıdır; TestValidateTags paketteki her
+// etiketli yapının burada olduğunu kontrol eder
+var validatedRequests = []interface{}{
+	UserRegisterRequest{}, UserLoginRequest{}, GoogleLoginRequest{}, UpdateUserRequest{},
+	SaveFCMTokenRequest{}, CreateGroupRequest{}, AddGroupRequest{}, CreateExpenseRequest{},
+	ExpenseUser{}, PayGroupExpenseRequest{}, MarkNotificationsReadRequest{}, ReminderSettings{},
+	NudgeDebtorRequest{}, CreatePlaceholderRequest{}, FriendRequestBody{}, CreateInviteLinkRequest{},
+}
+
+// TestValidateTags paketteki validate etiketlerini istek gelmeden denetler: etiketli her yapı
+// isimli bir paket tipi olmalı, validatedRequests'te bulunmalı ve checkTags'ten geçmeli
+func TestValidateTags(t *testing.T) {
+	listed := map[string]reflect.Type{}
+	for _, v := range validatedRequests {
+		typ := reflect.TypeOf(v)
+		listed[typ.Name()] = typ
+		if err := checkTags(typ); err != nil {
+			t.Error(err)
+		}
+	}
+
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		named := map[*ast.StructType]string{}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					if st, ok := ts.Type.(*ast.StructType); ok {
+						named[st] = ts.Name.Name
+					}
+				}
+			}
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			st, ok := n.(*ast.StructType)
+			if !ok || !hasValidateTag(st) {
+				return true
+			}
+			typeName, ok := named[st]
+			switch {
+			case !ok:
+				t.Errorf("%s: validate etiketli yapı paket düzeyinde isimli bir tip olmalı", fset.Position(st.Pos()))
+			case listed[typeName] == nil:
+				t.Errorf("%s: %s validatedRequests'e eklenmeli", fset.Position(st.Pos()), typeName)
+			}
+			return true
+		})
+	}
+}
+
+func hasValidateTag(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if field.Tag != nil && strings.Contains(field.Tag.Value, `validate:"`) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCheckTagsRejectsInvalidRules(t *testing.T) {
+	type item struct {
+		ID string `json:"id"`
+	}
+	cases := []interface{}{
+		struct {
+			Name string `validate:"required,maks=5"`
+		}{},
+		struct {
+			Name string `validate:"max=beş"`
+		}{},
+		struct {
+			Name string `validate:"required,"`
+		}{},
+		struct {
+			Active bool `validate:"min=1"`
+		}{},
+		struct {
+			Count int `validate:"email"`
+		}{},
+		struct {
+			Kind string `validate:"oneof="`
+		}{},
+		struct {
+			Items []item `validate:"unique=name"`
+		}{},
+		struct {
+			Tags []string `validate:"unique=id"`
+		}{},
+		struct {
+			Items []struct {
+				Amount *bool `validate:"gt=0"`
+			}
+		}{},
+	}
+	for _, c := range cases {
+		if err := checkTags(reflect.TypeOf(c)); err == nil {
+			t.Errorf("%T: hata bekleniyordu", c)
+		}
+	}
+
+	valid := struct {
+		Items []*item `validate:"required,max=3,unique=id"`
+		Note  *string `validate:"min=1,max=10"`
+	}{}
+	if err := checkTags(reflect.TypeOf(valid)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDecodeJSONInvalidTag(t *testing.T) {
+	var req struct {
+		Name string `json:"name" validate:"required,maks=5"`
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"name": "Ali"}`))
+	if decodeJSON(w, r, &req) {
+		t.Fatal("hatalı etiketle doğrulama geçmemeliydi")
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("durum %d, beklenen %d", w.Code, http.StatusInternalServerError)
+	}
+}